@@ -0,0 +1,77 @@
+package backtest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+// Annotation marks a point in time on the charts and in the report, e.g. a parameter change
+type Annotation struct {
+	Time  time.Time
+	Label string
+}
+
+// Annotate registers an annotation, which is drawn on the equity and price charts and
+// listed in the report. Annotations are kept when the statistic is reset.
+func (s *Statistic) Annotate(t time.Time, label string) {
+	s.annotations = append(s.annotations, Annotation{Time: t, Label: label})
+	sort.SliceStable(s.annotations, func(i, j int) bool {
+		return s.annotations[i].Time.Before(s.annotations[j].Time)
+	})
+}
+
+// Annotations returns the registered annotations sorted by time
+func (s Statistic) Annotations() []Annotation {
+	return append([]Annotation(nil), s.annotations...)
+}
+
+// annotationLines draws a dashed vertical line with the label for each annotation
+type annotationLines struct {
+	annotations []Annotation
+}
+
+// GetName implements the chart.Series interface
+func (a annotationLines) GetName() string {
+	return "Annotations"
+}
+
+// GetYAxis implements the chart.Series interface
+func (a annotationLines) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+// GetStyle implements the chart.Series interface
+func (a annotationLines) GetStyle() chart.Style {
+	return chart.Style{Show: true}
+}
+
+// Validate implements the chart.Series interface
+func (a annotationLines) Validate() error {
+	return nil
+}
+
+// Render draws the annotations within the x range of the chart
+func (a annotationLines) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	color := drawing.ColorFromHex("7f7f7f")
+	r.SetStrokeColor(color)
+	r.SetStrokeWidth(1)
+	r.SetStrokeDashArray([]float64{4, 4})
+	r.SetFontColor(color)
+	r.SetFontSize(9)
+
+	for _, an := range a.annotations {
+		x := timeToFloat(an.Time)
+		if x < xrange.GetMin() || x > xrange.GetMax() {
+			continue
+		}
+		px := canvasBox.Left + xrange.Translate(x)
+		r.MoveTo(px, canvasBox.Top)
+		r.LineTo(px, canvasBox.Bottom)
+		r.Stroke()
+		r.Text(an.Label, px+3, canvasBox.Top+12)
+	}
+	r.SetStrokeDashArray(nil)
+}
@@ -0,0 +1,57 @@
+package backtest
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// PeriodsDaily is the number of trading days per year
+	PeriodsDaily = 252
+	// PeriodsWeekly is the number of weeks per year
+	PeriodsWeekly = 52
+	// PeriodsMonthly is the number of months per year
+	PeriodsMonthly = 12
+)
+
+// calendarYear is the average length of a calendar year
+const calendarYear = time.Duration(365.25 * 24 * float64(time.Hour))
+
+// SetPeriodsPerYear sets the number of periods per year used to annualize the ratios,
+// e.g. PeriodsDaily for daily bars, 0 detects it from the spacing of the equity points
+func (s *Statistic) SetPeriodsPerYear(periods float64) {
+	s.periodsPerYear = periods
+}
+
+// PeriodsPerYear returns the number of periods per year used to annualize the ratios.
+// Without a set value it is detected from the median spacing of the equity points:
+// daily, weekly and monthly data use trading days, weeks and months, other spacings
+// the number of intervals in a calendar year.
+func (s Statistic) PeriodsPerYear() float64 {
+	if s.periodsPerYear > 0 {
+		return s.periodsPerYear
+	}
+	if len(s.equity) < 2 {
+		return PeriodsDaily
+	}
+
+	intervals := make([]time.Duration, 0, len(s.equity)-1)
+	for i := 1; i < len(s.equity); i++ {
+		intervals = append(intervals, s.equity[i].timestamp.Sub(s.equity[i-1].timestamp))
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	median := intervals[len(intervals)/2]
+
+	day := 24 * time.Hour
+	switch {
+	case median <= 0:
+		return PeriodsDaily
+	case median >= 20*time.Hour && median <= 4*day:
+		return PeriodsDaily
+	case median >= 5*day && median <= 10*day:
+		return PeriodsWeekly
+	case median >= 25*day && median <= 35*day:
+		return PeriodsMonthly
+	}
+	return float64(calendarYear) / float64(median)
+}
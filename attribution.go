@@ -0,0 +1,142 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// untagged is the attribution tag of fills without tags
+const untagged = "untagged"
+
+// attributionBook tracks the cash flows and holdings attributed to a strategy tag
+type attributionBook struct {
+	cash float64            // net cash flow of the fills incl. cost
+	qty  map[string]float64 // qty per symbol, negative on short holdings
+	pnl  []float64          // marked to market profit or loss per equity point
+}
+
+// StrategyStats holds the performance attributed to a strategy via the tags of its signals.
+// Fills with several tags are attributed in equal shares to each tag.
+type StrategyStats struct {
+	Tag     string
+	PnL     float64 // marked to market profit or loss net of fees
+	Return  float64 // PnL relative to the initial cash
+	Sharpe  float64 // annualized Sharp ratio of the attributed returns
+	Fills   int
+	Fees    float64
+	Trades  int // round-trip trades of the fills carrying the tag
+	WinRate float64
+}
+
+// trackAttribution books a fill to the attribution of its tags
+func (s *Statistic) trackAttribution(f FillEvent) {
+	tags := f.GetTags()
+	if len(tags) == 0 {
+		tags = []string{untagged}
+	}
+	share := 1 / float64(len(tags))
+
+	if s.attribution == nil {
+		s.attribution = make(map[string]*attributionBook)
+	}
+	for _, tag := range tags {
+		book, ok := s.attribution[tag]
+		if !ok {
+			// the tag had no profit or loss at the earlier equity points
+			book = &attributionBook{qty: make(map[string]float64), pnl: make([]float64, len(s.equity))}
+			s.attribution[tag] = book
+		}
+
+		switch f.GetDirection() {
+		case "BOT":
+			book.cash -= f.NetValue() * share
+			book.qty[f.GetSymbol()] += f.GetQty() * share
+		case "SLD":
+			book.cash += f.NetValue() * share
+			book.qty[f.GetSymbol()] -= f.GetQty() * share
+		}
+	}
+}
+
+// updateAttribution marks the attributed holdings to the latest prices, replacing the
+// last pnl if the last equity point was replaced
+func (s *Statistic) updateAttribution(replace bool) {
+	for _, book := range s.attribution {
+		// sum in symbol order, so the float rounding doesn't depend on the map order
+		symbols := make([]string, 0, len(book.qty))
+		for symbol := range book.qty {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		pnl := book.cash
+		for _, symbol := range symbols {
+			pnl += book.qty[symbol] * s.prices[symbol]
+		}
+		if replace && len(book.pnl) > 0 {
+			book.pnl[len(book.pnl)-1] = pnl
+			continue
+		}
+		book.pnl = append(book.pnl, pnl)
+	}
+}
+
+// StrategyStatistics returns the performance attributed to each strategy tag, sorted by tag
+func (s Statistic) StrategyStatistics() []StrategyStats {
+	fills := make(map[string][]FillEvent)
+	for _, f := range s.transactionHistory {
+		tags := f.GetTags()
+		if len(tags) == 0 {
+			tags = []string{untagged}
+		}
+		for _, tag := range tags {
+			fills[tag] = append(fills[tag], f)
+		}
+	}
+
+	periods := s.PeriodsPerYear()
+	list := make([]StrategyStats, 0, len(s.attribution))
+	for tag, book := range s.attribution {
+		stats := StrategyStats{Tag: tag, Fills: len(fills[tag])}
+		for _, f := range fills[tag] {
+			share := 1.0
+			if len(f.GetTags()) > 1 {
+				share /= float64(len(f.GetTags()))
+			}
+			stats.Fees += f.GetCost() * share
+		}
+
+		if len(book.pnl) > 0 {
+			stats.PnL = book.pnl[len(book.pnl)-1]
+		}
+		if s.initialCash != 0 {
+			stats.Return = stats.PnL / s.initialCash
+
+			// returns of the attributed pnl relative to the initial cash
+			var returns []float64
+			for i := 1; i < len(book.pnl); i++ {
+				returns = append(returns, (book.pnl[i]-book.pnl[i-1])/s.initialCash)
+			}
+			if mean, stddev := stat.MeanStdDev(returns, nil); stddev > 0 {
+				stats.Sharpe = mean / stddev * math.Sqrt(periods)
+			}
+		}
+
+		trades := pairTrades(fills[tag])
+		for _, t := range trades {
+			if t.PnL > 0 {
+				stats.WinRate++
+			}
+		}
+		stats.Trades = len(trades)
+		if stats.Trades > 0 {
+			stats.WinRate /= float64(stats.Trades)
+		}
+
+		list = append(list, stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tag < list[j].Tag })
+
+	return list
+}
@@ -1,5 +1,22 @@
 package backtest
 
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// PanicPolicy declares how a panic of the strategy is handled
+type PanicPolicy int
+
+const (
+	// PanicSkip skips the data event of the panic and continues the test
+	PanicSkip PanicPolicy = iota
+	// PanicTerminate stops the test and returns the StrategyError from Run
+	PanicTerminate
+)
+
 // DP sets the the precision of rounded floating numbers
 // used after calculations to format
 const DP = 4 // DP
@@ -12,12 +29,63 @@ type Test struct {
 	portfolio  PortfolioHandler
 	exchange   ExecutionHandler
 	statistic  StatisticHandler
-	eventQueue []EventHandler
+	filters    FilterChain
+	regime     *RegimeDetector
+	features   *FeatureExporter
+	dashboard  *Dashboard
+	metrics    *Metrics
+	killSwitch *KillSwitch
+	notifier   *notifier
+	eventQueue EventQueue
+	warnings   []Warning
+	preHooks   []EventHook
+	postHooks  []EventHook
+	logger     Logger
+	stopRules  []StopRule
+	clock      Clock
+	replay     *Replay
+	timers     []*timer
+	stops      *stopTracker // state of the stop rules while running
+	stopReason string       // reason of the early stop of the last run
+	reconcile  *Reconciliation
+	indicators *IndicatorCache // indicator cache shared between tests, nil for one per run
+
+	progress         func(Progress)
+	progressInterval time.Duration
+
+	panicPolicy PanicPolicy
+
+	deterministic bool  // seed the random number generators before each run
+	seed          int64 // seed of the determinism mode
+
+	warmupBars     int           // number of data events to skip before trading
+	warmupDuration time.Duration // duration from the first data event to skip before trading
+	bars           int           // number of processed data events
+	start          time.Time     // time of the first data event
+
+	processed       int    // data events taken from the data stream
+	resumed         bool   // the state was restored from a checkpoint
+	checkpointPath  string // file of the periodic checkpoints
+	checkpointEvery int    // data events between two checkpoints, 0 for none
 }
 
-// New creates a default test backtest value for use.
-func New() *Test {
-	return &Test{}
+// New creates a test configured by the options. The options can be given in any order,
+// the components are wired when the test runs and Validate reports missing ones:
+//
+//	test := backtest.New(
+//		backtest.WithSymbols("AAPL"),
+//		backtest.WithData(data),
+//		backtest.WithStrategy(strategy),
+//		backtest.WithPortfolio(portfolio),
+//		backtest.WithExchange(exchange),
+//		backtest.WithStatistics(statistic),
+//	)
+func New(opts ...Option) *Test {
+	t := &Test{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 // SetSymbols sets the symbols to include into the test
@@ -35,6 +103,43 @@ func (t *Test) SetStrategy(strategy StrategyHandler) {
 	t.strategy = strategy
 }
 
+// SetStrategies combines multiple strategies with the given aggregation policy
+// and sets them as the strategy provider of the test
+func (t *Test) SetStrategies(policy AggregationPolicy, strategies ...StrategyHandler) {
+	t.strategy = NewCompositeStrategy(policy, strategies...)
+}
+
+// SetSymbolStrategies sets a separate strategy for each symbol
+func (t *Test) SetSymbolStrategies(strategies map[string]StrategyHandler) {
+	t.strategy = SymbolStrategies(strategies)
+}
+
+// AddFilter appends signal filters applied between the strategy and the portfolio
+func (t *Test) AddFilter(filters ...SignalFilter) {
+	t.filters = append(t.filters, filters...)
+}
+
+// SetRegimeDetector sets a regime detector, which is updated on every data event
+// before the strategy is called
+func (t *Test) SetRegimeDetector(regime *RegimeDetector) {
+	t.regime = regime
+}
+
+// SetFeatureExporter sets a feature exporter, which writes a feature vector on every data event
+func (t *Test) SetFeatureExporter(features *FeatureExporter) {
+	t.features = features
+}
+
+// SetDashboard sets a live dashboard, which receives the equity and fills while the test runs
+func (t *Test) SetDashboard(dashboard *Dashboard) {
+	t.dashboard = dashboard
+}
+
+// SetMetrics sets a metrics exporter, which counts the events and tracks the portfolio while the test runs
+func (t *Test) SetMetrics(metrics *Metrics) {
+	t.metrics = metrics
+}
+
 // SetPortfolio sets the portfolio provider to to be used within the test
 func (t *Test) SetPortfolio(portfolio PortfolioHandler) {
 	t.portfolio = portfolio
@@ -50,65 +155,318 @@ func (t *Test) SetStatistic(statistic StatisticHandler) {
 	t.statistic = statistic
 }
 
+// SetWarmup sets the number of data events at the start of the test during which
+// data flows to the strategy but no orders are placed and no statistics are recorded
+func (t *Test) SetWarmup(bars int) {
+	t.warmupBars = bars
+}
+
+// SetWarmupDuration sets the duration from the first data event during which
+// data flows to the strategy but no orders are placed and no statistics are recorded
+func (t *Test) SetWarmupDuration(d time.Duration) {
+	t.warmupDuration = d
+}
+
+// SetPanicPolicy sets how a panic of the strategy is handled
+func (t *Test) SetPanicPolicy(policy PanicPolicy) {
+	t.panicPolicy = policy
+}
+
 // Reset rests the backtest into a clean state with loaded data
 func (t *Test) Reset() {
-	t.eventQueue = nil
+	t.eventQueue.Reset()
+	t.bars = 0
+	t.start = time.Time{}
+	t.processed = 0
+	t.resumed = false
 	t.data.Reset()
 	t.portfolio.Reset()
+	if t.regime != nil {
+		t.regime.Reset()
+	}
+	if t.dashboard != nil {
+		t.dashboard.Reset()
+	}
+	if t.metrics != nil {
+		t.metrics.Reset()
+	}
 	t.statistic.Reset()
 	return
 }
 
+// Data returns the data handler of the backtest
+func (t *Test) Data() DataHandler {
+	return t.data
+}
+
 // Stats returns the statistic handler of the backtest
 func (t *Test) Stats() StatisticHandler {
 	return t.statistic
 }
 
-// Run starts the test.
-func (t *Test) Run() error {
-	// before first run, set portfolio cash
-	t.portfolio.SetCash(t.portfolio.InitialCash())
+// PartialResultError is returned by RunContext if the test was cancelled before the end
+// of the data, the statistic holds the results up to the last processed event
+type PartialResultError struct {
+	Time time.Time // time of the last processed event
+	Err  error     // error of the context
+}
+
+// Error implements the error interface
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("test stopped at %s with partial results: %v", e.Time.Format(time.RFC3339), e.Err)
+}
+
+// Unwrap returns the error of the context
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+// Results holds the outcome of a run
+type Results struct {
+	Statistic  StatisticHandler
+	Cash       float64    // cash of the portfolio at the end of the run
+	Value      float64    // value of the portfolio at the end of the run
+	Positions  []Position // positions of the portfolio at the end of the run
+	Warnings   []Warning  // events which failed without stopping the run
+	StopReason string     // reason of a stop rule ending the run early, if any
+}
+
+// Warning is an event which failed without stopping the run, e.g. a rejected order
+type Warning struct {
+	Time    time.Time
+	Symbol  string
+	Message string
+	Stack   string // stack trace of a strategy panic
+}
+
+// Run starts the test and returns the results, which are also returned
+// with partial statistics on an error.
+func (t *Test) Run() (Results, error) {
+	return t.RunContext(context.Background())
+}
+
+// RunContext starts the test and stops it when the context is cancelled or its deadline
+// is exceeded, in which case a PartialResultError is returned
+func (t *Test) RunContext(ctx context.Context) (Results, error) {
+	if err := t.Validate(); err != nil {
+		return Results{}, err
+	}
+	err := t.run(ctx)
+	return t.results(), err
+}
+
+// results returns the outcome of the last run
+func (t *Test) results() Results {
+	return Results{
+		Statistic:  t.statistic,
+		Cash:       t.portfolio.Cash(),
+		Value:      t.portfolio.Value(),
+		Positions:  t.portfolio.Positions(),
+		Warnings:   t.warnings,
+		StopReason: t.stopReason,
+	}
+}
+
+// run processes the events until the data is used up or the context is done
+func (t *Test) run(ctx context.Context) (err error) {
+	// a resumed test continues with the restored cash and warnings
+	t.stopReason = ""
+	t.stops = nil
+	if len(t.stopRules) > 0 {
+		t.stops = &stopTracker{start: time.Now()}
+	}
+	if !t.resumed {
+		t.warnings = nil
+		t.portfolio.SetCash(t.portfolio.InitialCash())
+		t.seedComponents()
+		if r, ok := t.Clock().(Reseter); ok {
+			r.Reset()
+		}
+		for _, tm := range t.timers {
+			tm.next, tm.stopped = time.Time{}, false
+		}
+
+		// interleave the data events of all symbols in time order
+		if s, ok := t.data.(StreamSorter); ok {
+			s.SortStream()
+		}
+	}
+	t.resumed = false
+
+	if t.notifier != nil {
+		t.notifier.start(t.log())
+		defer func() {
+			t.notifier.finish(t.results(), err)
+			t.notifier.stop()
+		}()
+	}
+
+	// the clients of the dashboard are told about the end of the run, also when it fails
+	if t.dashboard != nil {
+		defer t.dashboard.Done()
+	}
+
+	// start streaming live data, the stream ends with the context
+	live, _ := t.data.(Subscriber)
+	if t.killSwitch != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		t.killSwitch.start(cancel, t.exchange, live)
+		defer t.killSwitch.stop()
+	}
+	if live != nil {
+		if err := live.Subscribe(ctx, t.symbols); err != nil {
+			return err
+		}
+	}
+
+	// let the portfolio view the pending orders of the exchange
+	if p, ok := t.portfolio.(OrderBookSetter); ok {
+		p.SetOrderBook(t.exchange)
+	}
+	t.setLoggers()
+	t.setIndicators()
+	t.log().Info("test started", "events", len(t.data.Stream()), "cash", t.portfolio.Cash())
+
+	if t.metrics != nil {
+		t.metrics.Start()
+		defer t.metrics.Stop()
+	}
+
+	var stopped error
+	var last, paced time.Time
+	checkpointed := t.processed
+
+	var progress *progressTracker
+	if t.progress != nil {
+		progress = newProgressTracker(t.progress, t.progressInterval, len(t.data.Stream()))
+		defer progress.done()
+	}
 
 	// poll event queue - set initial event, always proceed (until no more data), get next event each iteration
 	for event, ok := t.nextEvent(); true; event, ok = t.nextEvent() {
+		if err := ctx.Err(); err != nil {
+			stopped = &PartialResultError{Time: last, Err: err}
+			break
+		}
+
 		// no event in queue
 		if !ok {
+			// write a checkpoint between two data events
+			if t.checkpointEvery > 0 && t.processed-checkpointed >= t.checkpointEvery {
+				if err := t.WriteCheckpoint(t.checkpointPath); err != nil {
+					return err
+				}
+				t.log().Debug("checkpoint written", "path", t.checkpointPath, "events", t.processed)
+				checkpointed = t.processed
+			}
+
+			// pace the bars of a replay
+			if t.replay != nil {
+				if err := t.paceReplay(ctx, &paced); err != nil {
+					stopped = &PartialResultError{Time: last, Err: err}
+					break
+				}
+			}
+
 			// poll data stream
 			data, ok := t.data.Next()
 			// no  data event, exit event loop
 			if !ok {
+				// live data ends when the run is cancelled
+				if err := ctx.Err(); err != nil {
+					stopped = &PartialResultError{Time: last, Err: err}
+				}
 				break
 			}
+			t.processed++
+			// skip the data of symbols not included into the test
+			if !t.includes(data.GetSymbol()) {
+				continue
+			}
+			t.fireTimers(data.GetTime())
 			// found data, add to event stream
-			t.eventQueue = append(t.eventQueue, data)
+			t.eventQueue.Append(data)
 			// start new event polling cycle
 			continue
 		}
 
+		t.Clock().Advance(event.GetTime())
+		if err := runHooks(t.preHooks, event); err == ErrSkipEvent {
+			t.log().Debug("event skipped by hook", "event", eventType(event), "symbol", event.GetSymbol(), "time", event.GetTime())
+			continue
+		} else if err != nil {
+			return err
+		}
+
 		// processing event
 		err := t.eventLoop(event)
 		if err != nil {
 			return err
 		}
+		if err := runHooks(t.postHooks, event); err != nil && err != ErrSkipEvent {
+			return err
+		}
 		// event in queue found, add to event history
 		t.statistic.TrackEvent(event)
+		last = event.GetTime()
+		if d, ok := event.(DataEventHandler); ok && progress != nil {
+			progress.update(d, t.portfolio.Value())
+		}
+		if t.metrics != nil {
+			t.metrics.Event(event)
+		}
+		if t.killSwitch != nil {
+			t.killSwitch.event(event)
+		}
+
+		if t.stops != nil {
+			if reason := t.stops.check(t.stopRules, event, t.portfolio); reason != "" {
+				t.stopReason = reason
+				t.log().Info("test stopped early", "time", event.GetTime(), "reason", reason)
+				stopped = &EarlyStopError{Time: event.GetTime(), Reason: reason}
+				break
+			}
+		}
 	}
 
-	return nil
-}
+	if t.killSwitch != nil && t.killSwitch.Halted() != "" {
+		t.stopReason = "killed: " + t.killSwitch.Halted()
+		t.log().Info("test killed", "time", last, "reason", t.killSwitch.Halted())
+		stopped = &EarlyStopError{Time: last, Reason: t.stopReason}
+	}
+	if live != nil && stopped == nil && live.Err() != nil {
+		stopped = fmt.Errorf("live data failed: %v", live.Err())
+	}
+	t.log().Info("test finished", "events", t.processed, "value", t.portfolio.Value(), "warnings", len(t.warnings))
 
-// nextEvent gets the next event from the events queue
-func (t *Test) nextEvent() (e EventHandler, ok bool) {
-	// if event queue empty return false
-	if len(t.eventQueue) == 0 {
-		return e, false
+	// write the feature rows still waiting for their label
+	if t.features != nil {
+		if err := t.features.Flush(); err != nil {
+			return err
+		}
 	}
 
-	// return first element from the event queue
-	e = t.eventQueue[0]
-	t.eventQueue = t.eventQueue[1:]
+	return stopped
+}
 
-	return e, true
+// includes checks if the symbol is included into the test, all symbols are if none are set
+func (t *Test) includes(symbol string) bool {
+	if len(t.symbols) == 0 {
+		return true
+	}
+	for _, s := range t.symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// nextEvent gets the next event from the events queue
+func (t *Test) nextEvent() (e EventHandler, ok bool) {
+	return t.eventQueue.Next()
 }
 
 // eventLoop
@@ -116,41 +474,219 @@ func (t *Test) eventLoop(e EventHandler) error {
 	// type check for event type
 	switch event := e.(type) {
 	case DataEventHandler:
+		warmup := t.isWarmup(event)
+
+		// classify the market regime before the strategy queries it
+		if t.regime != nil {
+			t.regime.Update(event, t.data)
+		}
+
 		// update portfolio to the last known price data
 		t.portfolio.Update(event)
+
+		if t.features != nil {
+			if err := t.features.Export(event, t.data, t.portfolio); err != nil {
+				return err
+			}
+		}
+
+		if warmup {
+			// feed the strategy, but discard its signal
+			_, strategyErr, _ := t.calculateSignal(event)
+			if strategyErr != nil {
+				t.eventQueue.Append(strategyErr)
+			}
+			break
+		}
+
 		// update statistics
 		t.statistic.Update(event, t.portfolio)
+		if t.dashboard != nil {
+			t.dashboard.Update(event, t.portfolio)
+		}
+		if t.metrics != nil {
+			t.metrics.Update(t.portfolio)
+		}
+		if t.notifier != nil {
+			t.notifier.update(event, t.portfolio.Value())
+		}
+
+		// fill pending orders triggered by the data event, unless the exchange is down
+		if !t.outage(event) {
+			fills, err := t.exchange.OnData(event)
+			if err != nil {
+				return err
+			}
+			for _, fill := range fills {
+				t.eventQueue.Append(fill)
+			}
+		}
 
-		signal, err := t.strategy.CalculateSignal(event, t.data, t.portfolio)
+		signal, strategyErr, err := t.calculateSignal(event)
+		if strategyErr != nil {
+			t.eventQueue.Append(strategyErr)
+			break
+		}
 		if err != nil {
 			break
 		}
-		t.eventQueue = append(t.eventQueue, signal)
+		signal, err = t.filter(signal)
+		if err != nil {
+			break
+		}
+		t.eventQueue.Append(signal)
+
+	case *StrategyError:
+		t.log().Error("strategy panicked", "symbol", event.GetSymbol(), "time", event.GetTime(), "panic", event.Panic, "stack", event.Stack)
+		if t.panicPolicy == PanicTerminate {
+			t.statistic.TrackEvent(event)
+			return event
+		}
+		t.warn(event, event)
+
+	case *Discrepancy:
+		t.warn(event, event)
+		if t.reconcile != nil && t.reconcile.AutoCorrect {
+			if err := t.correct(event); err != nil {
+				return err
+			}
+		}
+
+	case CancelOrderEvent:
+		if err := t.exchange.CancelOrder(event); err != nil {
+			t.log().Debug("cancel order failed", "symbol", event.GetSymbol(), "order", event.GetOrderID(), "error", err)
+		}
+
+	case ModifyOrderEvent:
+		if err := t.exchange.ModifyOrder(event); err != nil {
+			t.log().Debug("modify order failed", "symbol", event.GetSymbol(), "order", event.GetOrderID(), "error", err)
+		}
+
+	case BasketSignalEvent:
+		orders, err := t.portfolio.OnBasketSignal(event, t.data)
+		if err != nil {
+			t.warn(event, err)
+			break
+		}
+		for _, order := range orders {
+			t.eventQueue.Append(order)
+		}
 
 	case SignalEvent:
 		order, err := t.portfolio.OnSignal(event, t.data)
 		if err != nil {
+			// signals without direction are the strategy's way of not trading
+			if event.GetDirection() != "" {
+				t.warn(event, err)
+			}
 			break
 		}
-		t.eventQueue = append(t.eventQueue, order)
+		t.eventQueue.Append(order)
 
 	case OrderEvent:
+		if t.outage(event) {
+			t.warn(event, errOutage)
+			break
+		}
 		fill, err := t.exchange.ExecuteOrder(event, t.data)
 		if err != nil {
+			t.warn(event, err)
 			break
 		}
-		t.eventQueue = append(t.eventQueue, fill)
+		if fill == nil {
+			break
+		}
+		t.eventQueue.Append(fill)
 	case FillEvent:
+		if t.stops != nil {
+			t.stops.beforeFill(event, t.portfolio)
+		}
 		transaction, err := t.portfolio.OnFill(event, t.data)
 		if err != nil {
+			t.warn(event, err)
 			break
 		}
+		if t.stops != nil {
+			t.stops.afterFill(event, t.portfolio)
+		}
 		t.statistic.TrackTransaction(transaction)
+		if t.dashboard != nil {
+			t.dashboard.Fill(transaction)
+		}
+		if t.notifier != nil {
+			t.notifier.fill(transaction)
+		}
+		if t.metrics != nil {
+			t.metrics.Update(t.portfolio)
+		}
+		// notify the strategy and the filters about the fill
+		if l, ok := t.strategy.(FillListener); ok {
+			l.OnFill(transaction)
+		}
+		for _, f := range t.filters {
+			if l, ok := f.(FillListener); ok {
+				l.OnFill(transaction)
+			}
+		}
 	}
 
 	return nil
 }
 
+// filter passes a signal through the filters, order management signals are not filtered
+func (t *Test) filter(signal SignalEvent) (SignalEvent, error) {
+	switch signal.(type) {
+	case CancelOrderEvent, ModifyOrderEvent:
+		return signal, nil
+	}
+	return t.filters.Filter(signal, t.data, t.portfolio)
+}
+
+// warn records a failed event as warning of the run
+func (t *Test) warn(e EventHandler, err error) {
+	t.log().Warn("event rejected", "event", eventType(e), "symbol", e.GetSymbol(), "time", e.GetTime(), "error", err)
+	w := Warning{Time: e.GetTime(), Symbol: e.GetSymbol(), Message: err.Error()}
+	if se, ok := err.(*StrategyError); ok {
+		w.Stack = se.Stack
+	}
+	t.warnings = append(t.warnings, w)
+	if t.notifier != nil {
+		t.notifier.warning(w)
+	}
+}
+
+// calculateSignal calls the strategy and recovers from its panics
+func (t *Test) calculateSignal(e DataEventHandler) (signal SignalEvent, strategyErr *StrategyError, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			strategyErr = &StrategyError{
+				Event: Event{Time: e.GetTime(), Symbol: e.GetSymbol()},
+				Panic: r,
+				Stack: string(debug.Stack()),
+			}
+		}
+	}()
+
+	signal, err = t.strategy.CalculateSignal(e, t.data, t.portfolio)
+	return signal, nil, err
+}
+
+// isWarmup counts a data event and checks if it is within the warmup period
+func (t *Test) isWarmup(e DataEventHandler) bool {
+	t.bars++
+	if t.start.IsZero() {
+		t.start = e.GetTime()
+	}
+
+	if t.bars <= t.warmupBars {
+		return true
+	}
+	if t.warmupDuration > 0 && e.GetTime().Sub(t.start) < t.warmupDuration {
+		return true
+	}
+	return false
+}
+
 // Reseter provides a resting interface.
 type Reseter interface {
 	Reset()
@@ -0,0 +1,67 @@
+package backtest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBars returns the bars of a symbol, one per minute from the start of the queue tests
+func testBars(symbol string, bars ...BarData) []DataEventHandler {
+	var stream []DataEventHandler
+	for i, b := range bars {
+		event := Event{Time: queueStart.Add(time.Duration(i) * time.Minute), Symbol: symbol}
+		stream = append(stream, Bar{Event: event, BarData: b})
+	}
+	return stream
+}
+
+// closeBars returns bars of a symbol opening, closing and ranging at the prices
+func closeBars(symbol string, prices ...float64) []DataEventHandler {
+	bars := make([]BarData, len(prices))
+	for i, p := range prices {
+		bars[i] = BarData{Open: p, High: p, Low: p, Close: p}
+	}
+	return testBars(symbol, bars...)
+}
+
+// newTestRun creates a test of the strategy over the data events with 10000 cash
+func newTestRun(stream []DataEventHandler, strategy StrategyHandler, opts ...Option) *Test {
+	data := &Data{}
+	data.SetStream(stream)
+	portfolio := &Portfolio{}
+	portfolio.SetInitialCash(10000)
+	return New(append([]Option{
+		WithSymbols(data.Symbols()...),
+		WithData(data),
+		WithStrategy(strategy),
+		WithPortfolio(portfolio),
+		WithExchange(&Exchange{}),
+		WithStatistics(&Statistic{}),
+	}, opts...)...)
+}
+
+func TestStrategyPanicSkipped(t *testing.T) {
+	strategy := NewBaseStrategy("A", DeciderFunc(func(b *BaseStrategy) (SignalEvent, error) {
+		if b.Event().GetTime().Equal(queueStart.Add(time.Minute)) {
+			panic("bad bar")
+		}
+		return b.Hold(), nil
+	}))
+	test := newTestRun(closeBars("A", 10, 11, 12), strategy)
+
+	results, err := test.Run()
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(results.Warnings) != 1 {
+		t.Fatalf("got %d warnings, want the panic", len(results.Warnings))
+	}
+	w := results.Warnings[0]
+	if !strings.Contains(w.Message, "bad bar") {
+		t.Errorf("warning message is %q, want the panic", w.Message)
+	}
+	if !strings.Contains(w.Stack, "TestStrategyPanicSkipped") {
+		t.Errorf("warning stack doesn't show the panicking strategy:\n%s", w.Stack)
+	}
+}
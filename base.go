@@ -0,0 +1,141 @@
+package backtest
+
+// Decider implements the decision logic of a strategy built on a BaseStrategy
+type Decider interface {
+	Decide(*BaseStrategy) (SignalEvent, error)
+}
+
+// DeciderFunc is an adapter to use ordinary functions as deciders
+type DeciderFunc func(*BaseStrategy) (SignalEvent, error)
+
+// Decide calls f(b)
+func (f DeciderFunc) Decide(b *BaseStrategy) (SignalEvent, error) {
+	return f(b)
+}
+
+// BaseStrategy implements the boilerplate of a strategy, so user strategies
+// only implement the decision logic. It filters the data events for its symbol
+// and provides helpers to query the position and to create signals.
+type BaseStrategy struct {
+	Symbol  string // symbol the strategy trades, empty for all symbols
+	Decider Decider
+
+	event     DataEventHandler
+	data      DataHandler
+	portfolio PortfolioHandler
+}
+
+// NewBaseStrategy creates a base strategy for a symbol with the given decision logic
+func NewBaseStrategy(symbol string, decider Decider) *BaseStrategy {
+	return &BaseStrategy{Symbol: symbol, Decider: decider}
+}
+
+// CalculateSignal calls the decider for data events of the strategy symbol
+func (b *BaseStrategy) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	b.event = de
+	b.data = d
+	b.portfolio = p
+
+	if (b.Symbol != "" && de.GetSymbol() != b.Symbol) || b.Decider == nil {
+		return b.Hold(), nil
+	}
+
+	return b.Decider.Decide(b)
+}
+
+// Event returns the current data event
+func (b *BaseStrategy) Event() DataEventHandler {
+	return b.event
+}
+
+// Data returns the data handler of the test
+func (b *BaseStrategy) Data() DataHandler {
+	return b.data
+}
+
+// Portfolio returns the portfolio handler of the test
+func (b *BaseStrategy) Portfolio() PortfolioHandler {
+	return b.portfolio
+}
+
+// Price returns the latest price of the current data event
+func (b *BaseStrategy) Price() float64 {
+	return b.event.LatestPrice()
+}
+
+// Position returns the open position on the current symbol
+func (b *BaseStrategy) Position() (Position, bool) {
+	return b.portfolio.Position(b.event.GetSymbol())
+}
+
+// IsInvested checks if the portfolio has an open position on the current symbol
+func (b *BaseStrategy) IsInvested() bool {
+	_, ok := b.Position()
+	return ok
+}
+
+// OpenOrders returns the pending orders on the current symbol
+func (b *BaseStrategy) OpenOrders() []Order {
+	return b.portfolio.OpenOrders(b.event.GetSymbol())
+}
+
+// Hold returns a signal without direction, resulting in no order
+func (b *BaseStrategy) Hold() *Signal {
+	return &Signal{Event: Event{Time: b.event.GetTime(), Symbol: b.event.GetSymbol()}}
+}
+
+// Buy returns a signal to buy the given qty of the current symbol, a qty of 0 uses the size handler
+func (b *BaseStrategy) Buy(qty float64) *Signal {
+	signal := b.Hold()
+	signal.SetDirection("buy")
+	signal.SetQty(qty)
+	return signal
+}
+
+// Sell returns a signal to sell the given qty of the current symbol, a qty of 0 uses the size handler
+func (b *BaseStrategy) Sell(qty float64) *Signal {
+	signal := b.Hold()
+	signal.SetDirection("sell")
+	signal.SetQty(qty)
+	return signal
+}
+
+// ClosePosition returns a signal closing the open position on the current symbol
+func (b *BaseStrategy) ClosePosition() *Signal {
+	pos, ok := b.Position()
+	switch {
+	case !ok:
+		return b.Hold()
+	case pos.Qty > 0:
+		return b.Sell(pos.Qty)
+	default:
+		return b.Buy(-pos.Qty)
+	}
+}
+
+// ScaleOut returns a signal closing a fraction of the open position on the current symbol
+func (b *BaseStrategy) ScaleOut(fraction float64) *Signal {
+	pos, ok := b.Position()
+	if !ok {
+		return b.Hold()
+	}
+
+	signal := b.Hold()
+	signal.SetDirection("sell")
+	if pos.Qty < 0 {
+		signal.SetDirection("buy")
+	}
+	signal.Fraction = fraction
+	return signal
+}
+
+// CancelOrder returns a signal canceling a pending order by id, an id of 0 cancels
+// all pending orders on the current symbol
+func (b *BaseStrategy) CancelOrder(id int) *CancelOrder {
+	return &CancelOrder{Signal: *b.Hold(), OrderID: id}
+}
+
+// ModifyOrder returns a signal modifying a pending order by id, zero values are left unchanged
+func (b *BaseStrategy) ModifyOrder(id int, qty, limit, stop float64) *ModifyOrder {
+	return &ModifyOrder{Signal: *b.Hold(), OrderID: id, NewQty: qty, NewLimit: limit, NewStop: stop}
+}
@@ -0,0 +1,122 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// Bootstrap resamples the equity returns in blocks of consecutive periods, which keeps
+// the autocorrelation of the returns within a block
+type Bootstrap struct {
+	Samples    int        // number of resampled return series
+	BlockSize  int        // number of consecutive returns per block, 1 for independent returns
+	Confidence float64    // confidence level of the intervals between 0 and 1, e.g. 0.95
+	Rand       *rand.Rand // random number generator, a randomly seeded one if nil
+}
+
+// ConfidenceInterval holds the bootstrapped distribution of a metric. The p-value is the
+// fraction of samples, shifted to a true value of 0, at or above the estimate.
+type ConfidenceInterval struct {
+	Estimate float64
+	Lower    float64
+	Upper    float64
+	PValue   float64
+}
+
+// BootstrapResult holds the confidence intervals of the bootstrapped metrics
+type BootstrapResult struct {
+	Sharpe ConfidenceInterval
+	CAGR   ConfidenceInterval
+}
+
+// Bootstrap calculates confidence intervals and p-values of the annualized Sharp ratio
+// and the CAGR by block-bootstrap resampling of the equity returns
+func (s Statistic) Bootstrap(b Bootstrap) (BootstrapResult, error) {
+	var result BootstrapResult
+	if b.Samples < 1 {
+		return result, errors.New("bootstrap needs at least one sample")
+	}
+	if b.BlockSize < 1 {
+		b.BlockSize = 1
+	}
+	if b.Confidence <= 0 || b.Confidence >= 1 {
+		return result, errors.New("confidence level must be between 0 and 1")
+	}
+	if len(s.equity) <= b.BlockSize {
+		return result, errors.New("not enough equity points for the block size")
+	}
+
+	returns := make([]float64, 0, len(s.equity)-1)
+	for _, e := range s.equity[1:] {
+		returns = append(returns, e.equityReturn)
+	}
+	years := float64(s.equity[len(s.equity)-1].timestamp.Sub(s.equity[0].timestamp)) / float64(calendarYear)
+	periods := s.PeriodsPerYear()
+
+	r := b.Rand
+	if r == nil {
+		r = newRand()
+	}
+
+	sharpe := make([]float64, 0, b.Samples)
+	cagr := make([]float64, 0, b.Samples)
+	sample := make([]float64, len(returns))
+	for i := 0; i < b.Samples; i++ {
+		// draw blocks with a random start until the sample is filled
+		for n := 0; n < len(sample); {
+			start := r.Intn(len(returns) - b.BlockSize + 1)
+			n += copy(sample[n:], returns[start:start+b.BlockSize])
+		}
+		sharpe = append(sharpe, sharpeOf(sample, periods))
+		cagr = append(cagr, cagrOf(sample, years))
+	}
+
+	result.Sharpe = confidenceInterval(sharpeOf(returns, periods), sharpe, b.Confidence)
+	result.CAGR = confidenceInterval(cagrOf(returns, years), cagr, b.Confidence)
+	return result, nil
+}
+
+// sharpeOf returns the annualized Sharp ratio of returns without risk free rate
+func sharpeOf(returns []float64, periods float64) float64 {
+	mean, stddev := stat.MeanStdDev(returns, nil)
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev * math.Sqrt(periods)
+}
+
+// cagrOf returns the annual growth rate of compounding the returns over the years
+func cagrOf(returns []float64, years float64) float64 {
+	growth := 1.0
+	for _, r := range returns {
+		growth *= 1 + r
+	}
+	if years <= 0 || growth <= 0 {
+		return -1
+	}
+	return math.Pow(growth, 1/years) - 1
+}
+
+// confidenceInterval builds the percentile interval and p-value of the samples around the estimate
+func confidenceInterval(estimate float64, samples []float64, confidence float64) ConfidenceInterval {
+	sort.Float64s(samples)
+	ci := ConfidenceInterval{
+		Estimate: estimate,
+		Lower:    stat.Quantile((1-confidence)/2, stat.Empirical, samples, nil),
+		Upper:    stat.Quantile(1-(1-confidence)/2, stat.Empirical, samples, nil),
+	}
+
+	// the samples minus the estimate approximate the distribution under a true value of 0
+	var extreme int
+	for _, v := range samples {
+		if v-estimate >= estimate {
+			extreme++
+		}
+	}
+	ci.PValue = float64(extreme) / float64(len(samples))
+	return ci
+}
@@ -0,0 +1,541 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Broker is the interface of a live trading venue. A BrokerExchange routes the orders of a
+// test to a broker, so the strategies and portfolios of a backtest trade live unchanged.
+type Broker interface {
+	// SubmitOrder places an order and returns its ID at the broker
+	SubmitOrder(context.Context, *Order) (string, error)
+	// CancelOrder cancels an open order by its ID at the broker
+	CancelOrder(context.Context, string) error
+	// Updates streams the fills and state changes of the placed orders
+	Updates() <-chan OrderUpdate
+	// Positions returns the positions held at the broker
+	Positions(context.Context) ([]Position, error)
+	// Balances returns the cash and asset balances of the account
+	Balances(context.Context) ([]Balance, error)
+}
+
+// OrderModifier is the optional interface of brokers changing open orders in place
+type OrderModifier interface {
+	ModifyOrder(ctx context.Context, id string, o *Order) error
+}
+
+// BracketSubmitter is the optional interface of brokers placing the stop loss and take
+// profit of an entry as one order cancelling the other, e.g. the OCO orders of spot
+// exchanges, which can't place two orders selling the same qty. The broker cancels the
+// other order when one is filled and reports it as cancelled.
+type BracketSubmitter interface {
+	SubmitBracket(ctx context.Context, stop, profit *Order) (stopID, profitID string, err error)
+}
+
+// OrderStatus is the state of an order at a broker
+type OrderStatus string
+
+const (
+	// OrderOpen orders wait to be filled
+	OrderOpen OrderStatus = "open"
+	// OrderPartiallyFilled orders are filled in part and wait for the rest
+	OrderPartiallyFilled OrderStatus = "partially_filled"
+	// OrderFilled orders are completely filled
+	OrderFilled OrderStatus = "filled"
+	// OrderCancelled orders were cancelled or expired before they were filled
+	OrderCancelled OrderStatus = "cancelled"
+	// OrderRejected orders were not accepted by the broker
+	OrderRejected OrderStatus = "rejected"
+)
+
+// Done returns true if the order will not be filled anymore
+func (s OrderStatus) Done() bool {
+	return s == OrderFilled || s == OrderCancelled || s == OrderRejected
+}
+
+// OrderUpdate is a change of an order reported by a broker
+type OrderUpdate struct {
+	OrderID string      // ID of the order at the broker
+	Status  OrderStatus // state of the order after the update
+	Fill    *Fill       // fill of the update with the filled qty, nil if nothing was filled
+	Reason  string      // reason of a cancellation or rejection
+}
+
+// Balance is the balance of an asset or currency of a broker account
+type Balance struct {
+	Asset  string
+	Free   float64 // available for trading
+	Locked float64 // reserved by open orders
+}
+
+// Total returns the free and locked balance
+func (b Balance) Total() float64 {
+	return b.Free + b.Locked
+}
+
+// defaultBrokerTimeout limits the requests of a BrokerExchange to its broker
+const defaultBrokerTimeout = 10 * time.Second
+
+// BrokerExchange is an execution handler placing the orders of a test at a broker. Orders
+// are acknowledged without a fill, the fills reported by the broker are returned with the
+// next data event like the fills of pending orders of the simulated exchange. Stop loss and
+// take profit are placed as orders when their entry is filled, and the remaining order of
+// a bracket is cancelled when the other one is filled. Orders over the rate limit are
+// rejected before they reach the broker.
+type BrokerExchange struct {
+	broker  Broker
+	timeout time.Duration
+	logger  Logger
+	limiter *rateLimiter
+
+	placing sync.Mutex // serializes the placing of orders with a halt
+
+	mu     sync.Mutex
+	halted bool // orders are rejected after a halt
+	lastID int
+	orders map[int]*brokerOrder // open orders by ID of the test
+	ids    map[string]int       // IDs of the test by ID at the broker
+}
+
+// brokerOrder is an open order placed at a broker
+type brokerOrder struct {
+	order  *Order
+	id     string  // ID at the broker
+	filled float64 // filled qty
+	last   *Fill   // last fill
+	oco    bool    // the broker cancels the other order of the bracket
+}
+
+// NewBrokerExchange creates an execution handler placing the orders at the broker, within
+// the default rate limit of the broker if it implements the RateLimiter interface
+func NewBrokerExchange(b Broker) *BrokerExchange {
+	e := &BrokerExchange{
+		broker:  b,
+		timeout: defaultBrokerTimeout,
+		orders:  make(map[int]*brokerOrder),
+		ids:     make(map[string]int),
+	}
+	if r, ok := b.(RateLimiter); ok {
+		e.SetRateLimit(r.RateLimit())
+	}
+	return e
+}
+
+// Broker returns the broker of the exchange
+func (e *BrokerExchange) Broker() Broker {
+	return e.broker
+}
+
+// SetTimeout sets the timeout of the requests to the broker, 10 seconds by default
+func (e *BrokerExchange) SetTimeout(d time.Duration) {
+	e.timeout = d
+}
+
+// SetRateLimit sets the limit of the orders placed at the broker. The stop loss and take
+// profit of a filled entry count towards the limit but are never rejected.
+func (e *BrokerExchange) SetRateLimit(l RateLimit) {
+	e.limiter = newRateLimiter(l)
+}
+
+// SetLogger implements the LoggerSetter interface, the logger is also set on the broker
+func (e *BrokerExchange) SetLogger(l Logger) {
+	e.logger = l
+	if s, ok := e.broker.(LoggerSetter); ok {
+		s.SetLogger(l)
+	}
+}
+
+// ExecuteOrder places an order at the broker, its fills are returned by OnData
+func (e *BrokerExchange) ExecuteOrder(order OrderEvent, data DataHandler) (*Fill, error) {
+	o, ok := order.(*Order)
+	if !ok {
+		return nil, errors.New("could not execute order, unknown order type")
+	}
+	if o.Qty <= 0 {
+		return nil, fmt.Errorf("could not execute order, invalid qty %v", o.Qty)
+	}
+	return nil, e.submit(o)
+}
+
+// submit places an order at the broker and tracks it as open, unless the exchange is
+// halted. A halt waits for the order, which is then cancelled with the open orders.
+func (e *BrokerExchange) submit(o *Order) error {
+	e.placing.Lock()
+	defer e.placing.Unlock()
+	if e.isHalted() {
+		return errors.New("exchange halted")
+	}
+	return e.place(o, o.ParentID != 0)
+}
+
+// isHalted checks if the exchange is halted
+func (e *BrokerExchange) isHalted() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.halted
+}
+
+// place places an order at the broker and tracks it as open, forced orders are not
+// rejected by the rate limit
+func (e *BrokerExchange) place(o *Order, force bool) error {
+	e.mu.Lock()
+	if o.ID == 0 {
+		e.lastID++
+		o.ID = e.lastID
+	}
+	e.mu.Unlock()
+
+	if e.limiter != nil {
+		if err := e.limiter.allow(o.GetSymbol(), time.Now(), force); err != nil {
+			logger(e.logger).Warn("order throttled", "id", o.ID, "symbol", o.GetSymbol(), "error", err)
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	id, err := e.broker.SubmitOrder(ctx, o)
+	if err != nil {
+		return fmt.Errorf("broker rejected order: %v", err)
+	}
+
+	e.mu.Lock()
+	e.orders[o.ID] = &brokerOrder{order: o, id: id}
+	e.ids[id] = o.ID
+	e.mu.Unlock()
+	logger(e.logger).Info("order placed", "id", o.ID, "broker_id", id, "type", o.OrderType, "symbol", o.GetSymbol(), "direction", o.Direction, "qty", o.Qty)
+	return nil
+}
+
+// OnData returns the fills reported by the broker since the last call and expires the
+// open orders of the symbol of the data event after their time to live
+func (e *BrokerExchange) OnData(data DataEventHandler) ([]*Fill, error) {
+	var fills []*Fill
+	for {
+		select {
+		case u, ok := <-e.broker.Updates():
+			if !ok {
+				return fills, errors.New("broker closed the order updates")
+			}
+			if f := e.update(u); f != nil {
+				fills = append(fills, f)
+			}
+			continue
+		default:
+		}
+		break
+	}
+	e.expire(data)
+	return fills, nil
+}
+
+// update applies an order update and returns its fill
+func (e *BrokerExchange) update(u OrderUpdate) *Fill {
+	e.mu.Lock()
+	bo, ok := e.orders[e.ids[u.OrderID]]
+	if !ok {
+		e.mu.Unlock()
+		logger(e.logger).Warn("update of unknown order", "broker_id", u.OrderID, "status", u.Status)
+		return nil
+	}
+	o := bo.order
+	if u.Fill != nil {
+		bo.filled += u.Fill.Qty
+		bo.last = u.Fill
+	}
+	if u.Status.Done() {
+		delete(e.orders, o.ID)
+		delete(e.ids, u.OrderID)
+	}
+	e.mu.Unlock()
+
+	switch u.Status {
+	case OrderCancelled, OrderRejected:
+		logger(e.logger).Warn("order "+string(u.Status), "id", o.ID, "symbol", o.GetSymbol(), "reason", u.Reason, "filled", bo.filled)
+		// protect the partially filled qty of an entry
+		if o.ParentID == 0 {
+			e.addBracket(o, bo.last, bo.filled)
+		}
+	case OrderFilled:
+		e.addBracket(o, bo.last, bo.filled)
+		if o.ParentID != 0 && !bo.oco {
+			e.cancelBracket(o)
+		}
+	}
+	if u.Fill == nil {
+		return nil
+	}
+
+	f := *u.Fill
+	if f.Symbol == "" {
+		f.Symbol = o.GetSymbol()
+	}
+	if f.Time.IsZero() {
+		f.Time = time.Now()
+	}
+	if f.Direction == "" {
+		f.Direction = "BOT"
+		if o.Direction == "sell" {
+			f.Direction = "SLD"
+		}
+	}
+	if f.Cost == 0 {
+		f.Cost = f.Commission + f.ExchangeFee
+	}
+	f.Tags, f.OrderType, f.Arrival = o.Tags, o.OrderType, o.Arrival
+	logger(e.logger).Debug("order filled", "id", o.ID, "symbol", f.Symbol, "direction", f.Direction, "qty", f.Qty, "price", f.Price)
+	return &f
+}
+
+// addBracket places the stop loss and take profit orders of a filled entry
+func (e *BrokerExchange) addBracket(entry *Order, last *Fill, filled float64) {
+	if (entry.StopLoss == 0 && entry.TakeProfit == 0) || filled == 0 {
+		return
+	}
+	direction := "sell"
+	if entry.Direction == "sell" {
+		direction = "buy"
+	}
+	var price float64
+	if last != nil {
+		price = last.Price
+	}
+	child := Order{
+		Event:     Event{Time: entry.GetTime(), Symbol: entry.GetSymbol()},
+		ParentID:  entry.ID,
+		Direction: direction,
+		Qty:       filled,
+		Tags:      entry.Tags,
+		Arrival:   price,
+	}
+	if b, ok := e.broker.(BracketSubmitter); ok && entry.StopLoss != 0 && entry.TakeProfit != 0 {
+		stop, profit := child, child
+		stop.OrderType, stop.Stop = "STP", entry.StopLoss
+		profit.OrderType, profit.Limit = "LMT", entry.TakeProfit
+		if err := e.placeBracket(b, &stop, &profit); err != nil {
+			logger(e.logger).Error("bracket not placed", "parent", entry.ID, "symbol", entry.GetSymbol(), "error", err)
+		}
+		return
+	}
+	if entry.StopLoss != 0 {
+		stop := child
+		stop.OrderType, stop.Stop = "STP", entry.StopLoss
+		if err := e.submit(&stop); err != nil {
+			logger(e.logger).Error("stop loss not placed", "parent", entry.ID, "symbol", entry.GetSymbol(), "error", err)
+		}
+	}
+	if entry.TakeProfit != 0 {
+		profit := child
+		profit.OrderType, profit.Limit = "LMT", entry.TakeProfit
+		if err := e.submit(&profit); err != nil {
+			logger(e.logger).Error("take profit not placed", "parent", entry.ID, "symbol", entry.GetSymbol(), "error", err)
+		}
+	}
+}
+
+// placeBracket places the stop loss and take profit of an entry as one order at the broker
+// and tracks both as open
+func (e *BrokerExchange) placeBracket(b BracketSubmitter, stop, profit *Order) error {
+	e.placing.Lock()
+	defer e.placing.Unlock()
+	e.mu.Lock()
+	halted := e.halted
+	for _, o := range []*Order{stop, profit} {
+		e.lastID++
+		o.ID = e.lastID
+	}
+	e.mu.Unlock()
+	if halted {
+		return errors.New("exchange halted")
+	}
+	if e.limiter != nil {
+		if err := e.limiter.allow(stop.GetSymbol(), time.Now(), true); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	stopID, profitID, err := b.SubmitBracket(ctx, stop, profit)
+	if err != nil {
+		return fmt.Errorf("broker rejected bracket: %v", err)
+	}
+
+	e.mu.Lock()
+	e.orders[stop.ID] = &brokerOrder{order: stop, id: stopID, oco: true}
+	e.ids[stopID] = stop.ID
+	e.orders[profit.ID] = &brokerOrder{order: profit, id: profitID, oco: true}
+	e.ids[profitID] = profit.ID
+	e.mu.Unlock()
+	logger(e.logger).Info("bracket placed", "parent", stop.ParentID, "stop_id", stopID, "profit_id", profitID, "symbol", stop.GetSymbol(), "qty", stop.Qty, "stop", stop.Stop, "limit", profit.Limit)
+	return nil
+}
+
+// cancelBracket cancels the open orders of the bracket of a filled order
+func (e *BrokerExchange) cancelBracket(filled *Order) {
+	e.mu.Lock()
+	var ids []string
+	for _, bo := range e.orders {
+		if bo.order.ParentID == filled.ParentID {
+			ids = append(ids, bo.id)
+		}
+	}
+	e.mu.Unlock()
+	for _, id := range ids {
+		if err := e.cancel(id); err != nil {
+			logger(e.logger).Error("bracket order not cancelled", "broker_id", id, "parent", filled.ParentID, "error", err)
+		}
+	}
+}
+
+// expire counts the bars of the open orders of the symbol and cancels the expired ones
+func (e *BrokerExchange) expire(data DataEventHandler) {
+	e.mu.Lock()
+	var ids []string
+	for _, bo := range e.orders {
+		o := bo.order
+		if o.GetSymbol() != data.GetSymbol() || o.TTL == 0 {
+			continue
+		}
+		o.bars++
+		if o.bars >= o.TTL {
+			ids = append(ids, bo.id)
+		}
+	}
+	e.mu.Unlock()
+	for _, id := range ids {
+		if err := e.cancel(id); err != nil {
+			logger(e.logger).Error("expired order not cancelled", "broker_id", id, "error", err)
+		}
+	}
+}
+
+// PendingOrders returns the open orders placed at the broker
+func (e *BrokerExchange) PendingOrders() []*Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	orders := make([]*Order, 0, len(e.orders))
+	for _, bo := range e.orders {
+		orders = append(orders, bo.order)
+	}
+	sort.Slice(orders, func(i, k int) bool { return orders[i].ID < orders[k].ID })
+	return orders
+}
+
+// CancelOrder cancels open orders by id or all open orders of the symbol. The orders are
+// removed when the broker confirms the cancellation.
+func (e *BrokerExchange) CancelOrder(c CancelOrderEvent) error {
+	var found bool
+	for _, bo := range e.matching(c.GetOrderID(), c.GetSymbol()) {
+		found = true
+		if err := e.cancel(bo.id); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errors.New("no pending order to cancel")
+	}
+	return nil
+}
+
+// ModifyOrder changes open orders by id or all open orders of the symbol, if the broker
+// implements the OrderModifier interface
+func (e *BrokerExchange) ModifyOrder(m ModifyOrderEvent) error {
+	modifier, ok := e.broker.(OrderModifier)
+	if !ok {
+		return errors.New("broker can't modify orders")
+	}
+	matching := e.matching(m.GetOrderID(), m.GetSymbol())
+	if len(matching) == 0 {
+		return errors.New("no pending order to modify")
+	}
+	for _, bo := range matching {
+		modified := *bo.order
+		m.Modify(&modified)
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		err := modifier.ModifyOrder(ctx, bo.id, &modified)
+		cancel()
+		if err != nil {
+			return err
+		}
+		e.mu.Lock()
+		*bo.order = modified
+		e.mu.Unlock()
+	}
+	return nil
+}
+
+// matching returns the open orders with the id, or of the symbol if the id is 0
+func (e *BrokerExchange) matching(id int, symbol string) []*brokerOrder {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var orders []*brokerOrder
+	for _, bo := range e.orders {
+		if matchOrder(bo.order, id, symbol) {
+			orders = append(orders, bo)
+		}
+	}
+	return orders
+}
+
+// cancel cancels an order at the broker
+func (e *BrokerExchange) cancel(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	return e.broker.CancelOrder(ctx, id)
+}
+
+// Halt stops the trading at the broker: the open orders are cancelled, the positions at the
+// broker are closed with market orders and new orders are rejected. All orders are tried,
+// the first error is returned.
+func (e *BrokerExchange) Halt(ctx context.Context) error {
+	// wait for the orders being placed, they are cancelled with the open orders
+	e.placing.Lock()
+	e.mu.Lock()
+	e.halted = true
+	ids := make([]string, 0, len(e.orders))
+	for _, bo := range e.orders {
+		ids = append(ids, bo.id)
+	}
+	e.mu.Unlock()
+	e.placing.Unlock()
+
+	var first error
+	for _, id := range ids {
+		if err := e.broker.CancelOrder(ctx, id); err != nil {
+			logger(e.logger).Error("order not cancelled on halt", "broker_id", id, "error", err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	positions, err := e.broker.Positions(ctx)
+	if err != nil {
+		if first == nil {
+			first = err
+		}
+		return first
+	}
+	for _, p := range positions {
+		if p.Qty == 0 {
+			continue
+		}
+		o := &Order{Event: Event{Time: time.Now(), Symbol: p.Symbol}, OrderType: "MKT", Direction: "sell", Qty: p.Qty}
+		if p.Qty < 0 {
+			o.Direction, o.Qty = "buy", -p.Qty
+		}
+		if err := e.place(o, true); err != nil {
+			logger(e.logger).Error("position not closed on halt", "symbol", p.Symbol, "qty", p.Qty, "error", err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	logger(e.logger).Warn("exchange halted", "cancelled", len(ids), "positions", len(positions))
+	return first
+}
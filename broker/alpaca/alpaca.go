@@ -0,0 +1,292 @@
+// Package alpaca implements the backtest.Broker interface for Alpaca, on the paper or the
+// live trading endpoint. Fills are streamed from the trade updates of the account.
+//
+//	b := alpaca.New(keyID, secret, true) // paper trading
+//	if err := b.Connect(ctx); err != nil {
+//		return err
+//	}
+//	defer b.Close()
+//	test := backtest.New(backtest.WithBroker(b), ...)
+//
+// Market orders are day orders, limit and stop orders are good till cancelled unless
+// their qty is fractional, which Alpaca only accepts for day orders.
+package alpaca
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+const (
+	liveREST    = "https://api.alpaca.markets"
+	paperREST   = "https://paper-api.alpaca.markets"
+	updatesSize = 1024
+)
+
+// Broker is an Alpaca trading account
+type Broker struct {
+	keyID, secret string
+	rest          string // base URL of the trading API
+	stream        string // URL of the trade updates stream
+	client        *http.Client
+	logger        backtest.Logger
+
+	updates chan backtest.OrderUpdate
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a broker for the API key, on the paper trading endpoint if paper is true
+func New(keyID, secret string, paper bool) *Broker {
+	b := &Broker{
+		keyID:   keyID,
+		secret:  secret,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		updates: make(chan backtest.OrderUpdate, updatesSize),
+		logger:  backtest.NewLogger(ioutil.Discard, backtest.LevelError),
+	}
+	b.SetBaseURL(liveREST)
+	if paper {
+		b.SetBaseURL(paperREST)
+	}
+	return b
+}
+
+// SetBaseURL sets the base URL of the trading API, the trade updates are streamed from
+// its /stream path
+func (b *Broker) SetBaseURL(rest string) {
+	b.rest = strings.TrimRight(rest, "/")
+	b.stream = "ws" + strings.TrimPrefix(b.rest, "http") + "/stream"
+}
+
+// SetLogger implements the backtest.LoggerSetter interface
+func (b *Broker) SetLogger(l backtest.Logger) {
+	b.logger = l
+}
+
+// RateLimit implements the backtest.RateLimiter interface, within the 200 requests per
+// minute of the API with room for cancellations and account requests
+func (b *Broker) RateLimit() backtest.RateLimit {
+	return backtest.RateLimit{PerSecond: 2, Burst: 10, PerMinute: 100}
+}
+
+// Connect checks the account and starts streaming the trade updates, it must be called
+// before the first order
+func (b *Broker) Connect(ctx context.Context) error {
+	var account struct {
+		Status         string `json:"status"`
+		TradingBlocked bool   `json:"trading_blocked"`
+		AccountBlocked bool   `json:"account_blocked"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/v2/account", nil, &account); err != nil {
+		return err
+	}
+	if account.TradingBlocked || account.AccountBlocked {
+		return fmt.Errorf("alpaca: trading is blocked for the account, status %s", account.Status)
+	}
+
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	streamCtx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	go b.runStream(streamCtx, conn)
+	return nil
+}
+
+// Close stops the trade updates stream and closes the updates
+func (b *Broker) Close() error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	<-b.done
+	b.cancel = nil
+	close(b.updates)
+	return nil
+}
+
+// Updates implements the backtest.Broker interface
+func (b *Broker) Updates() <-chan backtest.OrderUpdate {
+	return b.updates
+}
+
+// orderRequest is the body of a new order
+type orderRequest struct {
+	Symbol      string `json:"symbol"`
+	Qty         string `json:"qty"`
+	Side        string `json:"side"`
+	Type        string `json:"type"`
+	TimeInForce string `json:"time_in_force"`
+	LimitPrice  string `json:"limit_price,omitempty"`
+	StopPrice   string `json:"stop_price,omitempty"`
+}
+
+// SubmitOrder implements the backtest.Broker interface
+func (b *Broker) SubmitOrder(ctx context.Context, o *backtest.Order) (string, error) {
+	req := orderRequest{
+		Symbol:      o.GetSymbol(),
+		Qty:         formatFloat(o.Qty),
+		Side:        o.Direction,
+		TimeInForce: "gtc",
+	}
+	if o.Direction != "buy" && o.Direction != "sell" {
+		return "", fmt.Errorf("alpaca: invalid direction %q", o.Direction)
+	}
+	switch o.OrderType {
+	case "MKT":
+		req.Type, req.TimeInForce = "market", "day"
+	case "LMT":
+		req.Type, req.LimitPrice = "limit", formatPrice(o.Limit)
+	case "STP":
+		req.Type, req.StopPrice = "stop", formatPrice(o.Stop)
+	default:
+		return "", fmt.Errorf("alpaca: unsupported order type %q", o.OrderType)
+	}
+	if o.Qty != math.Trunc(o.Qty) {
+		req.TimeInForce = "day"
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := b.do(ctx, http.MethodPost, "/v2/orders", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// CancelOrder implements the backtest.Broker interface
+func (b *Broker) CancelOrder(ctx context.Context, id string) error {
+	return b.do(ctx, http.MethodDelete, "/v2/orders/"+id, nil, nil)
+}
+
+// Positions implements the backtest.Broker interface
+func (b *Broker) Positions(ctx context.Context) ([]backtest.Position, error) {
+	var resp []struct {
+		Symbol        string `json:"symbol"`
+		Qty           string `json:"qty"`
+		Side          string `json:"side"`
+		AvgEntryPrice string `json:"avg_entry_price"`
+		CurrentPrice  string `json:"current_price"`
+		MarketValue   string `json:"market_value"`
+		CostBasis     string `json:"cost_basis"`
+		UnrealizedPL  string `json:"unrealized_pl"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/v2/positions", nil, &resp); err != nil {
+		return nil, err
+	}
+	positions := make([]backtest.Position, 0, len(resp))
+	for _, p := range resp {
+		qty := parseFloat(p.Qty)
+		if p.Side == "short" && qty > 0 {
+			qty = -qty
+		}
+		positions = append(positions, backtest.Position{
+			Timestamp:        time.Now(),
+			Symbol:           p.Symbol,
+			Qty:              qty,
+			AvgPrice:         parseFloat(p.AvgEntryPrice),
+			AvgPriceNet:      parseFloat(p.AvgEntryPrice),
+			MarketPrice:      parseFloat(p.CurrentPrice),
+			MarketValue:      parseFloat(p.MarketValue),
+			CostBasis:        math.Abs(parseFloat(p.CostBasis)),
+			UnrealProfitLoss: parseFloat(p.UnrealizedPL),
+			TotalProfitLoss:  parseFloat(p.UnrealizedPL),
+		})
+	}
+	return positions, nil
+}
+
+// Balances implements the backtest.Broker interface and returns the cash of the account
+// in its currency
+func (b *Broker) Balances(ctx context.Context) ([]backtest.Balance, error) {
+	var account struct {
+		Currency string `json:"currency"`
+		Cash     string `json:"cash"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/v2/account", nil, &account); err != nil {
+		return nil, err
+	}
+	return []backtest.Balance{{Asset: account.Currency, Free: parseFloat(account.Cash)}}, nil
+}
+
+// do sends a request with the JSON body, if not nil, to the trading API and decodes the
+// JSON response into v, if not nil
+func (b *Broker) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var buf []byte
+	if body != nil {
+		var err error
+		if buf, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, b.rest+path, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("APCA-API-KEY-ID", b.keyID)
+	req.Header.Set("APCA-API-SECRET-KEY", b.secret)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("alpaca: %s (code %d)", apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("alpaca: %s %s: %s", method, path, resp.Status)
+	}
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("alpaca: invalid response of %s: %v", path, err)
+	}
+	return nil
+}
+
+// formatFloat formats a qty without exponent
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatPrice formats a price with the sub-penny precision Alpaca accepts, 2 decimals
+// from 1 dollar on and 4 below
+func formatPrice(v float64) string {
+	if v < 1 {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// parseFloat parses a number of the API, 0 if empty or invalid
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
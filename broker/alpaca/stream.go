@@ -0,0 +1,180 @@
+package alpaca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// tradeUpdate is an order event of the trade updates stream
+type tradeUpdate struct {
+	Event     string    `json:"event"`
+	Price     string    `json:"price"`
+	Qty       string    `json:"qty"`
+	Timestamp time.Time `json:"timestamp"`
+	Order     struct {
+		ID     string `json:"id"`
+		Symbol string `json:"symbol"`
+		Side   string `json:"side"`
+	} `json:"order"`
+}
+
+// message is a message of the stream
+type message struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// dial connects to the trade updates stream, authenticates and listens to the updates
+func (b *Broker) dial(ctx context.Context) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, b.stream, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	auth := map[string]interface{}{"action": "auth", "key": b.keyID, "secret": b.secret}
+	if err := conn.WriteJSON(auth); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var m message
+	if err := readJSON(conn, &m); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var status struct {
+		Status string `json:"status"`
+	}
+	json.Unmarshal(m.Data, &status)
+	if m.Stream != "authorization" || status.Status != "authorized" {
+		conn.Close()
+		return nil, fmt.Errorf("alpaca: stream not authorized: %s", m.Data)
+	}
+
+	listen := map[string]interface{}{"action": "listen", "data": map[string][]string{"streams": {"trade_updates"}}}
+	if err := conn.WriteJSON(listen); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+	return conn, nil
+}
+
+// readJSON reads a message, the stream sends JSON in text or binary frames
+func readJSON(conn *websocket.Conn, v interface{}) error {
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, v)
+}
+
+// runStream reads the trade updates until the context is done and reconnects when the
+// connection drops
+func (b *Broker) runStream(ctx context.Context, conn *websocket.Conn) {
+	defer close(b.done)
+	backoff := time.Second
+	for {
+		err := b.readStream(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn("trade updates reconnecting, fills in between are missed", "error", err, "backoff", backoff)
+		for {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if conn, err = b.dial(ctx); err == nil {
+				backoff = time.Second
+				break
+			}
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+			b.logger.Warn("trade updates not connected", "error", err, "backoff", backoff)
+		}
+	}
+}
+
+// readStream reads the trade updates until the connection drops
+func (b *Broker) readStream(ctx context.Context, conn *websocket.Conn) error {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var m message
+		if err := readJSON(conn, &m); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return err
+		}
+		if m.Stream != "trade_updates" {
+			continue
+		}
+		var t tradeUpdate
+		if err := json.Unmarshal(m.Data, &t); err != nil {
+			b.logger.Warn("invalid trade update", "error", err)
+			continue
+		}
+		u, ok := update(t)
+		if !ok {
+			continue
+		}
+		select {
+		case b.updates <- u:
+		default:
+			b.logger.Error("order update dropped, updates are not read", "order", u.OrderID, "status", u.Status)
+		}
+	}
+}
+
+// update converts a trade update to an order update, false for events without a change
+// of the order state
+func update(t tradeUpdate) (backtest.OrderUpdate, bool) {
+	u := backtest.OrderUpdate{OrderID: t.Order.ID}
+	switch t.Event {
+	case "new", "accepted", "pending_new":
+		u.Status = backtest.OrderOpen
+	case "partial_fill":
+		u.Status = backtest.OrderPartiallyFilled
+	case "fill":
+		u.Status = backtest.OrderFilled
+	case "rejected":
+		u.Status, u.Reason = backtest.OrderRejected, t.Event
+	case "canceled", "expired", "done_for_day":
+		u.Status, u.Reason = backtest.OrderCancelled, t.Event
+	default:
+		return u, false
+	}
+	if u.Status != backtest.OrderPartiallyFilled && u.Status != backtest.OrderFilled {
+		return u, true
+	}
+
+	u.Fill = &backtest.Fill{
+		Event:    backtest.Event{Time: t.Timestamp, Symbol: t.Order.Symbol},
+		Exchange: "alpaca",
+		Qty:      parseFloat(t.Qty),
+		Price:    parseFloat(t.Price),
+	}
+	u.Fill.Direction = "BOT"
+	if t.Order.Side == "sell" {
+		u.Fill.Direction = "SLD"
+	}
+	return u, true
+}
@@ -0,0 +1,327 @@
+// Package binance implements the backtest.Broker interface for Binance spot and its
+// testnet. Orders are rounded to the lot and price filters of the symbol and checked
+// against its minimum notional before they are placed, the stop loss and take profit of
+// an entry are placed as one OCO order list. Fills are streamed from the user data stream
+// and balances are read from the account.
+//
+//	b := binance.New(key, secret, true) // testnet
+//	if err := b.Connect(ctx); err != nil {
+//		return err
+//	}
+//	defer b.Close()
+//	test := backtest.New(backtest.WithBroker(b), ...)
+//
+// Symbols of the test are Binance symbols like BTCUSDT. Spot accounts have no positions,
+// the positions are the balances of the base assets of the symbols traded or loaded with
+// LoadSymbols.
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+const (
+	liveREST    = "https://api.binance.com"
+	liveStream  = "wss://stream.binance.com:9443/ws/"
+	testREST    = "https://testnet.binance.vision"
+	testStream  = "wss://stream.testnet.binance.vision/ws/"
+	recvWindow  = "5000"
+	updatesSize = 1024
+)
+
+// Broker is a Binance spot account
+type Broker struct {
+	key, secret string
+	rest        string // base URL of the REST API
+	stream      string // base URL of the user data stream
+	client      *http.Client
+	logger      backtest.Logger
+
+	mu      sync.Mutex
+	offset  time.Duration     // server time minus local time
+	symbols map[string]symbol // exchange info by symbol
+	orders  map[string]string // symbols of the placed orders by ID
+
+	updates chan backtest.OrderUpdate
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a broker for the API key, on the testnet if testnet is true
+func New(key, secret string, testnet bool) *Broker {
+	b := &Broker{
+		key:     key,
+		secret:  secret,
+		rest:    liveREST,
+		stream:  liveStream,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		symbols: make(map[string]symbol),
+		orders:  make(map[string]string),
+		updates: make(chan backtest.OrderUpdate, updatesSize),
+		logger:  backtest.NewLogger(ioutil.Discard, backtest.LevelError),
+	}
+	if testnet {
+		b.rest, b.stream = testREST, testStream
+	}
+	return b
+}
+
+// SetBaseURLs sets the base URLs of the REST API and the user data stream, e.g. for a proxy
+func (b *Broker) SetBaseURLs(rest, stream string) {
+	b.rest, b.stream = strings.TrimRight(rest, "/"), strings.TrimRight(stream, "/")+"/"
+}
+
+// SetLogger implements the backtest.LoggerSetter interface
+func (b *Broker) SetLogger(l backtest.Logger) {
+	b.logger = l
+}
+
+// RateLimit implements the backtest.RateLimiter interface, within the 50 orders per 10
+// seconds of the spot API
+func (b *Broker) RateLimit() backtest.RateLimit {
+	return backtest.RateLimit{PerSecond: 4, Burst: 20, PerMinute: 200}
+}
+
+// Connect syncs the clock with the server and starts the user data stream, it must be
+// called before the first order
+func (b *Broker) Connect(ctx context.Context) error {
+	var t struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+	start := time.Now()
+	if err := b.do(ctx, http.MethodGet, "/api/v3/time", nil, false, &t); err != nil {
+		return err
+	}
+	// the server time is taken about half way through the request
+	local := start.Add(time.Since(start) / 2)
+	b.mu.Lock()
+	b.offset = time.Unix(0, t.ServerTime*int64(time.Millisecond)).Sub(local)
+	b.mu.Unlock()
+
+	key, err := b.listenKey(ctx)
+	if err != nil {
+		return err
+	}
+	streamCtx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	go b.runStream(streamCtx, key)
+	return nil
+}
+
+// Close stops the user data stream and closes the updates
+func (b *Broker) Close() error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	<-b.done
+	b.cancel = nil
+	close(b.updates)
+	return nil
+}
+
+// Updates implements the backtest.Broker interface
+func (b *Broker) Updates() <-chan backtest.OrderUpdate {
+	return b.updates
+}
+
+// SubmitOrder implements the backtest.Broker interface. The qty is rounded down to the lot
+// size and the prices to the tick size of the symbol.
+func (b *Broker) SubmitOrder(ctx context.Context, o *backtest.Order) (string, error) {
+	s, err := b.symbol(ctx, o.GetSymbol())
+	if err != nil {
+		return "", err
+	}
+	params, err := s.orderParams(o)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		OrderID int64 `json:"orderId"`
+	}
+	if err := b.do(ctx, http.MethodPost, "/api/v3/order", params, true, &resp); err != nil {
+		return "", err
+	}
+	id := strconv.FormatInt(resp.OrderID, 10)
+	b.mu.Lock()
+	b.orders[id] = s.Symbol
+	b.mu.Unlock()
+	return id, nil
+}
+
+// SubmitBracket implements the backtest.BracketSubmitter interface and places the stop
+// loss and take profit as an OCO order list, so the qty is only sold once. Binance expires
+// the other order when one is filled.
+func (b *Broker) SubmitBracket(ctx context.Context, stop, profit *backtest.Order) (string, string, error) {
+	s, err := b.symbol(ctx, stop.GetSymbol())
+	if err != nil {
+		return "", "", err
+	}
+	params, err := s.ocoParams(stop, profit)
+	if err != nil {
+		return "", "", err
+	}
+
+	var resp struct {
+		OrderReports []struct {
+			OrderID int64  `json:"orderId"`
+			Type    string `json:"type"`
+		} `json:"orderReports"`
+	}
+	if err := b.do(ctx, http.MethodPost, "/api/v3/orderList/oco", params, true, &resp); err != nil {
+		return "", "", err
+	}
+	var stopID, profitID string
+	for _, r := range resp.OrderReports {
+		id := strconv.FormatInt(r.OrderID, 10)
+		if r.Type == "LIMIT_MAKER" {
+			profitID = id
+		} else {
+			stopID = id
+		}
+	}
+	if stopID == "" || profitID == "" {
+		return "", "", fmt.Errorf("binance: OCO order list of %s without both orders", s.Symbol)
+	}
+	b.mu.Lock()
+	b.orders[stopID] = s.Symbol
+	b.orders[profitID] = s.Symbol
+	b.mu.Unlock()
+	return stopID, profitID, nil
+}
+
+// CancelOrder implements the backtest.Broker interface, cancelling an order of an OCO order
+// list cancels the list
+func (b *Broker) CancelOrder(ctx context.Context, id string) error {
+	b.mu.Lock()
+	symbol, ok := b.orders[id]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("binance: unknown order %s", id)
+	}
+	params := url.Values{"symbol": {symbol}, "orderId": {id}}
+	return b.do(ctx, http.MethodDelete, "/api/v3/order", params, true, nil)
+}
+
+// Balances implements the backtest.Broker interface and returns the non-zero balances
+func (b *Broker) Balances(ctx context.Context) ([]backtest.Balance, error) {
+	var account struct {
+		Balances []struct {
+			Asset  string `json:"asset"`
+			Free   string `json:"free"`
+			Locked string `json:"locked"`
+		} `json:"balances"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/api/v3/account", url.Values{"omitZeroBalances": {"true"}}, true, &account); err != nil {
+		return nil, err
+	}
+	var balances []backtest.Balance
+	for _, a := range account.Balances {
+		free, _ := strconv.ParseFloat(a.Free, 64)
+		locked, _ := strconv.ParseFloat(a.Locked, 64)
+		if free == 0 && locked == 0 {
+			continue
+		}
+		balances = append(balances, backtest.Balance{Asset: a.Asset, Free: free, Locked: locked})
+	}
+	return balances, nil
+}
+
+// Positions implements the backtest.Broker interface, the positions are the balances of
+// the base assets of the known symbols
+func (b *Broker) Positions(ctx context.Context) ([]backtest.Position, error) {
+	balances, err := b.Balances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byAsset := make(map[string]float64)
+	for _, balance := range balances {
+		byAsset[balance.Asset] = balance.Total()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var positions []backtest.Position
+	for _, s := range b.symbols {
+		if qty := byAsset[s.BaseAsset]; qty != 0 {
+			positions = append(positions, backtest.Position{Timestamp: time.Now(), Symbol: s.Symbol, Qty: qty})
+		}
+	}
+	return positions, nil
+}
+
+// do sends a request to the REST API and decodes the JSON response into v, if not nil.
+// Signed requests are authenticated with the API key and the signature of the params.
+func (b *Broker) do(ctx context.Context, method, path string, params url.Values, signed bool, v interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if signed {
+		b.mu.Lock()
+		now := time.Now().Add(b.offset)
+		b.mu.Unlock()
+		params.Set("timestamp", strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10))
+		params.Set("recvWindow", recvWindow)
+	}
+	query := params.Encode()
+	if signed {
+		mac := hmac.New(sha256.New, []byte(b.secret))
+		mac.Write([]byte(query))
+		query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	u := b.rest + path
+	if query != "" {
+		u += "?" + query
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if b.key != "" {
+		req.Header.Set("X-MBX-APIKEY", b.key)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return fmt.Errorf("binance: %s (code %d)", apiErr.Msg, apiErr.Code)
+		}
+		return fmt.Errorf("binance: %s %s: %s", method, path, resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("binance: invalid response of %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/shopspring/decimal"
+)
+
+// symbol is the exchange info of a symbol with its trading filters
+type symbol struct {
+	Symbol     string `json:"symbol"`
+	Status     string `json:"status"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+	Filters    []struct {
+		FilterType       string          `json:"filterType"`
+		MinPrice         decimal.Decimal `json:"minPrice"`
+		MaxPrice         decimal.Decimal `json:"maxPrice"`
+		TickSize         decimal.Decimal `json:"tickSize"`
+		MinQty           decimal.Decimal `json:"minQty"`
+		MaxQty           decimal.Decimal `json:"maxQty"`
+		StepSize         decimal.Decimal `json:"stepSize"`
+		MinNotional      decimal.Decimal `json:"minNotional"`
+		ApplyToMarket    *bool           `json:"applyToMarket"`
+		ApplyMinToMarket *bool           `json:"applyMinToMarket"`
+	} `json:"filters"`
+
+	tick, step, marketStep decimal.Decimal
+	minQty, maxQty         decimal.Decimal
+	minNotional            decimal.Decimal
+	notionalOnMarket       bool
+}
+
+// init takes the limits of the symbol from its filters
+func (s *symbol) init() {
+	s.notionalOnMarket = true
+	for _, f := range s.Filters {
+		switch f.FilterType {
+		case "PRICE_FILTER":
+			s.tick = f.TickSize
+		case "LOT_SIZE":
+			s.step, s.minQty, s.maxQty = f.StepSize, f.MinQty, f.MaxQty
+		case "MARKET_LOT_SIZE":
+			s.marketStep = f.StepSize
+		case "MIN_NOTIONAL", "NOTIONAL":
+			s.minNotional = f.MinNotional
+			if apply := f.ApplyToMarket; apply != nil {
+				s.notionalOnMarket = *apply
+			}
+			if apply := f.ApplyMinToMarket; apply != nil {
+				s.notionalOnMarket = *apply
+			}
+		}
+	}
+}
+
+// LoadSymbols loads the exchange info of the symbols, their filters are used for the orders
+// and their base assets for the positions. Symbols are loaded on their first order if not.
+func (b *Broker) LoadSymbols(ctx context.Context, symbols ...string) error {
+	list, err := json.Marshal(symbols)
+	if err != nil {
+		return err
+	}
+	var info struct {
+		Symbols []symbol `json:"symbols"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/api/v3/exchangeInfo", url.Values{"symbols": {string(list)}}, false, &info); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range info.Symbols {
+		s.init()
+		b.symbols[s.Symbol] = s
+	}
+	return nil
+}
+
+// symbol returns the exchange info of a symbol, loaded if unknown
+func (b *Broker) symbol(ctx context.Context, name string) (symbol, error) {
+	b.mu.Lock()
+	s, ok := b.symbols[name]
+	b.mu.Unlock()
+	if !ok {
+		if err := b.LoadSymbols(ctx, name); err != nil {
+			return s, err
+		}
+		b.mu.Lock()
+		s, ok = b.symbols[name]
+		b.mu.Unlock()
+		if !ok {
+			return s, fmt.Errorf("binance: unknown symbol %s", name)
+		}
+	}
+	if s.Status != "TRADING" {
+		return s, fmt.Errorf("binance: %s is not trading, status %s", name, s.Status)
+	}
+	return s, nil
+}
+
+// orderParams returns the params of an order rounded to the filters of the symbol, or an
+// error if the order is below the limits of the symbol
+func (s symbol) orderParams(o *backtest.Order) (url.Values, error) {
+	params := url.Values{"symbol": {s.Symbol}, "newOrderRespType": {"ACK"}}
+	switch o.Direction {
+	case "buy":
+		params.Set("side", "BUY")
+	case "sell":
+		params.Set("side", "SELL")
+	default:
+		return nil, fmt.Errorf("binance: invalid direction %q", o.Direction)
+	}
+
+	step := s.step
+	if o.OrderType == "MKT" && s.marketStep.IsPositive() {
+		step = s.marketStep
+	}
+	qty := roundDown(decimal.NewFromFloat(o.Qty), step)
+	if qty.LessThan(s.minQty) || !qty.IsPositive() {
+		return nil, fmt.Errorf("binance: qty %v below the minimum %s of %s", o.Qty, s.minQty, s.Symbol)
+	}
+	if s.maxQty.IsPositive() && qty.GreaterThan(s.maxQty) {
+		return nil, fmt.Errorf("binance: qty %v above the maximum %s of %s", o.Qty, s.maxQty, s.Symbol)
+	}
+	params.Set("quantity", qty.String())
+
+	// the price of the notional check, the market price for market orders
+	price := decimal.NewFromFloat(o.Arrival)
+	switch o.OrderType {
+	case "MKT":
+		params.Set("type", "MARKET")
+		if !s.notionalOnMarket {
+			price = decimal.Zero
+		}
+	case "LMT":
+		// round limits to the better price, buys are not filled above and sells below it
+		limit := roundDown(decimal.NewFromFloat(o.Limit), s.tick)
+		if o.Direction == "sell" {
+			limit = roundUp(decimal.NewFromFloat(o.Limit), s.tick)
+		}
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", limit.String())
+		price = limit
+	case "STP":
+		stop := roundNearest(decimal.NewFromFloat(o.Stop), s.tick)
+		params.Set("type", "STOP_LOSS")
+		params.Set("stopPrice", stop.String())
+		price = stop
+	default:
+		return nil, fmt.Errorf("binance: unsupported order type %q", o.OrderType)
+	}
+
+	if price.IsPositive() && qty.Mul(price).LessThan(s.minNotional) {
+		return nil, fmt.Errorf("binance: order value %s below the minimum notional %s of %s", qty.Mul(price).StringFixed(2), s.minNotional, s.Symbol)
+	}
+	return params, nil
+}
+
+// ocoParams returns the params of an OCO order list of a stop loss and a take profit, the
+// take profit is a limit maker order above the market for sells and below it for buys
+func (s symbol) ocoParams(stop, profit *backtest.Order) (url.Values, error) {
+	stopParams, err := s.orderParams(stop)
+	if err != nil {
+		return nil, err
+	}
+	profitParams, err := s.orderParams(profit)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{
+		"symbol":           {s.Symbol},
+		"side":             {stopParams.Get("side")},
+		"quantity":         {stopParams.Get("quantity")},
+		"newOrderRespType": {"RESULT"},
+	}
+	stopLeg, profitLeg := "below", "above"
+	if stop.Direction == "buy" {
+		stopLeg, profitLeg = "above", "below"
+	}
+	params.Set(stopLeg+"Type", "STOP_LOSS")
+	params.Set(stopLeg+"StopPrice", stopParams.Get("stopPrice"))
+	params.Set(profitLeg+"Type", "LIMIT_MAKER")
+	params.Set(profitLeg+"Price", profitParams.Get("price"))
+	return params, nil
+}
+
+// roundDown rounds down to a multiple of the step, if the step is positive
+func roundDown(v, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return v
+	}
+	return v.Div(step).Floor().Mul(step)
+}
+
+// roundUp rounds up to a multiple of the step, if the step is positive
+func roundUp(v, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return v
+	}
+	return v.Div(step).Ceil().Mul(step)
+}
+
+// roundNearest rounds to the nearest multiple of the step, if the step is positive
+func roundNearest(v, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return v
+	}
+	return v.Div(step).Round(0).Mul(step)
+}
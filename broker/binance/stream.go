@@ -0,0 +1,192 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// keepAlive is the interval the listen key of the user data stream is renewed, it expires
+// after 60 minutes
+const keepAlive = 30 * time.Minute
+
+// executionReport is an order event of the user data stream
+type executionReport struct {
+	Symbol          string `json:"s"`
+	Side            string `json:"S"`
+	OrderID         int64  `json:"i"`
+	Status          string `json:"X"`
+	ExecutionType   string `json:"x"`
+	RejectReason    string `json:"r"`
+	LastQty         string `json:"l"`
+	LastPrice       string `json:"L"`
+	Commission      string `json:"n"`
+	CommissionAsset string `json:"N"`
+	TransactionTime int64  `json:"T"`
+}
+
+// listenKey creates a listen key of the user data stream
+func (b *Broker) listenKey(ctx context.Context) (string, error) {
+	var resp struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := b.do(ctx, http.MethodPost, "/api/v3/userDataStream", nil, false, &resp); err != nil {
+		return "", err
+	}
+	return resp.ListenKey, nil
+}
+
+// runStream reads the user data stream until the context is done, it reconnects when the
+// connection drops and renews the listen key
+func (b *Broker) runStream(ctx context.Context, key string) {
+	defer close(b.done)
+	go b.keepAlive(ctx, &key)
+
+	backoff := time.Second
+	for {
+		err := b.readStream(ctx, key)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn("user data stream reconnecting, fills in between are missed", "error", err, "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+		// the listen key may have expired while disconnected
+		if renewed, err := b.listenKey(ctx); err == nil {
+			b.mu.Lock()
+			key = renewed
+			b.mu.Unlock()
+			backoff = time.Second
+		}
+	}
+}
+
+// keepAlive renews the listen key until the context is done
+func (b *Broker) keepAlive(ctx context.Context, key *string) {
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			params := url.Values{"listenKey": {*key}}
+			b.mu.Unlock()
+			if err := b.do(ctx, http.MethodPut, "/api/v3/userDataStream", params, false, nil); err != nil {
+				b.logger.Error("listen key not renewed", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readStream reads the events of the user data stream until the connection drops
+func (b *Broker) readStream(ctx context.Context, key string) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, b.stream+key, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var event struct {
+			Type string `json:"e"`
+		}
+		if err := json.Unmarshal(msg, &event); err != nil || event.Type != "executionReport" {
+			continue
+		}
+		var report executionReport
+		if err := json.Unmarshal(msg, &report); err != nil {
+			b.logger.Warn("invalid execution report", "error", err)
+			continue
+		}
+		u := b.update(report)
+		select {
+		case b.updates <- u:
+		default:
+			b.logger.Error("order update dropped, updates are not read", "order", u.OrderID, "status", u.Status)
+		}
+	}
+}
+
+// update converts an execution report to an order update
+func (b *Broker) update(r executionReport) backtest.OrderUpdate {
+	u := backtest.OrderUpdate{OrderID: strconv.FormatInt(r.OrderID, 10), Reason: r.RejectReason}
+	switch r.Status {
+	case "NEW":
+		u.Status = backtest.OrderOpen
+	case "PARTIALLY_FILLED":
+		u.Status = backtest.OrderPartiallyFilled
+	case "FILLED":
+		u.Status = backtest.OrderFilled
+	case "REJECTED":
+		u.Status = backtest.OrderRejected
+	default: // CANCELED, EXPIRED, EXPIRED_IN_MATCH
+		u.Status = backtest.OrderCancelled
+		if u.Reason == "" || u.Reason == "NONE" {
+			u.Reason = r.Status
+		}
+	}
+	if r.ExecutionType != "TRADE" {
+		return u
+	}
+
+	qty, _ := strconv.ParseFloat(r.LastQty, 64)
+	price, _ := strconv.ParseFloat(r.LastPrice, 64)
+	u.Fill = &backtest.Fill{
+		Event:      backtest.Event{Time: time.Unix(0, r.TransactionTime*int64(time.Millisecond)), Symbol: r.Symbol},
+		Exchange:   "binance",
+		Qty:        qty,
+		Price:      price,
+		Commission: b.commission(r, price),
+	}
+	u.Fill.Direction = "BOT"
+	if r.Side == "SELL" {
+		u.Fill.Direction = "SLD"
+	}
+	return u
+}
+
+// commission returns the commission of a trade in the quote asset. Commissions paid in
+// another asset than the base or quote asset, like BNB, are not converted and count as 0.
+func (b *Broker) commission(r executionReport, price float64) float64 {
+	amount, _ := strconv.ParseFloat(r.Commission, 64)
+	b.mu.Lock()
+	s := b.symbols[r.Symbol]
+	b.mu.Unlock()
+	switch r.CommissionAsset {
+	case "", s.QuoteAsset:
+		return amount
+	case s.BaseAsset:
+		return amount * price
+	}
+	if amount != 0 {
+		b.logger.Debug("commission not converted", "symbol", r.Symbol, "asset", r.CommissionAsset, "amount", amount)
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package ib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// resolve returns the contract ID of a symbol, it is looked up at the gateway once and
+// cached
+func (b *Broker) resolve(ctx context.Context, symbol string) (int, error) {
+	b.mu.Lock()
+	c := b.contracts[symbol]
+	b.mu.Unlock()
+	if c.ConID != 0 {
+		return c.ConID, nil
+	}
+
+	var err error
+	switch c.SecType {
+	case "", "STK":
+		c.ConID, err = b.resolveStock(ctx, symbol, c)
+	case "FUT":
+		c.ConID, err = b.resolveFuture(ctx, symbol, c)
+	default:
+		return 0, fmt.Errorf("ib: contract of %s with security type %s needs a ConID", symbol, c.SecType)
+	}
+	if err != nil {
+		return 0, err
+	}
+	b.logger.Info("contract resolved", "symbol", symbol, "conid", c.ConID)
+	b.SetContract(symbol, c)
+	return c.ConID, nil
+}
+
+// resolveStock looks up the contract of a stock on its exchange, on a US exchange if it
+// is routed by SMART in USD
+func (b *Broker) resolveStock(ctx context.Context, symbol string, c Contract) (int, error) {
+	var resp map[string][]struct {
+		Name      string `json:"name"`
+		Contracts []struct {
+			ConID    int    `json:"conid"`
+			Exchange string `json:"exchange"`
+			IsUS     bool   `json:"isUS"`
+		} `json:"contracts"`
+	}
+	path := "/trsrv/stocks?symbols=" + url.QueryEscape(symbol)
+	if err := b.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	smart := c.Exchange == "" || c.Exchange == "SMART"
+	us := c.Currency == "" || c.Currency == "USD"
+	for _, s := range resp[symbol] {
+		for _, sc := range s.Contracts {
+			if smart && sc.IsUS == us || !smart && sc.Exchange == c.Exchange {
+				return sc.ConID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("ib: no stock contract for %s on %s", symbol, exchange(c))
+}
+
+// resolveFuture looks up the contract of a future expiring in the month of the contract,
+// the front month if not set
+func (b *Broker) resolveFuture(ctx context.Context, symbol string, c Contract) (int, error) {
+	var resp map[string][]struct {
+		ConID          int `json:"conid"`
+		ExpirationDate int `json:"expirationDate"` // as YYYYMMDD
+	}
+	path := "/trsrv/futures?symbols=" + url.QueryEscape(symbol)
+	if err := b.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	today, _ := strconv.Atoi(time.Now().Format("20060102"))
+	conID, front := 0, 0
+	for _, f := range resp[symbol] {
+		if c.Expiry != "" {
+			if strconv.Itoa(f.ExpirationDate/100) == c.Expiry {
+				return f.ConID, nil
+			}
+			continue
+		}
+		if f.ExpirationDate >= today && (front == 0 || f.ExpirationDate < front) {
+			conID, front = f.ConID, f.ExpirationDate
+		}
+	}
+	if conID == 0 {
+		return 0, fmt.Errorf("ib: no futures contract for %s expiring %s", symbol, expiry(c))
+	}
+	return conID, nil
+}
+
+// exchange returns the exchange of a contract for errors
+func exchange(c Contract) string {
+	if c.Exchange == "" {
+		return "SMART"
+	}
+	return c.Exchange
+}
+
+// expiry returns the expiry of a contract for errors
+func expiry(c Contract) string {
+	if c.Expiry == "" {
+		return "in the front month"
+	}
+	return "in " + c.Expiry
+}
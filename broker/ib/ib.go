@@ -0,0 +1,395 @@
+// Package ib implements the backtest.Broker interface for Interactive Brokers over the
+// Client Portal API of the IB Gateway. The gateway runs locally and is logged in from the
+// browser, the broker keeps its session alive and streams the order updates.
+//
+//	b := ib.New("https://localhost:5000", "DU123456")
+//	b.SetContract("ES", ib.Contract{SecType: "FUT", Exchange: "CME", Expiry: "202412"})
+//	if err := b.Connect(ctx); err != nil {
+//		return err
+//	}
+//	defer b.Close()
+//	test := backtest.New(backtest.WithBroker(b), ...)
+//
+// Symbols are resolved to contracts with the contracts set for them, symbols without are
+// stocks routed by SMART in USD.
+package ib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Contract describes the instrument of a symbol
+type Contract struct {
+	ConID    int    // contract ID, resolved from the other fields if 0
+	SecType  string // STK or FUT, other types need the ConID, STK if empty
+	Exchange string // exchange, SMART for stocks if empty
+	Currency string // currency, USD if empty
+	Expiry   string // expiry month of futures as YYYYMM, the front month if empty
+}
+
+const (
+	updatesSize = 1024
+	// keepAlive is the interval the session of the gateway is kept alive, it times out
+	// after about 5 minutes without requests
+	keepAlive = time.Minute
+	// maxReplies is the number of order warnings confirmed before an order fails
+	maxReplies = 5
+)
+
+// Broker is an account at Interactive Brokers, traded over the IB Gateway
+type Broker struct {
+	rest    string // base URL of the Client Portal API
+	account string
+	client  *http.Client
+	logger  backtest.Logger
+
+	mu        sync.Mutex
+	contracts map[string]Contract // contracts by symbol
+	symbols   map[int]string      // symbols by resolved contract ID
+	orders    map[string]*order   // placed orders by ID
+
+	updates chan backtest.OrderUpdate
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// order is the fill state of a placed order
+type order struct {
+	symbol string
+	side   string
+	filled float64
+	avg    float64
+}
+
+// New creates a broker for the account on the gateway at the URL, e.g.
+// https://localhost:5000. The self-signed certificate of a local gateway is accepted.
+func New(gateway, account string) *Broker {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: strings.Contains(gateway, "://localhost")}
+	return &Broker{
+		rest:      strings.TrimRight(gateway, "/") + "/v1/api",
+		account:   account,
+		client:    &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		logger:    backtest.NewLogger(ioutil.Discard, backtest.LevelError),
+		contracts: make(map[string]Contract),
+		symbols:   make(map[int]string),
+		orders:    make(map[string]*order),
+		updates:   make(chan backtest.OrderUpdate, updatesSize),
+	}
+}
+
+// SetHTTPClient sets the client of the requests to the gateway, e.g. to verify its
+// certificate
+func (b *Broker) SetHTTPClient(c *http.Client) {
+	b.client = c
+}
+
+// SetLogger implements the backtest.LoggerSetter interface
+func (b *Broker) SetLogger(l backtest.Logger) {
+	b.logger = l
+}
+
+// RateLimit implements the backtest.RateLimiter interface, within the request limit of the
+// gateway with room for the confirmations of the order warnings
+func (b *Broker) RateLimit() backtest.RateLimit {
+	return backtest.RateLimit{PerSecond: 1, Burst: 5, PerMinute: 50}
+}
+
+// SetContract sets the contract of a symbol
+func (b *Broker) SetContract(symbol string, c Contract) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.contracts[symbol] = c
+	if c.ConID != 0 {
+		b.symbols[c.ConID] = symbol
+	}
+}
+
+// Connect checks the session of the gateway and starts streaming the order updates, it
+// must be called before the first order
+func (b *Broker) Connect(ctx context.Context) error {
+	var status struct {
+		Authenticated bool `json:"authenticated"`
+		Connected     bool `json:"connected"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/iserver/auth/status", nil, &status); err != nil {
+		return err
+	}
+	if !status.Authenticated || !status.Connected {
+		return errors.New("ib: gateway session is not authenticated, log in to the gateway first")
+	}
+	// the accounts must be requested before orders are placed
+	var accounts struct {
+		Accounts []string `json:"accounts"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/iserver/accounts", nil, &accounts); err != nil {
+		return err
+	}
+	found := false
+	for _, a := range accounts.Accounts {
+		found = found || a == b.account
+	}
+	if !found {
+		return fmt.Errorf("ib: unknown account %s, the gateway has %s", b.account, strings.Join(accounts.Accounts, ", "))
+	}
+
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	streamCtx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	go b.runStream(streamCtx, conn)
+	return nil
+}
+
+// Close stops the order updates stream and closes the updates
+func (b *Broker) Close() error {
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	<-b.done
+	b.cancel = nil
+	close(b.updates)
+	return nil
+}
+
+// Updates implements the backtest.Broker interface
+func (b *Broker) Updates() <-chan backtest.OrderUpdate {
+	return b.updates
+}
+
+// tickle keeps the session of the gateway alive and returns its ID
+func (b *Broker) tickle(ctx context.Context) (string, error) {
+	var resp struct {
+		Session string `json:"session"`
+	}
+	if err := b.do(ctx, http.MethodPost, "/tickle", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Session, nil
+}
+
+// orderRequest is an order of the Client Portal API
+type orderRequest struct {
+	AcctID    string  `json:"acctId"`
+	ConID     int     `json:"conid"`
+	OrderType string  `json:"orderType"`
+	Side      string  `json:"side"`
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price,omitempty"`
+	TIF       string  `json:"tif"`
+}
+
+// SubmitOrder implements the backtest.Broker interface. The warnings the gateway asks to
+// confirm for an order are confirmed.
+func (b *Broker) SubmitOrder(ctx context.Context, o *backtest.Order) (string, error) {
+	conID, err := b.resolve(ctx, o.GetSymbol())
+	if err != nil {
+		return "", err
+	}
+	req := orderRequest{AcctID: b.account, ConID: conID, Quantity: o.Qty, TIF: "GTC"}
+	switch o.Direction {
+	case "buy":
+		req.Side = "BUY"
+	case "sell":
+		req.Side = "SELL"
+	default:
+		return "", fmt.Errorf("ib: invalid direction %q", o.Direction)
+	}
+	switch o.OrderType {
+	case "MKT":
+		req.OrderType, req.TIF = "MKT", "DAY"
+	case "LMT":
+		req.OrderType, req.Price = "LMT", o.Limit
+	case "STP":
+		// the price of stop orders is the stop price
+		req.OrderType, req.Price = "STP", o.Stop
+	default:
+		return "", fmt.Errorf("ib: unsupported order type %q", o.OrderType)
+	}
+
+	var replies []orderReply
+	path := "/iserver/account/" + b.account + "/orders"
+	var body interface{} = map[string][]orderRequest{"orders": {req}}
+	for i := 0; i <= maxReplies; i++ {
+		if err := b.do(ctx, http.MethodPost, path, body, &replies); err != nil {
+			return "", err
+		}
+		if len(replies) == 0 {
+			return "", errors.New("ib: order without reply")
+		}
+		r := replies[0]
+		if r.OrderID != "" {
+			b.mu.Lock()
+			b.orders[r.OrderID] = &order{symbol: o.GetSymbol(), side: req.Side}
+			b.mu.Unlock()
+			return r.OrderID, nil
+		}
+		if r.Error != "" {
+			return "", fmt.Errorf("ib: %s", r.Error)
+		}
+		b.logger.Info("order warning confirmed", "symbol", o.GetSymbol(), "message", strings.Join(r.Message, " "))
+		path, body = "/iserver/reply/"+r.ID, map[string]bool{"confirmed": true}
+	}
+	return "", fmt.Errorf("ib: order not placed after %d confirmations", maxReplies)
+}
+
+// orderReply is the reply to an order, its ID if placed or a warning to confirm
+type orderReply struct {
+	OrderID string   `json:"order_id"`
+	ID      string   `json:"id"`
+	Message []string `json:"message"`
+	Error   string   `json:"error"`
+}
+
+// CancelOrder implements the backtest.Broker interface
+func (b *Broker) CancelOrder(ctx context.Context, id string) error {
+	return b.do(ctx, http.MethodDelete, "/iserver/account/"+b.account+"/order/"+id, nil, nil)
+}
+
+// Positions implements the backtest.Broker interface
+func (b *Broker) Positions(ctx context.Context) ([]backtest.Position, error) {
+	var resp []struct {
+		ConID         int     `json:"conid"`
+		ContractDesc  string  `json:"contractDesc"`
+		Ticker        string  `json:"ticker"`
+		Position      float64 `json:"position"`
+		MktPrice      float64 `json:"mktPrice"`
+		MktValue      float64 `json:"mktValue"`
+		AvgPrice      float64 `json:"avgPrice"`
+		AvgCost       float64 `json:"avgCost"`
+		RealizedPnl   float64 `json:"realizedPnl"`
+		UnrealizedPnl float64 `json:"unrealizedPnl"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/portfolio/"+b.account+"/positions/0", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	positions := make([]backtest.Position, 0, len(resp))
+	for _, p := range resp {
+		if p.Position == 0 {
+			continue
+		}
+		symbol, ok := b.symbols[p.ConID]
+		if !ok {
+			symbol = p.Ticker
+		}
+		if symbol == "" {
+			symbol = p.ContractDesc
+		}
+		positions = append(positions, backtest.Position{
+			Timestamp:        time.Now(),
+			Symbol:           symbol,
+			Qty:              p.Position,
+			AvgPrice:         p.AvgPrice,
+			AvgPriceNet:      p.AvgCost,
+			MarketPrice:      p.MktPrice,
+			MarketValue:      p.MktValue,
+			RealProfitLoss:   p.RealizedPnl,
+			UnrealProfitLoss: p.UnrealizedPnl,
+			TotalProfitLoss:  p.RealizedPnl + p.UnrealizedPnl,
+		})
+	}
+	return positions, nil
+}
+
+// Balances implements the backtest.Broker interface and returns the cash by currency
+func (b *Broker) Balances(ctx context.Context) ([]backtest.Balance, error) {
+	var ledger map[string]struct {
+		Currency    string  `json:"currency"`
+		CashBalance float64 `json:"cashbalance"`
+	}
+	if err := b.do(ctx, http.MethodGet, "/portfolio/"+b.account+"/ledger", nil, &ledger); err != nil {
+		return nil, err
+	}
+	var balances []backtest.Balance
+	for key, l := range ledger {
+		// the base entry sums up the currencies
+		if key == "BASE" || l.CashBalance == 0 {
+			continue
+		}
+		balances = append(balances, backtest.Balance{Asset: key, Free: l.CashBalance})
+	}
+	return balances, nil
+}
+
+// do sends a request with the JSON body, if not nil, to the gateway and decodes the JSON
+// response into v, if not nil
+func (b *Broker) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var buf []byte
+	if body != nil {
+		var err error
+		if buf, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, b.rest+path, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("ib: %s", apiErr.Error)
+		}
+		return fmt.Errorf("ib: %s %s: %s", method, path, resp.Status)
+	}
+	if v == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("ib: invalid response of %s: %v", path, err)
+	}
+	return nil
+}
+
+// number is a number of the API, which sends some numbers as strings
+type number float64
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (n *number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", "", -1), 64)
+	if err != nil {
+		return err
+	}
+	*n = number(v)
+	return nil
+}
@@ -0,0 +1,201 @@
+package ib
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// liveOrder is an order of the live orders topic, with the cumulative fill of the order
+type liveOrder struct {
+	Account        string `json:"acct"`
+	ConID          int    `json:"conid"`
+	OrderID        int64  `json:"orderId"`
+	Ticker         string `json:"ticker"`
+	Side           string `json:"side"`
+	Status         string `json:"status"`
+	FilledQuantity number `json:"filledQuantity"`
+	AvgPrice       number `json:"avgPrice"`
+	LastExecution  int64  `json:"lastExecutionTime_r"` // in milliseconds
+}
+
+// message is a message of the stream
+type message struct {
+	Topic string          `json:"topic"`
+	Args  json.RawMessage `json:"args"`
+}
+
+// dial renews the session of the gateway, connects to its stream and subscribes to the
+// live orders
+func (b *Broker) dial(ctx context.Context) (*websocket.Conn, error) {
+	session, err := b.tickle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dialer := *websocket.DefaultDialer
+	if t, ok := b.client.Transport.(*http.Transport); ok {
+		dialer.TLSClientConfig = t.TLSClientConfig
+	}
+	header := http.Header{}
+	header.Set("Cookie", `api={"session":"`+session+`"}`)
+	stream := "wss" + strings.TrimPrefix(b.rest, "https") + "/ws"
+	conn, _, err := dialer.DialContext(ctx, stream, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("sor+{}")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// runStream reads the live orders until the context is done and reconnects when the
+// connection drops
+func (b *Broker) runStream(ctx context.Context, conn *websocket.Conn) {
+	defer close(b.done)
+	backoff := time.Second
+	for {
+		err := b.readStream(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Warn("live orders reconnecting, fills in between are missed", "error", err, "backoff", backoff)
+		for {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if conn, err = b.dial(ctx); err == nil {
+				backoff = time.Second
+				break
+			}
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+			b.logger.Warn("live orders not connected", "error", err, "backoff", backoff)
+		}
+	}
+}
+
+// readStream reads the live orders until the connection drops, the session is kept alive
+// meanwhile
+func (b *Broker) readStream(ctx context.Context, conn *websocket.Conn) error {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := b.tickle(ctx); err != nil {
+					b.logger.Warn("session not kept alive", "error", err)
+				}
+				conn.WriteMessage(websocket.TextMessage, []byte("tic"))
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var m message
+		if json.Unmarshal(msg, &m) != nil || m.Topic != "sor" {
+			continue
+		}
+		var orders []liveOrder
+		if err := json.Unmarshal(m.Args, &orders); err != nil {
+			b.logger.Warn("invalid live orders", "error", err)
+			continue
+		}
+		for _, o := range orders {
+			if o.Account != "" && o.Account != b.account {
+				continue
+			}
+			u, ok := b.update(o)
+			if !ok {
+				continue
+			}
+			select {
+			case b.updates <- u:
+			default:
+				b.logger.Error("order update dropped, updates are not read", "order", u.OrderID, "status", u.Status)
+			}
+		}
+	}
+}
+
+// update converts a live order to an order update, false for orders not placed by the
+// broker and states without a change. The live orders report the cumulative fill, the
+// fill of the update is the difference to the fill seen before.
+func (b *Broker) update(o liveOrder) (backtest.OrderUpdate, bool) {
+	id := strconv.FormatInt(o.OrderID, 10)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	placed, ok := b.orders[id]
+	if !ok {
+		return backtest.OrderUpdate{}, false
+	}
+
+	u := backtest.OrderUpdate{OrderID: id}
+	filled := float64(o.FilledQuantity)
+	switch o.Status {
+	case "PendingSubmit", "PreSubmitted", "Submitted":
+		u.Status = backtest.OrderOpen
+		if filled > 0 {
+			u.Status = backtest.OrderPartiallyFilled
+		}
+	case "Filled":
+		u.Status = backtest.OrderFilled
+	case "Cancelled", "PendingCancel":
+		u.Status, u.Reason = backtest.OrderCancelled, o.Status
+	case "Inactive":
+		u.Status, u.Reason = backtest.OrderRejected, o.Status
+	default:
+		return u, false
+	}
+	if u.Status.Done() {
+		delete(b.orders, id)
+	}
+	if filled <= placed.filled {
+		return u, u.Status != backtest.OrderPartiallyFilled
+	}
+
+	// the price of the fill from the average prices before and after it
+	avg := float64(o.AvgPrice)
+	qty := filled - placed.filled
+	price := (avg*filled - placed.avg*placed.filled) / qty
+	placed.filled, placed.avg = filled, avg
+
+	t := time.Now()
+	if o.LastExecution > 0 {
+		t = time.Unix(0, o.LastExecution*int64(time.Millisecond))
+	}
+	u.Fill = &backtest.Fill{
+		Event:    backtest.Event{Time: t, Symbol: placed.symbol},
+		Exchange: "ib",
+		Qty:      qty,
+		Price:    price,
+	}
+	u.Fill.Direction = "BOT"
+	if placed.side == "SELL" {
+		u.Fill.Direction = "SLD"
+	}
+	return u, true
+}
@@ -0,0 +1,223 @@
+package backtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// TestConfig returns the configuration identifying the results of a test: the symbols,
+// warmup, seed, initial cash and every component changing the results, the strategy, the
+// sizer, the filters, the stop rules and the exchange. Components are identified by their
+// type with their params or exported fields, components which are functions can't be
+// identified and return an error.
+func TestConfig(t *Test) (map[string]interface{}, error) {
+	config := map[string]interface{}{
+		"symbols":        t.symbols,
+		"warmup_bars":    t.warmupBars,
+		"warmup_seconds": t.warmupDuration.Seconds(),
+		"panic_policy":   t.panicPolicy,
+		"data":           fmt.Sprintf("%T", t.data),
+	}
+	if t.deterministic {
+		config["seed"] = t.seed
+	}
+	if t.portfolio != nil {
+		config["initial_cash"] = t.portfolio.InitialCash()
+	}
+	if p, ok := t.portfolio.(*Portfolio); ok {
+		config["short_selling"] = p.shortSelling
+		if p.sizeManager != nil {
+			sizer, err := componentConfig(p.sizeManager)
+			if err != nil {
+				return nil, err
+			}
+			config["sizer"] = sizer
+		}
+	}
+
+	components := map[string]interface{}{
+		"strategy":  t.strategy,
+		"portfolio": t.portfolio,
+		"exchange":  t.exchange,
+	}
+	if t.regime != nil {
+		components["regime"] = t.regime
+	}
+	for name, c := range components {
+		v, err := componentConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		config[name] = v
+	}
+	filters := make([]interface{}, len(t.filters))
+	for i, f := range t.filters {
+		filters[i] = f
+	}
+	stopRules := make([]interface{}, len(t.stopRules))
+	for i, r := range t.stopRules {
+		stopRules[i] = r
+	}
+	for name, list := range map[string][]interface{}{"filters": filters, "stop_rules": stopRules} {
+		if len(list) == 0 {
+			continue
+		}
+		configs := make([]interface{}, len(list))
+		for i, c := range list {
+			v, err := componentConfig(c)
+			if err != nil {
+				return nil, err
+			}
+			configs[i] = v
+		}
+		config[name] = configs
+	}
+	return config, nil
+}
+
+// componentConfig identifies a component by its type and its params, or its exported
+// fields if it has no params
+func componentConfig(c interface{}) (interface{}, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if reflect.ValueOf(c).Kind() == reflect.Func {
+		return nil, fmt.Errorf("%T is a function and can't be identified to cache the run", c)
+	}
+	config := map[string]interface{}{"type": fmt.Sprintf("%T", c)}
+	if p, ok := c.(Parameterizer); ok {
+		params, err := GetParams(p)
+		if err != nil {
+			return nil, err
+		}
+		config["params"] = params
+		return config, nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("%T can't be identified to cache the run: %v", c, err)
+	}
+	if s := string(b); s != "{}" && s != "null" {
+		config["fields"] = json.RawMessage(b)
+	}
+	return config, nil
+}
+
+// ConfigHash returns the SHA-256 hash of a configuration and the hash of its dataset
+func ConfigHash(config map[string]interface{}, datasetHash string) (string, error) {
+	// maps are encoded with sorted keys, so equal configurations have equal hashes
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(b)
+	h.Write([]byte(datasetHash))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Find returns the latest saved run with the configuration hash. Runs saved by other
+// processes, e.g. the workers sharing a bucket, are looked up by their hash.
+func (r *ResultStore) Find(hash string) (StoredRun, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.index == nil {
+		records, err := r.List()
+		if err != nil {
+			return StoredRun{}, false, err
+		}
+		r.index = make(map[string]string, len(records))
+		for _, record := range records {
+			if record.ConfigHash != "" {
+				r.index[record.ConfigHash] = record.ID
+			}
+		}
+	}
+
+	id, ok := r.index[hash]
+	if !ok {
+		b, err := r.objects.Get(context.Background(), r.hashKey(hash))
+		if errors.Is(err, os.ErrNotExist) {
+			return StoredRun{}, false, nil
+		}
+		if err != nil {
+			return StoredRun{}, false, err
+		}
+		id = strings.TrimSpace(string(b))
+	}
+	run, err := r.Load(id)
+	if errors.Is(err, os.ErrNotExist) {
+		// deleted by another process
+		delete(r.index, hash)
+		return StoredRun{}, false, nil
+	}
+	if err != nil {
+		return StoredRun{}, false, err
+	}
+	r.index[hash] = id
+	return run, true, nil
+}
+
+// hashKey returns the object key of the ID of the latest run with the configuration hash,
+// it is not listed as run
+func (r *ResultStore) hashKey(hash string) string {
+	return r.prefix + "hashes/" + hash
+}
+
+// RunCached runs the test and saves the run to the store, unless a run with the same
+// configuration and data is saved already. It returns the stored run and true if it
+// was taken from the store.
+func (t *Test) RunCached(store *ResultStore) (StoredRun, bool, error) {
+	return t.RunCachedContext(context.Background(), store)
+}
+
+// RunCachedContext runs the test like RunCached until the context is done, cancelled
+// runs are not saved
+func (t *Test) RunCachedContext(ctx context.Context, store *ResultStore) (StoredRun, bool, error) {
+	return t.runCached(ctx, store, nil)
+}
+
+// runCached runs the test unless cached, the job params are added to the configuration
+// as they may configure the test beyond the strategy params
+func (t *Test) runCached(ctx context.Context, store *ResultStore, jobParams map[string]interface{}) (StoredRun, bool, error) {
+	if err := t.Validate(); err != nil {
+		return StoredRun{}, false, err
+	}
+	config, err := TestConfig(t)
+	if err != nil {
+		return StoredRun{}, false, err
+	}
+	if jobParams != nil {
+		config["job"] = jobParams
+	}
+	// hash the data before the run, which may sort or stress it
+	datasetHash := DatasetHash(t.data)
+	hash, err := ConfigHash(config, datasetHash)
+	if err != nil {
+		return StoredRun{}, false, err
+	}
+	if run, ok, err := store.Find(hash); err != nil || ok {
+		return run, ok, err
+	}
+
+	if _, err := t.RunContext(ctx); err != nil {
+		return StoredRun{}, false, err
+	}
+	s, ok := t.statistic.(*Statistic)
+	if !ok {
+		return StoredRun{}, false, errors.New("caching runs needs a *Statistic")
+	}
+	record, err := store.save(s, config, datasetHash)
+	if err != nil {
+		return StoredRun{}, false, err
+	}
+	return StoredRun{RunRecord: record, Result: s.JSON()}, false, nil
+}
@@ -0,0 +1,57 @@
+package backtest
+
+import "testing"
+
+// entryStrategy buys the qty on the bar number Entry, its fields identify it in the cache
+type entryStrategy struct {
+	Entry int
+	Qty   float64
+}
+
+// CalculateSignal implements the StrategyHandler interface
+func (s entryStrategy) CalculateSignal(e DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	signal := &Signal{Event: Event{Time: e.GetTime(), Symbol: e.GetSymbol()}}
+	if len(d.List(e.GetSymbol())) == s.Entry {
+		signal.SetDirection("buy")
+		signal.Qty = s.Qty
+	}
+	return signal, nil
+}
+
+func TestRunCached(t *testing.T) {
+	store, err := NewResultStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := []float64{100, 102, 101, 105, 107, 106}
+	run := func(strategy entryStrategy, prices []float64) (StoredRun, bool) {
+		run, cached, err := newTestRun(closeBars("A", prices...), strategy).RunCached(store)
+		if err != nil {
+			t.Fatalf("cached run failed: %v", err)
+		}
+		return run, cached
+	}
+
+	first, cached := run(entryStrategy{Entry: 2, Qty: 10}, prices)
+	if cached {
+		t.Fatal("first run taken from the empty store")
+	}
+
+	second, cached := run(entryStrategy{Entry: 2, Qty: 10}, prices)
+	if !cached {
+		t.Fatal("second run with the same config and data not taken from the store")
+	}
+	if second.ID != first.ID {
+		t.Errorf("second run is %s, want the stored run %s", second.ID, first.ID)
+	}
+	if len(second.Result.Transactions) != 1 {
+		t.Errorf("stored run has %d transactions, want the entry", len(second.Result.Transactions))
+	}
+
+	if _, cached := run(entryStrategy{Entry: 3, Qty: 10}, prices); cached {
+		t.Error("run with other strategy fields taken from the store")
+	}
+	if _, cached := run(entryStrategy{Entry: 2, Qty: 10}, append(prices, 110)); cached {
+		t.Error("run over other data taken from the store")
+	}
+}
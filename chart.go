@@ -0,0 +1,609 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+	"gonum.org/v1/gonum/stat"
+)
+
+// GraphResult serves the equity curve of the backtest as chart, see ChartOptionsFromRequest
+// for the supported query parameters
+func (s *Statistic) GraphResult(res http.ResponseWriter, req *http.Request) {
+	opts := ChartOptionsFromRequest(req)
+	res.Header().Set("Content-Type", opts.ContentType())
+	if err := s.WriteEquityChart(res, opts); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WriteEquityChart renders the equity curve of the backtest compared to buying and
+// holding with the initial cash, the benchmark if set and the initial cash as reference line
+func (s *Statistic) WriteEquityChart(w io.Writer, opts ChartOptions) error {
+	var xv []time.Time
+
+	var yv1 []float64
+	var yv2 []float64
+	var yv3 []float64
+	var yv4 []float64
+
+	var maxY float64
+	var minY float64
+
+	for _, e := range s.equity {
+		xv = append(xv, e.timestamp)
+		yv1 = append(yv1, e.equity)
+		yv2 = append(yv2, e.buyAndHoldValue)
+		yv3 = append(yv3, s.initialCash)
+		yv4 = append(yv4, e.benchmarkValue)
+		maxY = math.Max(math.Max(maxY, e.equity), e.buyAndHoldValue)
+		if minY == 0 {
+			minY = maxY
+		}
+		minY = math.Min(math.Min(minY, e.equity), e.buyAndHoldValue)
+		if e.benchmarkValue != 0 {
+			maxY = math.Max(maxY, e.benchmarkValue)
+			minY = math.Min(minY, e.benchmarkValue)
+		}
+	}
+	maxY = math.Max(maxY, s.initialCash)
+	minY = math.Min(minY, s.initialCash)
+
+	equitySeries := chart.TimeSeries{
+		Name: "Equity",
+		Style: chart.Style{
+			Show:        true,
+			StrokeColor: chart.GetDefaultColor(0),
+		},
+		XValues: xv,
+		YValues: yv1,
+	}
+
+	buyAndHoldSeries := chart.TimeSeries{
+		Name: "Buy and hold",
+		Style: chart.Style{
+			Show:        true,
+			StrokeColor: chart.GetDefaultColor(1),
+		},
+		XValues: xv,
+		YValues: yv2,
+	}
+
+	initialCashSeries := chart.TimeSeries{
+		Name: "Initial cash",
+		Style: chart.Style{
+			Show:            true,
+			StrokeColor:     chart.ColorAlternateGray,
+			StrokeDashArray: []float64{5, 5},
+		},
+		XValues: xv,
+		YValues: yv3,
+	}
+
+	graph := chart.Chart{
+		XAxis: chart.XAxis{
+			Style:          chart.Style{Show: true},
+			TickPosition:   chart.TickPositionBetweenTicks,
+			ValueFormatter: timeFormatter(xv),
+		},
+		YAxis: chart.YAxis{
+			Name:      "Equity",
+			NameStyle: chart.Style{Show: true},
+			Style:     chart.Style{Show: true},
+			Range: &chart.ContinuousRange{
+				Max: maxY + (maxY-minY)/10,
+				Min: minY - (maxY-minY)/10,
+			},
+		},
+		Series: []chart.Series{
+			equitySeries,
+			buyAndHoldSeries,
+			initialCashSeries,
+		},
+	}
+
+	if len(s.benchmark) > 0 {
+		graph.Series = append([]chart.Series{
+			performanceBands{equity: s.equity},
+		}, graph.Series...)
+		graph.Series = append(graph.Series, chart.TimeSeries{
+			Name: s.benchmarkName,
+			Style: chart.Style{
+				Show:        true,
+				StrokeColor: chart.GetDefaultColor(2),
+			},
+			XValues: xv,
+			YValues: yv4,
+		})
+	}
+	if len(s.annotations) > 0 {
+		graph.Series = append(graph.Series, annotationLines{annotations: s.annotations})
+	}
+	opts.apply(&graph)
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+
+	return graph.Render(opts.renderer(), w)
+}
+
+// timeFormatter returns a time axis formatter matching the time span of the values
+func timeFormatter(xv []time.Time) chart.ValueFormatter {
+	if len(xv) < 2 {
+		return chart.TimeValueFormatterWithFormat("2006-01-02 15:04")
+	}
+
+	span := xv[len(xv)-1].Sub(xv[0])
+	switch {
+	case span <= 48*time.Hour:
+		return chart.TimeValueFormatterWithFormat("01-02 15:04")
+	case span <= 365*24*time.Hour:
+		return chart.TimeValueFormatterWithFormat("2006-01-02")
+	}
+	return chart.TimeValueFormatterWithFormat("2006-01")
+}
+
+// GraphPrice serves the OHLC candles of a symbol with the buy and sell fills as chart.
+// The symbol is read from the symbol query parameter, defaulting to the first tracked symbol.
+func (s *Statistic) GraphPrice(res http.ResponseWriter, req *http.Request) {
+	symbol := req.URL.Query().Get("symbol")
+
+	opts := ChartOptionsFromRequest(req)
+	res.Header().Set("Content-Type", opts.ContentType())
+	if err := s.WritePriceChart(res, symbol, opts); err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+	}
+}
+
+// WritePriceChart renders the OHLC candles of a symbol with the buy and sell fills,
+// an empty symbol uses the first tracked symbol
+func (s *Statistic) WritePriceChart(w io.Writer, symbol string, opts ChartOptions) error {
+	var bars []Bar
+	for _, e := range s.eventHistory {
+		if bar, ok := e.(Bar); ok {
+			if symbol == "" {
+				symbol = bar.GetSymbol()
+			}
+			if bar.GetSymbol() == symbol {
+				bars = append(bars, bar)
+			}
+		}
+	}
+	if len(bars) == 0 {
+		return fmt.Errorf("no bars tracked for symbol %q", symbol)
+	}
+
+	minY, maxY := bars[0].Low, bars[0].High
+	for _, b := range bars {
+		minY = math.Min(minY, b.Low)
+		maxY = math.Max(maxY, b.High)
+	}
+
+	// markers for the fills, placed below the low of buys and above the high of sells
+	var markers []chart.Value2
+	for _, f := range s.transactionHistory {
+		if f.GetSymbol() != symbol {
+			continue
+		}
+		label := fmt.Sprintf("B %.2f", f.GetPrice())
+		if f.GetDirection() == "SLD" {
+			label = fmt.Sprintf("S %.2f", f.GetPrice())
+		}
+		markers = append(markers, chart.Value2{
+			XValue: timeToFloat(f.GetTime()),
+			YValue: f.GetPrice(),
+			Label:  label,
+		})
+	}
+
+	padding := (maxY - minY) / 10
+	graph := chart.Chart{
+		XAxis: chart.XAxis{
+			Style: chart.Style{Show: true},
+			Range: &chart.ContinuousRange{
+				Min: timeToFloat(bars[0].GetTime()),
+				Max: timeToFloat(bars[len(bars)-1].GetTime()),
+			},
+			ValueFormatter: floatTimeFormatter(bars[0].GetTime(), bars[len(bars)-1].GetTime()),
+		},
+		YAxis: chart.YAxis{
+			Style: chart.Style{Show: true},
+			Range: &chart.ContinuousRange{
+				Min: minY - padding,
+				Max: maxY + padding,
+			},
+		},
+		Series: []chart.Series{
+			candleSeries{name: symbol, bars: bars},
+			chart.AnnotationSeries{
+				Name:        "Fills",
+				Style:       chart.Style{Show: true},
+				Annotations: markers,
+			},
+		},
+	}
+	if len(s.annotations) > 0 {
+		graph.Series = append(graph.Series, annotationLines{annotations: s.annotations})
+	}
+
+	if opts.Title == "" {
+		opts.Title = symbol
+	}
+	opts.apply(&graph)
+
+	return graph.Render(opts.renderer(), w)
+}
+
+// candleSeries renders OHLC bars as candlesticks
+type candleSeries struct {
+	name string
+	bars []Bar
+}
+
+// GetName implements the chart.Series interface
+func (c candleSeries) GetName() string {
+	return c.name
+}
+
+// GetYAxis implements the chart.Series interface
+func (c candleSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+// GetStyle implements the chart.Series interface
+func (c candleSeries) GetStyle() chart.Style {
+	return chart.Style{Show: true}
+}
+
+// Validate implements the chart.Series interface
+func (c candleSeries) Validate() error {
+	if len(c.bars) == 0 {
+		return errors.New("candle series has no bars")
+	}
+	return nil
+}
+
+// Render draws a wick from low to high and a body from open to close for each bar,
+// green for rising and red for falling bars
+func (c candleSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	width := 1
+	if len(c.bars) > 0 {
+		width = (canvasBox.Right - canvasBox.Left) / (2 * len(c.bars))
+		if width < 1 {
+			width = 1
+		}
+	}
+
+	for _, b := range c.bars {
+		x := canvasBox.Left + xrange.Translate(timeToFloat(b.GetTime()))
+		yHigh := canvasBox.Bottom - yrange.Translate(b.High)
+		yLow := canvasBox.Bottom - yrange.Translate(b.Low)
+		yOpen := canvasBox.Bottom - yrange.Translate(b.Open)
+		yClose := canvasBox.Bottom - yrange.Translate(b.Close)
+
+		color := drawing.ColorFromHex("2ca02c")
+		if b.Close < b.Open {
+			color = drawing.ColorFromHex("d62728")
+		}
+		r.SetStrokeColor(color)
+		r.SetFillColor(color)
+		r.SetStrokeWidth(1)
+
+		r.MoveTo(x, yHigh)
+		r.LineTo(x, yLow)
+		r.Stroke()
+
+		top, bottom := yOpen, yClose
+		if bottom < top {
+			top, bottom = bottom, top
+		}
+		if bottom == top {
+			bottom++
+		}
+		r.MoveTo(x-width/2, top)
+		r.LineTo(x+width/2+1, top)
+		r.LineTo(x+width/2+1, bottom)
+		r.LineTo(x-width/2, bottom)
+		r.Close()
+		r.FillStroke()
+	}
+}
+
+// timeToFloat converts a time into a float chart value, the same way time series do
+func timeToFloat(t time.Time) float64 {
+	return float64(t.UnixNano())
+}
+
+// floatTimeFormatter formats float chart values created by timeToFloat
+func floatTimeFormatter(start, end time.Time) chart.ValueFormatter {
+	layout := "2006-01-02"
+	if end.Sub(start) <= 48*time.Hour {
+		layout = "01-02 15:04"
+	}
+	return func(v interface{}) string {
+		if f, ok := v.(float64); ok {
+			return time.Unix(0, int64(f)).Format(layout)
+		}
+		return ""
+	}
+}
+
+// GraphReturns serves the histogram of the per bar equity returns as chart
+func (s *Statistic) GraphReturns(res http.ResponseWriter, req *http.Request) {
+	opts := ChartOptionsFromRequest(req)
+	res.Header().Set("Content-Type", opts.ContentType())
+	if err := s.WriteReturnsHistogram(res, 20, opts); err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+	}
+}
+
+// WriteReturnsHistogram renders the histogram of the per bar equity returns with the
+// given number of bins, the mean and standard deviation are shown in the title
+func (s *Statistic) WriteReturnsHistogram(w io.Writer, bins int, opts ChartOptions) error {
+	if len(s.equity) < 2 {
+		return errors.New("not enough equity points for a returns histogram")
+	}
+	if bins < 1 {
+		return errors.New("histogram needs at least one bin")
+	}
+
+	// the first equity point has no return
+	returns := make([]float64, len(s.equity)-1)
+	for i, e := range s.equity[1:] {
+		returns[i] = e.equityReturn
+	}
+	mean, stddev := stat.MeanStdDev(returns, nil)
+
+	lo, hi := returns[0], returns[0]
+	for _, r := range returns {
+		lo = math.Min(lo, r)
+		hi = math.Max(hi, r)
+	}
+	width := (hi - lo) / float64(bins)
+	if width == 0 {
+		width = 1
+	}
+
+	counts := make([]float64, bins)
+	for _, r := range returns {
+		i := int((r - lo) / width)
+		if i >= bins {
+			i = bins - 1
+		}
+		counts[i]++
+	}
+
+	var bars []chart.Value
+	for i, count := range counts {
+		center := lo + (float64(i)+0.5)*width
+		style := chart.Style{
+			Show:        true,
+			FillColor:   chart.GetDefaultColor(0),
+			StrokeColor: chart.GetDefaultColor(0),
+		}
+		// highlight the bins containing the mean and the one standard deviation bounds
+		if math.Abs(center-mean) <= width/2 || math.Abs(math.Abs(center-mean)-stddev) <= width/2 {
+			style.FillColor = chart.GetDefaultColor(1)
+			style.StrokeColor = chart.GetDefaultColor(1)
+		}
+		bars = append(bars, chart.Value{
+			Label: fmt.Sprintf("%.2f%%", center*100),
+			Value: count,
+			Style: style,
+		})
+	}
+
+	title := fmt.Sprintf("Returns (mean %.4f%%, stddev %.4f%%)", mean*100, stddev*100)
+	if opts.Title != "" {
+		title = opts.Title + " " + title
+	}
+	theme := opts.theme()
+
+	graph := chart.BarChart{
+		Title:      title,
+		TitleStyle: chart.Style{Show: true, FontColor: theme.font},
+		Width:      opts.Width,
+		Height:     opts.Height,
+		Background: chart.Style{FillColor: theme.background},
+		Canvas:     chart.Style{FillColor: theme.background},
+		XAxis:      chart.Style{Show: true, FontColor: theme.font, StrokeColor: theme.axis},
+		YAxis: chart.YAxis{
+			Style: chart.Style{Show: true, FontColor: theme.font, StrokeColor: theme.axis},
+		},
+		Bars: bars,
+	}
+
+	return graph.Render(opts.renderer(), w)
+}
+
+// performanceBands shades the periods the equity is above the benchmark green
+// and the periods it is below the benchmark red
+type performanceBands struct {
+	equity []equityPoint
+}
+
+// GetName implements the chart.Series interface
+func (p performanceBands) GetName() string {
+	return "Out/underperformance"
+}
+
+// GetYAxis implements the chart.Series interface
+func (p performanceBands) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+// GetStyle implements the chart.Series interface
+func (p performanceBands) GetStyle() chart.Style {
+	return chart.Style{Show: true}
+}
+
+// Validate implements the chart.Series interface
+func (p performanceBands) Validate() error {
+	return nil
+}
+
+// Render draws a translucent band from each equity point to the next
+func (p performanceBands) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	for i := 0; i+1 < len(p.equity); i++ {
+		e := p.equity[i]
+		if e.benchmarkValue == 0 || e.equity == e.benchmarkValue {
+			continue
+		}
+
+		color := drawing.ColorFromHex("2ca02c").WithAlpha(40)
+		if e.equity < e.benchmarkValue {
+			color = drawing.ColorFromHex("d62728").WithAlpha(40)
+		}
+		r.SetFillColor(color)
+		r.SetStrokeColor(drawing.ColorTransparent)
+
+		x1 := canvasBox.Left + xrange.Translate(timeToFloat(e.timestamp))
+		x2 := canvasBox.Left + xrange.Translate(timeToFloat(p.equity[i+1].timestamp))
+		r.MoveTo(x1, canvasBox.Top)
+		r.LineTo(x2, canvasBox.Top)
+		r.LineTo(x2, canvasBox.Bottom)
+		r.LineTo(x1, canvasBox.Bottom)
+		r.Close()
+		r.Fill()
+	}
+}
+
+// GraphDrawdown serves the drawdown of the equity curve as chart
+func (s *Statistic) GraphDrawdown(res http.ResponseWriter, req *http.Request) {
+	opts := ChartOptionsFromRequest(req)
+	res.Header().Set("Content-Type", opts.ContentType())
+	if err := s.WriteDrawdownChart(res, opts); err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+	}
+}
+
+// WriteDrawdownChart renders the drawdown of the equity curve in percent
+func (s *Statistic) WriteDrawdownChart(w io.Writer, opts ChartOptions) error {
+	if len(s.equity) < 2 {
+		return errors.New("not enough equity points for a drawdown chart")
+	}
+
+	var xv []time.Time
+	var yv []float64
+	minY := 0.0
+	for _, e := range s.equity {
+		xv = append(xv, e.timestamp)
+		yv = append(yv, e.drawdown*100)
+		minY = math.Min(minY, e.drawdown*100)
+	}
+	if minY == 0 {
+		minY = -1
+	}
+
+	graph := chart.Chart{
+		XAxis: chart.XAxis{
+			Style:          chart.Style{Show: true},
+			ValueFormatter: timeFormatter(xv),
+		},
+		YAxis: chart.YAxis{
+			Name:      "Drawdown %",
+			NameStyle: chart.Style{Show: true},
+			Style:     chart.Style{Show: true},
+			Range: &chart.ContinuousRange{
+				Min: minY * 1.1,
+				Max: 0,
+			},
+		},
+		Series: []chart.Series{
+			chart.TimeSeries{
+				Name: "Drawdown",
+				Style: chart.Style{
+					Show:        true,
+					StrokeColor: drawing.ColorFromHex("d62728"),
+					FillColor:   drawing.ColorFromHex("d62728").WithAlpha(64),
+				},
+				XValues: xv,
+				YValues: yv,
+			},
+		},
+	}
+	opts.apply(&graph)
+
+	return graph.Render(opts.renderer(), w)
+}
+
+// GraphRolling serves the rolling Sharp ratio and volatility as chart,
+// the window is read from the window query parameter and defaults to 20 periods
+func (s *Statistic) GraphRolling(res http.ResponseWriter, req *http.Request) {
+	opts := ChartOptionsFromRequest(req)
+	window, err := strconv.Atoi(req.URL.Query().Get("window"))
+	if err != nil {
+		window = 20
+	}
+
+	res.Header().Set("Content-Type", opts.ContentType())
+	if err := s.WriteRollingChart(res, window, opts); err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+	}
+}
+
+// WriteRollingChart renders the rolling Sharp ratio on the left axis and the rolling
+// volatility in percent on the right axis over a window of periods
+func (s *Statistic) WriteRollingChart(w io.Writer, window int, opts ChartOptions) error {
+	sharpe := s.RollingSharpe(window, 0)
+	volatility := s.RollingVolatility(window)
+	if len(volatility) < 2 {
+		return errors.New("not enough equity points for a rolling chart")
+	}
+
+	var sharpeX, volX []time.Time
+	var sharpeY, volY []float64
+	for _, p := range sharpe {
+		sharpeX = append(sharpeX, p.Timestamp)
+		sharpeY = append(sharpeY, p.Value)
+	}
+	for _, p := range volatility {
+		volX = append(volX, p.Timestamp)
+		volY = append(volY, p.Value*100)
+	}
+
+	series := []chart.Series{
+		chart.TimeSeries{
+			Name:    fmt.Sprintf("Volatility %d", window),
+			YAxis:   chart.YAxisSecondary,
+			Style:   chart.Style{Show: true, StrokeColor: drawing.ColorFromHex("ff7f0e")},
+			XValues: volX,
+			YValues: volY,
+		},
+	}
+	if len(sharpe) > 1 {
+		series = append(series, chart.TimeSeries{
+			Name:    fmt.Sprintf("Sharp ratio %d", window),
+			Style:   chart.Style{Show: true, StrokeColor: drawing.ColorFromHex("1f77b4")},
+			XValues: sharpeX,
+			YValues: sharpeY,
+		})
+	}
+
+	graph := chart.Chart{
+		XAxis: chart.XAxis{
+			Style:          chart.Style{Show: true},
+			ValueFormatter: timeFormatter(volX),
+		},
+		YAxis: chart.YAxis{
+			Name:      "Sharp ratio",
+			NameStyle: chart.Style{Show: true},
+			Style:     chart.Style{Show: true},
+		},
+		YAxisSecondary: chart.YAxis{
+			Name:      "Volatility %",
+			NameStyle: chart.Style{Show: true},
+			Style:     chart.Style{Show: true},
+		},
+		Series: series,
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+	opts.apply(&graph)
+
+	return graph.Render(opts.renderer(), w)
+}
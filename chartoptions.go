@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+// ChartFormat declares the output format of a chart
+type ChartFormat string
+
+// ChartTheme declares the color theme of a chart
+type ChartTheme string
+
+const (
+	// ChartPNG renders charts as PNG image
+	ChartPNG ChartFormat = "png"
+	// ChartSVG renders charts as SVG image
+	ChartSVG ChartFormat = "svg"
+
+	// ThemeLight renders dark lines on a white background
+	ThemeLight ChartTheme = "light"
+	// ThemeDark renders light lines on a dark background
+	ThemeDark ChartTheme = "dark"
+)
+
+// ChartOptions sets the format, size, title and theme of a chart.
+// Zero values use PNG, the default size of the chart library, no title and the light theme.
+type ChartOptions struct {
+	Format ChartFormat
+	Width  int
+	Height int
+	Title  string
+	Theme  ChartTheme
+}
+
+// ChartOptionsFromRequest reads the chart options from the format, width, height,
+// title and theme query parameters of a request
+func ChartOptionsFromRequest(req *http.Request) ChartOptions {
+	q := req.URL.Query()
+	opts := ChartOptions{
+		Format: ChartFormat(q.Get("format")),
+		Title:  q.Get("title"),
+		Theme:  ChartTheme(q.Get("theme")),
+	}
+	opts.Width, _ = strconv.Atoi(q.Get("width"))
+	opts.Height, _ = strconv.Atoi(q.Get("height"))
+	return opts
+}
+
+// ContentType returns the MIME type of the chart format
+func (o ChartOptions) ContentType() string {
+	if o.Format == ChartSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// renderer returns the chart renderer of the format
+func (o ChartOptions) renderer() chart.RendererProvider {
+	if o.Format == ChartSVG {
+		return chart.SVG
+	}
+	return chart.PNG
+}
+
+// chartTheme holds the colors of a theme
+type chartTheme struct {
+	background drawing.Color
+	font       drawing.Color
+	axis       drawing.Color
+}
+
+// theme returns the colors of the theme
+func (o ChartOptions) theme() chartTheme {
+	if o.Theme == ThemeDark {
+		return chartTheme{
+			background: drawing.ColorFromHex("1e1e1e"),
+			font:       drawing.ColorFromHex("e0e0e0"),
+			axis:       drawing.ColorFromHex("808080"),
+		}
+	}
+	return chartTheme{
+		background: drawing.ColorWhite,
+		font:       drawing.ColorFromHex("333333"),
+		axis:       drawing.ColorFromHex("808080"),
+	}
+}
+
+// apply sets the size, title and theme on a chart
+func (o ChartOptions) apply(c *chart.Chart) {
+	theme := o.theme()
+
+	c.Width = o.Width
+	c.Height = o.Height
+	if o.Title != "" {
+		c.Title = o.Title
+		c.TitleStyle = chart.Style{Show: true, FontColor: theme.font}
+	}
+
+	c.Background.FillColor = theme.background
+	c.Canvas.FillColor = theme.background
+	c.XAxis.Style.FontColor = theme.font
+	c.XAxis.Style.StrokeColor = theme.axis
+	c.XAxis.NameStyle.FontColor = theme.font
+	c.YAxis.Style.FontColor = theme.font
+	c.YAxis.Style.StrokeColor = theme.axis
+	c.YAxis.NameStyle.FontColor = theme.font
+}
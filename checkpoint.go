@@ -0,0 +1,380 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	// concrete event types stored behind interfaces in the checkpoints, gob restores
+	// either the value or the pointer type, so the types are registered as the engine
+	// and the base strategy create them
+	for _, v := range []interface{}{
+		Bar{}, Tick{},
+		&Signal{}, &BasketSignal{}, &CancelOrder{}, &ModifyOrder{},
+		&Order{}, &Fill{}, &StrategyError{}, &Discrepancy{},
+	} {
+		gob.Register(v)
+	}
+}
+
+// checkpoint is the state of a test between two data events
+type checkpoint struct {
+	Processed int // data events taken from the data stream
+	Bars      int
+	Start     time.Time
+	Warnings  []Warning
+	Portfolio []byte
+	Exchange  []byte
+	Statistic []byte
+	Strategy  []byte
+}
+
+// SetCheckpoint writes a checkpoint to the file after every n data events, which can be
+// resumed with Resume after a restart of the process
+func (t *Test) SetCheckpoint(path string, every int) {
+	t.checkpointPath = path
+	t.checkpointEvery = every
+}
+
+// Checkpoint writes the state of the test, the position in the data stream and the states
+// of the portfolio, exchange, statistic and strategy supporting the StateSaver interface.
+// The regime detector, filters, feature exporter and live outputs are not included.
+func (t *Test) Checkpoint(w io.Writer) error {
+	c := checkpoint{
+		Processed: t.processed,
+		Bars:      t.bars,
+		Start:     t.start,
+		Warnings:  t.warnings,
+	}
+
+	components := []struct {
+		component interface{}
+		state     *[]byte
+	}{
+		{t.portfolio, &c.Portfolio},
+		{t.exchange, &c.Exchange},
+		{t.statistic, &c.Statistic},
+		{t.strategy, &c.Strategy},
+	}
+	for _, comp := range components {
+		s, ok := comp.component.(StateSaver)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := s.SaveState(&buf); err != nil {
+			return err
+		}
+		*comp.state = buf.Bytes()
+	}
+
+	return gob.NewEncoder(w).Encode(c)
+}
+
+// WriteCheckpoint writes a checkpoint to a file, replacing it only when complete
+func (t *Test) WriteCheckpoint(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err := t.Checkpoint(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Resume restores a checkpoint file and runs the test to the end. The test has to be set
+// up like the checkpointed one, with the same data not yet streamed.
+func (t *Test) Resume(path string) (Results, error) {
+	return t.ResumeContext(context.Background(), path)
+}
+
+// ResumeContext restores a checkpoint file and runs the test until the context is done
+func (t *Test) ResumeContext(ctx context.Context, path string) (Results, error) {
+	if err := t.Validate(); err != nil {
+		return Results{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Results{}, err
+	}
+	err = t.restore(f)
+	f.Close()
+	if err != nil {
+		return Results{}, err
+	}
+	return t.RunContext(ctx)
+}
+
+// restore reads a checkpoint and forwards the data stream to its position
+func (t *Test) restore(r io.Reader) error {
+	var c checkpoint
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return err
+	}
+
+	// stream the processed data events again in the order of the run, which sorted the
+	// stream before its first event, so the data handler knows their history
+	if s, ok := t.data.(StreamSorter); ok {
+		s.SortStream()
+	}
+	for i := 0; i < c.Processed; i++ {
+		if _, ok := t.data.Next(); !ok {
+			return errors.New("data ends before the checkpoint")
+		}
+	}
+
+	components := []struct {
+		name      string
+		component interface{}
+		state     []byte
+	}{
+		{"portfolio", t.portfolio, c.Portfolio},
+		{"exchange", t.exchange, c.Exchange},
+		{"statistic", t.statistic, c.Statistic},
+		{"strategy", t.strategy, c.Strategy},
+	}
+	for _, comp := range components {
+		if comp.state == nil {
+			continue
+		}
+		s, ok := comp.component.(StateSaver)
+		if !ok {
+			return fmt.Errorf("%s does not support loading its state", comp.name)
+		}
+		if err := s.LoadState(bytes.NewReader(comp.state)); err != nil {
+			return fmt.Errorf("could not load %s state: %v", comp.name, err)
+		}
+	}
+
+	t.eventQueue.Reset()
+	t.processed = c.Processed
+	t.bars = c.Bars
+	t.start = c.Start
+	t.warnings = c.Warnings
+	t.resumed = true
+	return nil
+}
+
+// positionState is the stored state of a position
+type positionState struct {
+	Timestamp time.Time
+	Symbol    string
+	Values    []float64
+}
+
+// values returns pointers to all numeric fields of the position
+func (p *position) values() []*float64 {
+	return []*float64{
+		&p.qty, &p.qtyBOT, &p.qtySLD,
+		&p.avgPrice, &p.avgPriceNet, &p.avgPriceBOT, &p.avgPriceSLD,
+		&p.value, &p.valueBOT, &p.valueSLD,
+		&p.netValue, &p.netValueBOT, &p.netValueSLD,
+		&p.marketPrice, &p.marketValue,
+		&p.commission, &p.exchangeFee, &p.cost, &p.costBasis,
+		&p.realProfitLoss, &p.unrealProfitLoss, &p.totalProfitLoss,
+	}
+}
+
+// portfolioState is the stored state of a portfolio
+type portfolioState struct {
+	InitialCash  float64
+	Cash         float64
+	Holdings     []positionState
+	Transactions []FillEvent
+}
+
+// SaveState implements the StateSaver interface and writes the cash, holdings and transactions
+func (p *Portfolio) SaveState(w io.Writer) error {
+	state := portfolioState{InitialCash: p.initialCash, Cash: p.cash, Transactions: p.transactions}
+	for _, pos := range p.holdings {
+		ps := positionState{Timestamp: pos.timestamp, Symbol: pos.symbol}
+		for _, v := range pos.values() {
+			ps.Values = append(ps.Values, *v)
+		}
+		state.Holdings = append(state.Holdings, ps)
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+// LoadState implements the StateSaver interface and restores the cash, holdings and transactions
+func (p *Portfolio) LoadState(r io.Reader) error {
+	var state portfolioState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+
+	p.initialCash = state.InitialCash
+	p.cash = state.Cash
+	p.transactions = state.Transactions
+	p.holdings = make(map[string]position, len(state.Holdings))
+	for _, ps := range state.Holdings {
+		pos := position{timestamp: ps.Timestamp, symbol: ps.Symbol}
+		fields := pos.values()
+		if len(ps.Values) != len(fields) {
+			return errors.New("position state does not match")
+		}
+		for i, v := range fields {
+			*v = ps.Values[i]
+		}
+		p.holdings[ps.Symbol] = pos
+	}
+	return nil
+}
+
+// pendingOrder is the stored state of a pending order
+type pendingOrder struct {
+	Order Order
+	Bars  int
+}
+
+// exchangeState is the stored state of an exchange
+type exchangeState struct {
+	Orders []pendingOrder
+	LastID int
+}
+
+// SaveState implements the StateSaver interface and writes the pending orders
+func (e *Exchange) SaveState(w io.Writer) error {
+	state := exchangeState{LastID: e.lastID}
+	for _, o := range e.orders {
+		state.Orders = append(state.Orders, pendingOrder{Order: *o, Bars: o.bars})
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+// LoadState implements the StateSaver interface and restores the pending orders
+func (e *Exchange) LoadState(r io.Reader) error {
+	var state exchangeState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+
+	e.lastID = state.LastID
+	e.orders = nil
+	for _, po := range state.Orders {
+		o := po.Order
+		o.bars = po.Bars
+		e.orders = append(e.orders, &o)
+	}
+	return nil
+}
+
+// attributionState is the stored state of an attribution book
+type attributionState struct {
+	Cash float64
+	Qty  map[string]float64
+	PnL  []float64
+}
+
+// setEquity replaces the equity series of the statistic with the points
+func (s *Statistic) setEquity(points []EquityPoint) {
+	s.equity = nil
+	s.high = equityPoint{}
+	s.low = equityPoint{}
+	for _, p := range points {
+		e := equityPoint{
+			timestamp:       p.Timestamp,
+			equity:          p.Equity,
+			equityReturn:    p.Return,
+			drawdown:        p.Drawdown,
+			buyAndHoldValue: p.BuyAndHold,
+			benchmarkValue:  p.Benchmark,
+			exposure:        p.Exposure,
+		}
+		s.prevHigh, s.prevLow = s.high, s.low
+		if e.equity >= s.high.equity {
+			s.high = e
+		}
+		if e.equity <= s.low.equity {
+			s.low = e
+		}
+		s.equity = append(s.equity, e)
+	}
+}
+
+// statisticState is the stored state of a statistic
+type statisticState struct {
+	Events         []EventHandler
+	Transactions   []FillEvent
+	Equity         []EquityPoint
+	InitialBuy     float64
+	InitialSymbol  string
+	InitialCash    float64
+	BenchmarkIndex int
+	BenchmarkBase  float64
+	Prices         map[string]float64
+	Attribution    map[string]attributionState
+}
+
+// SaveState implements the StateSaver interface and writes the tracked events, transactions
+// and equity points. The benchmark, annotations and settings are not included.
+func (s *Statistic) SaveState(w io.Writer) error {
+	state := statisticState{
+		Transactions:   s.transactionHistory,
+		Equity:         s.Equity(),
+		InitialBuy:     s.initialBuy,
+		InitialSymbol:  s.initialSymbol,
+		InitialCash:    s.initialCash,
+		BenchmarkIndex: s.benchmarkIndex,
+		BenchmarkBase:  s.benchmarkBase,
+		Prices:         s.prices,
+		Attribution:    make(map[string]attributionState, len(s.attribution)),
+	}
+	for _, e := range s.eventHistory {
+		// the panic value may not be encodable, keep its text
+		if se, ok := e.(*StrategyError); ok {
+			copied := *se
+			copied.Panic = fmt.Sprint(se.Panic)
+			e = &copied
+		}
+		state.Events = append(state.Events, e)
+	}
+	for tag, book := range s.attribution {
+		state.Attribution[tag] = attributionState{Cash: book.cash, Qty: book.qty, PnL: book.pnl}
+	}
+	return gob.NewEncoder(w).Encode(state)
+}
+
+// LoadState implements the StateSaver interface and restores the tracked events,
+// transactions and equity points
+func (s *Statistic) LoadState(r io.Reader) error {
+	var state statisticState
+	if err := gob.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+
+	s.eventHistory = state.Events
+	s.transactionHistory = state.Transactions
+	s.initialBuy = state.InitialBuy
+	s.initialSymbol = state.InitialSymbol
+	s.initialCash = state.InitialCash
+	s.benchmarkIndex = state.BenchmarkIndex
+	s.benchmarkBase = state.BenchmarkBase
+	s.prices = state.Prices
+
+	s.setEquity(state.Equity)
+
+	s.attribution = nil
+	if len(state.Attribution) > 0 {
+		s.attribution = make(map[string]*attributionBook, len(state.Attribution))
+		for tag, a := range state.Attribution {
+			s.attribution[tag] = &attributionBook{cash: a.Cash, qty: a.Qty, pnl: a.PnL}
+		}
+	}
+	return nil
+}
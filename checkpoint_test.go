@@ -0,0 +1,57 @@
+package backtest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+var checkpointPrices = []float64{100, 101, 103, 102, 105, 107, 104, 103, 106, 108, 110, 107, 105, 108, 111, 113, 112, 115, 114, 116}
+
+// cycleStrategy buys on the third bar of every six and closes on the sixth, it calls stop
+// on the bar stopAt if set
+func cycleStrategy(stopAt int, stop func()) StrategyHandler {
+	return NewBaseStrategy("A", DeciderFunc(func(b *BaseStrategy) (SignalEvent, error) {
+		n := len(b.Data().List("A"))
+		if n == stopAt && stop != nil {
+			stop()
+		}
+		switch {
+		case n%6 == 3 && !b.IsInvested():
+			return b.Buy(10), nil
+		case n%6 == 0 && b.IsInvested():
+			return b.ClosePosition(), nil
+		}
+		return b.Hold(), nil
+	}))
+}
+
+func TestCheckpointResume(t *testing.T) {
+	want, err := newTestRun(closeBars("A", checkpointPrices...), cycleStrategy(0, nil)).Run()
+	if err != nil {
+		t.Fatalf("uninterrupted run failed: %v", err)
+	}
+
+	// stop a run with periodic checkpoints in the middle of a position
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupted := newTestRun(closeBars("A", checkpointPrices...), cycleStrategy(11, cancel))
+	interrupted.SetCheckpoint(path, 5)
+	if _, err := interrupted.RunContext(ctx); err == nil {
+		t.Fatal("interrupted run ended without error")
+	}
+
+	// resume with a new test set up like the interrupted one
+	got, err := newTestRun(closeBars("A", checkpointPrices...), cycleStrategy(0, nil)).Resume(path)
+	if err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+	if got.Value != want.Value || got.Cash != want.Cash {
+		t.Errorf("resumed run ends at value %v and cash %v, want %v and %v", got.Value, got.Cash, want.Value, want.Cash)
+	}
+	if w, g := want.Statistic.(*Statistic), got.Statistic.(*Statistic); len(g.equity) != len(w.equity) || len(g.transactionHistory) != len(w.transactionHistory) {
+		t.Errorf("resumed run has %d equity points and %d fills, want %d and %d",
+			len(g.equity), len(g.transactionHistory), len(w.equity), len(w.transactionHistory))
+	}
+}
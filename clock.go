@@ -0,0 +1,186 @@
+package backtest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock tells the time of a test. Backtests run on the event time as fast as possible,
+// paper and live trading on the wall time.
+type Clock interface {
+	Now() time.Time
+	// Advance moves the clock to the time of the next event
+	Advance(time.Time)
+}
+
+// EventClock is the clock of backtests, its time is the time of the latest event
+type EventClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// Now implements the Clock interface
+func (c *EventClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Advance implements the Clock interface, the clock never moves back
+func (c *EventClock) Advance(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Reset implements the Reseter interface and sets the clock back to the zero time
+func (c *EventClock) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = time.Time{}
+}
+
+// RealClock is the wall clock of paper and live trading
+type RealClock struct{}
+
+// Now implements the Clock interface
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Advance implements the Clock interface, the wall time moves by itself
+func (RealClock) Advance(time.Time) {}
+
+// Schedule returns the times a timer fires
+type Schedule interface {
+	// Next returns the first time of the schedule after the given time
+	Next(time.Time) time.Time
+}
+
+// ScheduleFunc is a function implementing the Schedule interface
+type ScheduleFunc func(time.Time) time.Time
+
+// Next implements the Schedule interface
+func (f ScheduleFunc) Next(t time.Time) time.Time {
+	return f(t)
+}
+
+// Every returns a schedule firing at multiples of the duration, e.g. every full hour. The
+// duration must be positive, tests with timers of other durations don't validate.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+// every is the schedule of Every
+type every time.Duration
+
+// Next implements the Schedule interface, the zero time if the duration is not positive
+func (e every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	if d <= 0 {
+		return time.Time{}
+	}
+	return t.Truncate(d).Add(d)
+}
+
+// Validate implements the Validator interface
+func (e every) Validate() error {
+	if e <= 0 {
+		return fmt.Errorf("interval must be positive, is %v", time.Duration(e))
+	}
+	return nil
+}
+
+// Daily returns a schedule firing every day at the hour and minute
+func Daily(hour, minute int) Schedule {
+	return ScheduleFunc(func(t time.Time) time.Time {
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	})
+}
+
+// Weekly returns a schedule firing every week on the day at the hour and minute,
+// e.g. Weekly(time.Monday, 0, 0) to rebalance every Monday 00:00
+func Weekly(day time.Weekday, hour, minute int) Schedule {
+	daily := Daily(hour, minute)
+	return ScheduleFunc(func(t time.Time) time.Time {
+		next := daily.Next(t)
+		for next.Weekday() != day {
+			next = daily.Next(next)
+		}
+		return next
+	})
+}
+
+// TimerFunc is called at the times of a schedule and returns events to queue, e.g. the
+// signals of a rebalancing
+type TimerFunc func(now time.Time) []EventHandler
+
+// timer is a scheduled function of a test
+type timer struct {
+	schedule Schedule
+	fn       TimerFunc
+	next     time.Time
+	stopped  bool // the schedule did not advance
+}
+
+// SetClock sets the clock of the test, an EventClock if not set
+func (t *Test) SetClock(c Clock) {
+	t.clock = c
+}
+
+// Clock returns the clock of the test
+func (t *Test) Clock() Clock {
+	if t.clock == nil {
+		t.clock = &EventClock{}
+	}
+	return t.clock
+}
+
+// AddTimer calls the function at the times of the schedule, before the first data event
+// at or after each time. The returned events are processed like the events of the strategy.
+// A timer whose schedule returns a time not after the previous one is stopped.
+func (t *Test) AddTimer(s Schedule, fn TimerFunc) {
+	t.timers = append(t.timers, &timer{schedule: s, fn: fn})
+}
+
+// fireTimers calls the timers due until the time of a data event and queues their events
+func (t *Test) fireTimers(until time.Time) {
+	for _, tm := range t.timers {
+		if tm.stopped {
+			continue
+		}
+		if tm.next.IsZero() {
+			// fire on the first data event if it is on the schedule
+			from := until.Add(-time.Nanosecond)
+			if tm.next = tm.schedule.Next(from); !tm.next.After(from) {
+				t.stopTimer(tm)
+				continue
+			}
+		}
+		for !tm.next.After(until) {
+			t.Clock().Advance(tm.next)
+			for _, e := range tm.fn(tm.next) {
+				t.eventQueue.Append(e)
+			}
+			next := tm.schedule.Next(tm.next)
+			if !next.After(tm.next) {
+				t.stopTimer(tm)
+				break
+			}
+			tm.next = next
+		}
+	}
+}
+
+// stopTimer stops a timer whose schedule does not advance, it would fire forever
+func (t *Test) stopTimer(tm *timer) {
+	tm.stopped = true
+	t.log().Warn("timer stopped, its schedule does not advance", "time", tm.next)
+}
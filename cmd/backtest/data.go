@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// dataCommand downloads the CSV bars of a symbol into the cache directory or bucket
+func dataCommand(args []string) error {
+	fs := flag.NewFlagSet("data", flag.ExitOnError)
+	url := fs.String("url", "", "URL of the CSV bars (required)")
+	symbol := fs.String("symbol", "", "symbol of the bars (required)")
+	cache := fs.String("cache", "data", "cache directory or bucket URL like s3://bucket/data, the bars are saved as <symbol>.csv")
+	refresh := fs.Bool("refresh", false, "download the bars even if cached")
+	fs.Parse(args)
+
+	if *url == "" || *symbol == "" {
+		return errors.New("missing -url or -symbol")
+	}
+	store, prefix, err := backtest.OpenObjectStore(*cache)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key := prefix + *symbol + ".csv"
+	location := strings.TrimRight(*cache, "/") + "/" + *symbol + ".csv"
+	if _, err := store.Get(ctx, key); err == nil && !*refresh {
+		fmt.Println("cached", location)
+		return nil
+	}
+
+	resp, err := http.Get(*url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// check the download before it replaces the cache
+	bars, err := backtest.ReadBarsCSV(bytes.NewReader(data), *symbol)
+	if err != nil {
+		return fmt.Errorf("invalid bars: %v", err)
+	}
+	if err := store.Put(ctx, key, data); err != nil {
+		return err
+	}
+	fmt.Printf("saved %d bars to %s\n", len(bars), location)
+	return nil
+}
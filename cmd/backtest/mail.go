@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/ivtpz/backtest-go/notify"
+)
+
+// mailer creates the email sender to the comma separated recipients, the SMTP server is
+// configured by the environment: BACKTEST_SMTP_ADDR as host:port, BACKTEST_SMTP_USER,
+// BACKTEST_SMTP_PASSWORD and BACKTEST_SMTP_FROM, the user if not set
+func mailer(to string) (*notify.Email, error) {
+	addr := os.Getenv("BACKTEST_SMTP_ADDR")
+	if addr == "" {
+		return nil, errors.New("sending email needs BACKTEST_SMTP_ADDR")
+	}
+	user := os.Getenv("BACKTEST_SMTP_USER")
+	from := os.Getenv("BACKTEST_SMTP_FROM")
+	if from == "" {
+		from = user
+	}
+	if from == "" {
+		return nil, errors.New("sending email needs BACKTEST_SMTP_FROM or BACKTEST_SMTP_USER")
+	}
+	return notify.NewEmail(addr, user, os.Getenv("BACKTEST_SMTP_PASSWORD"), from, strings.Split(to, ",")...), nil
+}
@@ -0,0 +1,74 @@
+// Command backtest runs backtests assembled from a spec file with the registered
+// components, without writing Go for every experiment.
+//
+// Usage:
+//
+//	backtest run -spec spec.json [-store runs] [-report report.html] [-mail-to me@example.com]
+//	backtest optimize -spec spec.json -param fast=5,10,20 -param slow=50,100 [-method grid] [-mail-to me@example.com]
+//	backtest report -store runs [-id ID] -out report.html
+//	backtest paper -spec spec.json -feed wss://example.com/bars [-duration 8h] [-control :8081] [-out paper.html]
+//	backtest data -url https://example.com/btc.csv -symbol BTC [-cache data]
+//	backtest serve [-addr :8080] [-grpc :9090] [-store runs] [-workers 1] [-data data]
+//
+// The spec is the JSON form of backtest.Spec, e.g.
+//
+//	{
+//		"data": [{"name": "csv", "path": "data/BTC.csv", "symbol": "BTC"}],
+//		"strategy": {"name": "ma_cross", "fast": 10, "slow": 50},
+//		"initial_cash": 10000,
+//		"commission": 0.001
+//	}
+//
+// or a YAML or TOML config file of the config package.
+//
+// The result store and the data cache can be buckets shared by several machines, e.g.
+// -store s3://bucket/runs or -cache gs://bucket/data, with the credentials of the
+// objstore package in the environment. CSV data paths can be bucket URLs as well.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	// the s3 and gs schemes of the -store and -cache locations
+	_ "github.com/ivtpz/backtest-go/objstore"
+)
+
+// commands are the subcommands by name
+var commands = map[string]func(args []string) error{
+	"run":      runCommand,
+	"optimize": optimizeCommand,
+	"report":   reportCommand,
+	"paper":    paperCommand,
+	"data":     dataCommand,
+	"serve":    serveCommand,
+}
+
+const usage = `usage: backtest <command> [flags]
+
+commands:
+  run        run a backtest from a spec file
+  optimize   search the strategy params of a spec for the best results
+  report     write the reports of a stored run
+  paper      paper trade a spec on the live bars of a websocket feed
+  data       download and cache CSV bars of a symbol
+  serve      run the backtest service with the REST and gRPC API
+
+run "backtest <command> -h" for the flags of a command
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "backtest:", err)
+		os.Exit(1)
+	}
+}
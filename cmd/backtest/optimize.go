@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/optimize"
+)
+
+// objectives are the optimization objectives by name
+var objectives = map[string]optimize.Objective{
+	"sharpe":     optimize.Sharpe,
+	"calmar":     optimize.Calmar,
+	"net_profit": optimize.NetProfit,
+}
+
+// optimizeCommand searches the strategy params of a spec
+func optimizeCommand(args []string) error {
+	fs := flag.NewFlagSet("optimize", flag.ExitOnError)
+	specPath := fs.String("spec", "", "path of the JSON spec or YAML/TOML config file (required)")
+	var params paramFlags
+	fs.Var(&params, "param", "strategy param values as name=v1,v2,... or name=min:max:step (repeatable)")
+	method := fs.String("method", "grid", "search method: grid, random or tpe")
+	objective := fs.String("objective", "sharpe", "objective: sharpe, calmar or net_profit")
+	runs := fs.Int("runs", 50, "maximum runs of the random and tpe search")
+	maxTime := fs.Duration("time", 0, "maximum wall time of the random and tpe search")
+	seed := fs.Int64("seed", 1, "seed of the random and tpe search")
+	workers := fs.Int("workers", 0, "concurrent tests, the number of CPUs if 0")
+	storeDir := fs.String("store", "", "directory or bucket URL of the result store, runs already stored are not run again")
+	out := fs.String("out", "", "file to write all results to, .csv or .json")
+	top := fs.Int("top", 10, "number of best results to print")
+	mailTo := fs.String("mail-to", "", "comma separated recipients of the best results, the SMTP server is set by BACKTEST_SMTP_* variables")
+	fs.Parse(args)
+
+	spec, _, err := readSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		return errors.New("missing -param")
+	}
+	obj, ok := objectives[*objective]
+	if !ok {
+		return fmt.Errorf("unknown objective %q", *objective)
+	}
+	var store *backtest.ResultStore
+	if *storeDir != "" {
+		if store, err = backtest.NewResultStore(*storeDir); err != nil {
+			return err
+		}
+	}
+
+	// each test gets its own spec with the params merged into the strategy params
+	setup := func(p map[string]interface{}) (*backtest.Test, error) {
+		s := spec
+		s.Strategy.Params = make(map[string]interface{}, len(spec.Strategy.Params)+len(p))
+		for k, v := range spec.Strategy.Params {
+			s.Strategy.Params[k] = v
+		}
+		for k, v := range p {
+			s.Strategy.Params[k] = v
+		}
+		return backtest.DefaultRegistry.Build(s)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	budget := optimize.Budget{MaxRuns: *runs, MaxTime: *maxTime}
+	var results []optimize.Result
+	switch *method {
+	case "grid":
+		results, err = optimize.GridSearch{Ranges: params, Objective: obj, Workers: *workers, Store: store}.Run(ctx, setup)
+	case "random":
+		results, err = optimize.RandomSearch{Ranges: params, Objective: obj, Workers: *workers, Budget: budget, Seed: *seed, Store: store}.Run(ctx, setup)
+	case "tpe":
+		results, err = optimize.TPESearch{Ranges: params, Objective: obj, Workers: *workers, Budget: budget, Seed: *seed, Store: store}.Run(ctx, setup)
+	default:
+		return fmt.Errorf("unknown method %q", *method)
+	}
+	if err != nil && len(results) == 0 {
+		return err
+	}
+
+	printResults(results, *top)
+	if *out != "" {
+		if err := writeResults(*out, results); err != nil {
+			return err
+		}
+		fmt.Println("wrote", *out)
+	}
+	if *mailTo != "" {
+		mail, mailErr := mailer(*mailTo)
+		if mailErr == nil {
+			mailErr = mail.SendOptimization("Optimization "+filepath.Base(*specPath), results, *top)
+		}
+		if mailErr != nil {
+			return mailErr
+		}
+		fmt.Println("mailed results to", *mailTo)
+	}
+	return err
+}
+
+// printResults prints the best results with their params
+func printResults(results []optimize.Result, top int) {
+	fmt.Printf("%d runs\n", len(results))
+	for i, r := range results {
+		if i == top {
+			break
+		}
+		if r.Err != nil {
+			fmt.Printf("%3d  failed  %v  %v\n", i+1, formatParams(r.Params), r.Err)
+			continue
+		}
+		fmt.Printf("%3d  %10.4f  %v\n", i+1, r.Score, formatParams(r.Params))
+	}
+}
+
+// formatParams formats params as name=value pairs sorted by name
+func formatParams(p map[string]interface{}) string {
+	var parts []string
+	for _, name := range sortedNames(p) {
+		parts = append(parts, fmt.Sprintf("%s=%v", name, p[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// sortedNames returns the sorted keys of the params
+func sortedNames(p map[string]interface{}) []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeResults writes the results as CSV or JSON by the extension of the path
+func writeResults(path string, results []optimize.Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		err = optimize.WriteCSV(f, results)
+	case ".json":
+		err = optimize.WriteJSON(f, results)
+	default:
+		err = fmt.Errorf("unknown output format of %s", path)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// paramFlags collects the repeated -param flags as ranges
+type paramFlags []optimize.Range
+
+// String implements the flag.Value interface
+func (p *paramFlags) String() string {
+	var names []string
+	for _, r := range *p {
+		names = append(names, r.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Set implements the flag.Value interface, values are parsed as int, float or string
+func (p *paramFlags) Set(v string) error {
+	name, values, ok := strings.Cut(v, "=")
+	if !ok || name == "" || values == "" {
+		return errors.New("param must be name=values")
+	}
+
+	// a min:max:step range
+	if parts := strings.Split(values, ":"); len(parts) == 3 {
+		ints := true
+		var nums [3]float64
+		for i, s := range parts {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return fmt.Errorf("invalid range %q", values)
+			}
+			nums[i] = n
+			ints = ints && !strings.ContainsAny(s, ".eE")
+		}
+		if nums[2] <= 0 {
+			return fmt.Errorf("range step of %s must be positive", name)
+		}
+		if ints {
+			*p = append(*p, optimize.Ints(name, int(nums[0]), int(nums[1]), int(nums[2])))
+		} else {
+			*p = append(*p, optimize.Floats(name, nums[0], nums[1], nums[2]))
+		}
+		return nil
+	}
+
+	r := optimize.Range{Name: name}
+	for _, s := range strings.Split(values, ",") {
+		if n, err := strconv.Atoi(s); err == nil {
+			r.Values = append(r.Values, n)
+		} else if f, err := strconv.ParseFloat(s, 64); err == nil {
+			r.Values = append(r.Values, f)
+		} else {
+			r.Values = append(r.Values, s)
+		}
+	}
+	*p = append(*p, r)
+	return nil
+}
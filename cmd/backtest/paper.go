@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// paperCommand paper trades the test of a spec on the bars of a websocket feed, the data
+// of the spec is the history warming up the strategy
+func paperCommand(args []string) error {
+	fs := flag.NewFlagSet("paper", flag.ExitOnError)
+	specPath := fs.String("spec", "", "path of the JSON spec or YAML/TOML config file (required)")
+	feedURL := fs.String("feed", "", "websocket URL of the live bars, as JSON objects with symbol, time, open, high, low, close and volume (required)")
+	subscribe := fs.String("subscribe", "", "JSON message sent after connecting, {symbols} is replaced by the symbols as JSON list")
+	duration := fs.Duration("duration", 0, "stop after the duration, runs until interrupted if 0")
+	control := fs.String("control", "", "address serving GET /health and POST /kill with the token of BACKTEST_KILL_TOKEN, e.g. localhost:8081")
+	stale := fs.Duration("stale", 5*time.Minute, "time without bars until /health reports the feed stale")
+	var outputs outputFlags
+	fs.Var(&outputs, "out", "output file, the format is taken from the extension: .html, .pdf, .json or .csv for the equity (repeatable)")
+	fs.Parse(args)
+
+	if *feedURL == "" {
+		return errors.New("missing -feed")
+	}
+	spec, reports, err := readSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	outputs = append(outputs, reports...)
+	t, err := backtest.DefaultRegistry.Build(spec)
+	if err != nil {
+		return err
+	}
+
+	feed := &backtest.WebsocketFeed{URL: *feedURL}
+	if *subscribe != "" {
+		feed.Subscribe = func(symbols []string) []interface{} {
+			list, _ := json.Marshal(symbols)
+			return []interface{}{json.RawMessage(strings.Replace(*subscribe, "{symbols}", string(list), -1))}
+		}
+	}
+	backtest.WithLiveData(feed, t.Data().Stream()...)(t)
+	if *control != "" {
+		kill := backtest.NewKillSwitch(*stale)
+		kill.Token = os.Getenv("BACKTEST_KILL_TOKEN")
+		if kill.Token == "" {
+			fmt.Fprintln(os.Stderr, "backtest: POST /kill is disabled without BACKTEST_KILL_TOKEN")
+		}
+		t.SetKillSwitch(kill)
+		go func() {
+			if err := http.ListenAndServe(*control, kill); err != nil {
+				fmt.Fprintln(os.Stderr, "backtest: control:", err)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	fmt.Println("paper trading, interrupt to stop")
+
+	results, err := t.RunContext(ctx)
+	var partial *backtest.PartialResultError
+	var early *backtest.EarlyStopError
+	switch {
+	case errors.As(err, &partial):
+		fmt.Println("stopped at", partial.Time.Format(time.RFC3339))
+	case errors.As(err, &early):
+		fmt.Println(early)
+	case err != nil:
+		return err
+	}
+	s, ok := results.Statistic.(*backtest.Statistic)
+	if !ok {
+		return errors.New("results have no *Statistic")
+	}
+	if err := s.WriteResult(os.Stdout, backtest.PrintOptions{Precision: 2}); err != nil {
+		return err
+	}
+	return writeOutputs(s, outputs)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// reportCommand lists the stored runs or writes the reports of a stored run
+func reportCommand(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	storeDir := fs.String("store", "", "directory or bucket URL of the result store (required)")
+	id := fs.String("id", "", "ID of the run, the latest run if empty")
+	list := fs.Bool("list", false, "list the stored runs")
+	var outputs outputFlags
+	fs.Var(&outputs, "out", "output file, the format is taken from the extension: .html, .pdf, .json or .csv for the equity (repeatable)")
+	fs.Parse(args)
+
+	if *storeDir == "" {
+		return errors.New("missing -store")
+	}
+	store, err := backtest.NewResultStore(*storeDir)
+	if err != nil {
+		return err
+	}
+	records, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTIME\tSTRATEGY\tRETURN\tSHARPE")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\n", r.ID, r.Time.Format("2006-01-02 15:04"), strategyType(r.Config),
+				formatMetric(r.Metrics.TotalReturn), formatMetric(r.Metrics.SharpeRatio))
+		}
+		return w.Flush()
+	}
+
+	if *id == "" {
+		if len(records) == 0 {
+			return errors.New("no stored runs")
+		}
+		*id = records[len(records)-1].ID
+	}
+	run, err := store.Load(*id)
+	if err != nil {
+		return err
+	}
+	s := backtest.StatisticFromJSON(run.Result)
+	if len(outputs) == 0 {
+		return s.WriteResult(os.Stdout, backtest.PrintOptions{Precision: 2})
+	}
+	return writeOutputs(s, outputs)
+}
+
+// formatMetric formats a metric which may not be calculated
+func formatMetric(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", *v)
+}
+
+// strategyType returns the type of the strategy of a stored configuration, runs stored
+// before the components were identified by their fields have the type only
+func strategyType(config map[string]interface{}) interface{} {
+	if c, ok := config["strategy"].(map[string]interface{}); ok {
+		return c["type"]
+	}
+	return config["strategy"]
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/config"
+)
+
+// runCommand runs the test of a spec and writes its results
+func runCommand(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	specPath := fs.String("spec", "", "path of the JSON spec or YAML/TOML config file (required)")
+	storeDir := fs.String("store", "", "directory or bucket URL of the result store, runs already stored are not run again")
+	var outputs outputFlags
+	fs.Var(&outputs, "out", "output file, the format is taken from the extension: .html, .pdf, .json or .csv for the equity (repeatable)")
+	mailTo := fs.String("mail-to", "", "comma separated recipients of the results, the SMTP server is set by BACKTEST_SMTP_* variables")
+	mailAs := fs.String("mail", "report", "emailed results: report for the HTML report or summary for the metrics with the equity chart")
+	fs.Parse(args)
+
+	spec, reports, err := readSpec(*specPath)
+	if err != nil {
+		return err
+	}
+	outputs = append(outputs, reports...)
+	if *mailAs != "report" && *mailAs != "summary" {
+		return fmt.Errorf("unknown -mail %q", *mailAs)
+	}
+	t, err := backtest.DefaultRegistry.Build(spec)
+	if err != nil {
+		return err
+	}
+
+	var s *backtest.Statistic
+	if *storeDir != "" {
+		store, err := backtest.NewResultStore(*storeDir)
+		if err != nil {
+			return err
+		}
+		run, cached, err := t.RunCached(store)
+		if err != nil {
+			return err
+		}
+		if cached {
+			fmt.Printf("run %s taken from the store\n", run.ID)
+		} else {
+			fmt.Printf("run %s saved to the store\n", run.ID)
+		}
+		s = backtest.StatisticFromJSON(run.Result)
+	} else {
+		results, err := t.Run()
+		var stop *backtest.EarlyStopError
+		if err != nil && !errors.As(err, &stop) {
+			return err
+		}
+		if stop != nil {
+			fmt.Println(stop)
+		}
+		var ok bool
+		if s, ok = results.Statistic.(*backtest.Statistic); !ok {
+			return errors.New("results have no *Statistic")
+		}
+	}
+
+	if err := s.WriteResult(os.Stdout, backtest.PrintOptions{Precision: 2}); err != nil {
+		return err
+	}
+	if err := writeOutputs(s, outputs); err != nil {
+		return err
+	}
+	if *mailTo == "" {
+		return nil
+	}
+	return mailResults(*mailTo, *mailAs, "Backtest "+filepath.Base(*specPath), s)
+}
+
+// mailResults emails the results of a run as report or summary
+func mailResults(to, as, subject string, s *backtest.Statistic) error {
+	mail, err := mailer(to)
+	if err != nil {
+		return err
+	}
+	if as == "summary" {
+		err = mail.SendSummary(subject, s)
+	} else {
+		err = mail.SendReport(subject, s)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("mailed", as, "to", to)
+	return nil
+}
+
+// readSpec reads a JSON spec or a YAML or TOML config file with its reports
+func readSpec(path string) (backtest.Spec, []string, error) {
+	var spec backtest.Spec
+	if path == "" {
+		return spec, nil, errors.New("missing -spec")
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".toml":
+		c, err := config.Load(path)
+		if err != nil {
+			return spec, nil, err
+		}
+		if err := c.Validate(nil); err != nil {
+			return spec, nil, err
+		}
+		return c.Spec(), c.Reports, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return spec, nil, err
+	}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return spec, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return spec, nil, nil
+}
+
+// outputFlags collects the repeated -out flags
+type outputFlags []string
+
+// String implements the flag.Value interface
+func (o *outputFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+// Set implements the flag.Value interface
+func (o *outputFlags) Set(v string) error {
+	*o = append(*o, v)
+	return nil
+}
+
+// writeOutputs writes the results to the output files in the format of their extension
+func writeOutputs(s *backtest.Statistic, outputs []string) error {
+	for _, path := range outputs {
+		if err := config.WriteReport(s, path); err != nil {
+			return err
+		}
+		fmt.Println("wrote", path)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/rpc"
+	"github.com/ivtpz/backtest-go/server"
+	"google.golang.org/grpc"
+)
+
+// serveCommand runs the backtest service with the REST API, and the gRPC service if an
+// address is given
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	grpcAddr := fs.String("grpc", "", "address to serve the gRPC service on, e.g. :9090")
+	storeDir := fs.String("store", "", "directory or bucket URL of the result store, runs already stored are not run again")
+	workers := fs.Int("workers", 1, "number of tests running at once")
+	dataDir := fs.String("data", "", "directory or bucket URL the data paths of the specs are relative to, specs with data paths are rejected if empty")
+	retention := fs.Duration("retention", 24*time.Hour, "time finished runs are listed, 0 to keep them")
+	maxRuns := fs.Int("max-runs", 1000, "number of finished runs listed, 0 for no limit")
+	fs.Parse(args)
+
+	var store *backtest.ResultStore
+	if *storeDir != "" {
+		var err error
+		if store, err = backtest.NewResultStore(*storeDir); err != nil {
+			return err
+		}
+	}
+	m := server.NewManager(nil, store, *workers)
+	m.DataDir, m.Retention, m.MaxFinished = *dataDir, *retention, *maxRuns
+
+	errs := make(chan error, 2)
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		s := grpc.NewServer()
+		rpc.RegisterBacktestServer(s, rpc.NewServer(m))
+		fmt.Println("gRPC listening on", *grpcAddr)
+		go func() { errs <- s.Serve(lis) }()
+	}
+	fmt.Println("listening on", *addr)
+	go func() { errs <- http.ListenAndServe(*addr, server.NewHandler(m)) }()
+	return <-errs
+}
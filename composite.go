@@ -0,0 +1,266 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// AggregationPolicy declares how the signals of multiple strategies are combined
+type AggregationPolicy int
+
+const (
+	// AggregateFirst uses the first signal with a direction
+	AggregateFirst AggregationPolicy = iota
+	// AggregateMajority uses the direction most strategies agree on
+	AggregateMajority
+	// AggregateWeighted sums the weighted signal strengths of the signals with a direction,
+	// the size, entry and bracket are those of the first signal agreeing with the sum
+	AggregateWeighted
+)
+
+// CompositeStrategy combines multiple strategies into a single strategy handler
+type CompositeStrategy struct {
+	Policy     AggregationPolicy
+	strategies []StrategyHandler
+	weights    []float64
+}
+
+// NewCompositeStrategy creates a composite strategy with the given aggregation policy
+func NewCompositeStrategy(policy AggregationPolicy, strategies ...StrategyHandler) *CompositeStrategy {
+	c := &CompositeStrategy{Policy: policy}
+	for _, s := range strategies {
+		c.Add(s, 1)
+	}
+	return c
+}
+
+// Add attaches a strategy with the given weight to the composite
+func (c *CompositeStrategy) Add(strategy StrategyHandler, weight float64) {
+	c.strategies = append(c.strategies, strategy)
+	c.weights = append(c.weights, weight)
+}
+
+// Strategies returns the attached strategies
+func (c *CompositeStrategy) Strategies() []StrategyHandler {
+	return c.strategies
+}
+
+// CalculateSignal collects the signals of all attached strategies and aggregates them.
+// Basket, cancel and modify signals are instructions rather than votes and can't be
+// aggregated, they are returned as error.
+func (c *CompositeStrategy) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	if len(c.strategies) == 0 {
+		return &Signal{}, errors.New("no strategies attached")
+	}
+
+	var signals []SignalEvent
+	var weights []float64
+	for i, s := range c.strategies {
+		signal, err := s.CalculateSignal(de, d, p)
+		if err != nil || signal == nil {
+			continue
+		}
+		switch signal.(type) {
+		case BasketSignalEvent, CancelOrderEvent, ModifyOrderEvent:
+			return &Signal{}, fmt.Errorf("cannot aggregate the %T of strategy %d", signal, i)
+		}
+		signals = append(signals, signal)
+		weights = append(weights, c.weights[i])
+	}
+
+	event := Event{Time: de.GetTime(), Symbol: de.GetSymbol()}
+	signal := Signal{Event: event}
+
+	switch c.Policy {
+	case AggregateFirst:
+		signal.SetDirection(firstDirection(signals))
+		copySignal(&signal, signals)
+	case AggregateMajority:
+		signal.SetDirection(majorityDirection(signals))
+		copySignal(&signal, signals)
+	case AggregateWeighted:
+		direction, strength := weightedDirection(signals, weights)
+		signal.SetDirection(direction)
+		copySignal(&signal, signals)
+		signal.SetStrength(strength)
+	default:
+		return &signal, errors.New("unknown aggregation policy")
+	}
+
+	// keep the tags of all signals agreeing with the aggregated direction
+	for _, s := range signals {
+		if s.GetDirection() == signal.GetDirection() && signal.GetDirection() != "" {
+			if t, ok := s.(Tagger); ok {
+				signal.Tags = appendTags(signal.Tags, t.GetTags()...)
+			}
+		}
+	}
+
+	return &signal, nil
+}
+
+// OnFill notifies all attached strategies listening for fills
+func (c *CompositeStrategy) OnFill(fill FillEvent) {
+	for _, s := range c.strategies {
+		if l, ok := s.(FillListener); ok {
+			l.OnFill(fill)
+		}
+	}
+}
+
+// SetSeed implements the Seeder interface and seeds the attached strategies, each with
+// its own seed derived from the seed
+func (c *CompositeStrategy) SetSeed(seed int64) {
+	for i, s := range c.strategies {
+		if sd, ok := s.(Seeder); ok {
+			sd.SetSeed(seed + int64(i))
+		}
+	}
+}
+
+// appendTags appends tags not yet contained
+func appendTags(tags []string, add ...string) []string {
+	for _, a := range add {
+		found := false
+		for _, t := range tags {
+			if t == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tags = append(tags, a)
+		}
+	}
+	return tags
+}
+
+// firstDirection returns the first direction found in the signals
+func firstDirection(signals []SignalEvent) string {
+	for _, s := range signals {
+		if s.GetDirection() != "" {
+			return s.GetDirection()
+		}
+	}
+	return ""
+}
+
+// copySignal copies the strength, size, entry and bracket of the first signal agreeing
+// with the direction of the aggregated signal, the tags are merged separately. The
+// weighted policy replaces the strength with the weighted one.
+func copySignal(signal *Signal, signals []SignalEvent) {
+	if signal.GetDirection() == "" {
+		return
+	}
+	for _, s := range signals {
+		if s.GetDirection() != signal.GetDirection() {
+			continue
+		}
+		signal.Strength = s.GetStrength()
+		if n, ok := s.(Notionaler); ok {
+			signal.Notional = n.GetNotional()
+		}
+		if q, ok := s.(Quantifier); ok {
+			signal.Qty = q.GetQty()
+		}
+		if f, ok := s.(Fractioner); ok {
+			signal.Fraction = f.GetFraction()
+		}
+		if l, ok := s.(Limiter); ok {
+			signal.Limit = l.GetLimit()
+		}
+		if e, ok := s.(Expirer); ok {
+			signal.TTL = e.GetTTL()
+		}
+		if b, ok := s.(Bracketer); ok {
+			signal.StopLoss, signal.TakeProfit = b.GetStopLoss(), b.GetTakeProfit()
+		}
+		return
+	}
+}
+
+// majorityDirection returns the direction with the most votes, a tie returns no direction
+func majorityDirection(signals []SignalEvent) string {
+	var buy, sell int
+	for _, s := range signals {
+		switch s.GetDirection() {
+		case "buy":
+			buy++
+		case "sell":
+			sell++
+		}
+	}
+
+	switch {
+	case buy > sell:
+		return "buy"
+	case sell > buy:
+		return "sell"
+	}
+	return ""
+}
+
+// weightedDirection sums the signals as +weight*strength for buy and -weight*strength for sell,
+// the returned strength is the absolute sum relative to the total weight of the signals with
+// a direction, so holding strategies abstain
+func weightedDirection(signals []SignalEvent, weights []float64) (string, float64) {
+	var sum, total float64
+	for i, s := range signals {
+		switch s.GetDirection() {
+		case "buy":
+			sum += weights[i] * s.GetStrength()
+			total += weights[i]
+		case "sell":
+			sum -= weights[i] * s.GetStrength()
+			total += weights[i]
+		}
+	}
+
+	if total == 0 {
+		return "", 0
+	}
+
+	switch {
+	case sum > 0:
+		return "buy", sum / total
+	case sum < 0:
+		return "sell", -sum / total
+	}
+	return "", 0
+}
+
+// SymbolStrategies dispatches the data events of each symbol to its own strategy,
+// data events of symbols without a strategy produce no signal
+type SymbolStrategies map[string]StrategyHandler
+
+// CalculateSignal calls the strategy of the data event symbol
+func (m SymbolStrategies) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	s, ok := m[de.GetSymbol()]
+	if !ok {
+		return &Signal{Event: Event{Time: de.GetTime(), Symbol: de.GetSymbol()}}, nil
+	}
+	return s.CalculateSignal(de, d, p)
+}
+
+// OnFill notifies the strategy of the fill symbol if it listens for fills
+func (m SymbolStrategies) OnFill(fill FillEvent) {
+	if l, ok := m[fill.GetSymbol()].(FillListener); ok {
+		l.OnFill(fill)
+	}
+}
+
+// SetSeed implements the Seeder interface and seeds the strategies in the order of their
+// symbols, each with its own seed derived from the seed
+func (m SymbolStrategies) SetSeed(seed int64) {
+	symbols := make([]string, 0, len(m))
+	for symbol := range m {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for i, symbol := range symbols {
+		if sd, ok := m[symbol].(Seeder); ok {
+			sd.SetSeed(seed + int64(i))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package backtest
+
+import "testing"
+
+// fixedStrategy returns its signal for every data event
+type fixedStrategy struct {
+	signal SignalEvent
+}
+
+// CalculateSignal returns the signal of the strategy
+func (s fixedStrategy) CalculateSignal(DataEventHandler, DataHandler, PortfolioHandler) (SignalEvent, error) {
+	return s.signal, nil
+}
+
+// aggregate returns the signal of a composite of the signals with the policy
+func aggregate(policy AggregationPolicy, signals ...SignalEvent) (*Signal, error) {
+	c := NewCompositeStrategy(policy)
+	for _, s := range signals {
+		c.Add(fixedStrategy{s}, 1)
+	}
+	bar := closeBars("A", 10)[0]
+	signal, err := c.CalculateSignal(bar, &Data{}, &Portfolio{})
+	if err != nil {
+		return nil, err
+	}
+	return signal.(*Signal), nil
+}
+
+func TestCompositeWeightedIgnoresHolds(t *testing.T) {
+	signal, err := aggregate(AggregateWeighted,
+		&Signal{Direction: "buy", Strength: 0.8},
+		&Signal{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signal.GetDirection() != "buy" || signal.GetStrength() != 0.8 {
+		t.Errorf("got %s at strength %v, want buy at 0.8 with the hold abstaining", signal.GetDirection(), signal.GetStrength())
+	}
+}
+
+func TestCompositeWeightedKeepsEntry(t *testing.T) {
+	signal, err := aggregate(AggregateWeighted,
+		&Signal{Direction: "buy", Strength: 1, Notional: 500, Limit: 9.5, TTL: 3, StopLoss: 9, TakeProfit: 12},
+		&Signal{Direction: "buy", Strength: 0.5, Limit: 9.8},
+		&Signal{Direction: "sell", Strength: 0.5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signal.GetDirection() != "buy" || signal.GetStrength() != 1.0/3 {
+		t.Errorf("got %s at strength %v, want buy at 1/3", signal.GetDirection(), signal.GetStrength())
+	}
+	if signal.Notional != 500 || signal.Limit != 9.5 || signal.TTL != 3 || signal.StopLoss != 9 || signal.TakeProfit != 12 {
+		t.Errorf("got %+v, want the size, entry and bracket of the first buy", *signal)
+	}
+}
+
+func TestCompositeRejectsInstructions(t *testing.T) {
+	basket := &BasketSignal{Legs: []Leg{{Symbol: "A", Direction: "buy", Ratio: 1}}}
+	for _, policy := range []AggregationPolicy{AggregateFirst, AggregateMajority, AggregateWeighted} {
+		if _, err := aggregate(policy, &Signal{Direction: "buy"}, basket); err == nil {
+			t.Errorf("policy %d aggregated a basket signal", policy)
+		}
+	}
+}
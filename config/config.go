@@ -0,0 +1,374 @@
+// Package config builds complete tests from YAML or TOML files, so experiments can be
+// set up without writing Go:
+//
+//	symbols: [BTC]
+//	start: 2021-01-01
+//	end: 2021-12-31
+//	data:
+//	  - name: csv
+//	    path: data/BTC.csv
+//	    symbol: BTC
+//	strategy:
+//	  name: ma_cross
+//	  fast: 10
+//	  slow: 50
+//	fees:
+//	  commission: 0.001
+//	initial_cash: 10000
+//	reports: [report.html, results.json]
+//
+// The components are taken from a backtest.Registry by name, their params can be given
+// inline or below a params key. The file is checked against the schema and the registry
+// before the test is built, and all problems found are reported together.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Config is the configuration of a test
+type Config struct {
+	Symbols      []string                 `json:"symbols"` // symbols of the data if empty
+	Start        Date                     `json:"start"`   // first date of the data, all data if empty
+	End          Date                     `json:"end"`     // last date of the data, inclusive, all data if empty
+	Data         []backtest.ComponentSpec `json:"data"`
+	Strategy     backtest.ComponentSpec   `json:"strategy"`
+	Sizer        *backtest.ComponentSpec  `json:"sizer"`
+	Filters      []backtest.ComponentSpec `json:"filters"`
+	InitialCash  float64                  `json:"initial_cash"`
+	Fees         Fees                     `json:"fees"`
+	ShortSelling bool                     `json:"short_selling"`
+	Warmup       int                      `json:"warmup"`
+	Seed         *int64                   `json:"seed"`    // runs the test deterministic if set
+	Reports      []string                 `json:"reports"` // report files, the format is taken from the extension
+
+	file string // path of the loaded file
+}
+
+// Fees are the trading costs of the exchange
+type Fees struct {
+	Commission float64 `json:"commission"` // commission rate of the traded value, e.g. 0.001
+	Exchange   float64 `json:"exchange"`   // fixed fee per fill
+}
+
+// Date is a date or time of the config, given as 2006-01-02 or RFC3339
+type Date struct {
+	time.Time
+	DateOnly bool // true if given without time of day
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("date must be 2006-01-02 or RFC3339, got %s", b)
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		*d = Date{Time: t, DateOnly: true}
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("date must be 2006-01-02 or RFC3339, got %q", s)
+	}
+	*d = Date{Time: t}
+	return nil
+}
+
+// Error lists the problems of a config file
+type Error struct {
+	File     string
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("%s: %s", e.File, e.Problems[0])
+	}
+	return fmt.Sprintf("%s: %d problems:\n  - %s", e.File, len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Load reads a config file, the format is taken from the extension: .yaml, .yml, .toml
+// or .json
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(b, strings.TrimPrefix(filepath.Ext(path), "."))
+	if e, ok := err.(*Error); ok {
+		e.File = path
+	}
+	if c != nil {
+		c.file = path
+	}
+	return c, err
+}
+
+// Parse parses a config in the format yaml, yml, toml or json
+func Parse(b []byte, format string) (*Config, error) {
+	raw := make(map[string]interface{})
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		err = yaml.Unmarshal(b, &raw)
+	case "toml":
+		_, err = toml.Decode(string(b), &raw)
+	case "json":
+		err = json.Unmarshal(b, &raw)
+	default:
+		return nil, fmt.Errorf("unknown config format %q, use yaml, toml or json", format)
+	}
+	if err != nil {
+		return nil, &Error{File: "config", Problems: []string{err.Error()}}
+	}
+
+	// the raw values are decoded with the json tags, so all formats share the schema
+	j, err := json.Marshal(normalize(raw))
+	if err != nil {
+		return nil, &Error{File: "config", Problems: []string{err.Error()}}
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, &Error{File: "config", Problems: []string{schemaProblem(err)}}
+	}
+	return &c, nil
+}
+
+// normalize replaces the dates of YAML and TOML by strings in the formats of Date
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	case []map[string]interface{}:
+		for _, e := range v {
+			normalize(e)
+		}
+	case time.Time:
+		// TOML decodes local dates and times at the offset of the host, in a zone named
+		// after their kind, they are rebuilt from their fields in UTC
+		switch v.Location().String() {
+		case "date-local", "datetime-local", "time-local":
+			v = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+		}
+		if h, m, s := v.Clock(); h == 0 && m == 0 && s == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return v
+}
+
+// schemaProblem turns a decoding error into a message naming the field
+func schemaProblem(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("%s: expected %s, got %s", typeErr.Field, kindName(typeErr.Type), typeErr.Value)
+	}
+	msg := strings.TrimPrefix(err.Error(), "json: ")
+	if strings.HasPrefix(msg, "unknown field") {
+		return msg + ", see the Config type for the known fields"
+	}
+	return msg
+}
+
+// kindName names a type of the schema in config terms
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Ptr:
+		return kindName(t.Elem())
+	}
+	return "a table"
+}
+
+// reportFormats are the known extensions of report files
+var reportFormats = map[string]bool{".html": true, ".pdf": true, ".json": true, ".csv": true}
+
+// Validate checks the config and the components against the registry, the default
+// registry if nil, and returns an *Error with all problems found
+func (c *Config) Validate(r *backtest.Registry) error {
+	if r == nil {
+		r = backtest.DefaultRegistry
+	}
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	component := func(kind, field string, spec backtest.ComponentSpec) {
+		if spec.Name == "" {
+			problem("%s: missing name", field)
+			return
+		}
+		names := r.Names(kind)
+		for _, name := range names {
+			if name == spec.Name {
+				return
+			}
+		}
+		problem("%s: unknown %s %q, known are %s", field, kind, spec.Name, strings.Join(names, ", "))
+	}
+
+	if len(c.Data) == 0 {
+		problem("data: at least one data source is required")
+	}
+	for i, d := range c.Data {
+		component("data", fmt.Sprintf("data[%d]", i), d)
+	}
+	component("strategy", "strategy", c.Strategy)
+	if c.Sizer != nil {
+		component("sizer", "sizer", *c.Sizer)
+	}
+	for i, f := range c.Filters {
+		component("filter", fmt.Sprintf("filters[%d]", i), f)
+	}
+
+	if !c.Start.IsZero() && !c.End.IsZero() && c.End.Before(c.Start.Time) {
+		problem("end: %s is before start %s", c.End.Format("2006-01-02"), c.Start.Format("2006-01-02"))
+	}
+	if c.InitialCash <= 0 {
+		problem("initial_cash: must be positive, got %v", c.InitialCash)
+	}
+	if c.Fees.Commission < 0 || c.Fees.Commission >= 1 {
+		problem("fees.commission: must be a rate in [0, 1), got %v", c.Fees.Commission)
+	}
+	if c.Fees.Exchange < 0 {
+		problem("fees.exchange: must not be negative, got %v", c.Fees.Exchange)
+	}
+	if c.Warmup < 0 {
+		problem("warmup: must not be negative, got %d", c.Warmup)
+	}
+	for i, path := range c.Reports {
+		if !reportFormats[strings.ToLower(filepath.Ext(path))] {
+			problem("reports[%d]: unknown format of %q, use .html, .pdf, .json or .csv", i, path)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Error{File: c.name(), Problems: problems}
+	}
+	return nil
+}
+
+// name returns the path of the loaded file, or config for parsed configs
+func (c *Config) name() string {
+	if c.file == "" {
+		return "config"
+	}
+	return c.file
+}
+
+// Spec returns the spec of the config for backtest.Registry.Build
+func (c *Config) Spec() backtest.Spec {
+	end := c.End.Time
+	if c.End.DateOnly {
+		// the end date is inclusive
+		end = end.AddDate(0, 0, 1)
+	}
+	return backtest.Spec{
+		Symbols:      c.Symbols,
+		Start:        c.Start.Time,
+		End:          end,
+		Data:         c.Data,
+		Strategy:     c.Strategy,
+		Sizer:        c.Sizer,
+		Filters:      c.Filters,
+		InitialCash:  c.InitialCash,
+		Commission:   c.Fees.Commission,
+		ExchangeFee:  c.Fees.Exchange,
+		ShortSelling: c.ShortSelling,
+		Warmup:       c.Warmup,
+		Seed:         c.Seed,
+	}
+}
+
+// Build validates the config and builds its test with the components of the registry,
+// the default registry if nil
+func (c *Config) Build(r *backtest.Registry) (*backtest.Test, error) {
+	if r == nil {
+		r = backtest.DefaultRegistry
+	}
+	if err := c.Validate(r); err != nil {
+		return nil, err
+	}
+	t, err := r.Build(c.Spec())
+	if err != nil {
+		return nil, &Error{File: c.name(), Problems: []string{err.Error()}}
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// WriteReports writes the results to the report files of the config
+func (c *Config) WriteReports(s *backtest.Statistic) error {
+	for _, path := range c.Reports {
+		if err := WriteReport(s, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteReport writes the results to a file in the format of its extension: .html and
+// .pdf reports, .json results or .csv equity
+func WriteReport(s *backtest.Statistic, path string) error {
+	var write func(*os.File) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html":
+		write = func(f *os.File) error { return s.WriteReport(f) }
+	case ".pdf":
+		write = func(f *os.File) error { return s.WritePDFReport(f) }
+	case ".json":
+		write = func(f *os.File) error {
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", " ")
+			return enc.Encode(s.JSON())
+		}
+	case ".csv":
+		write = func(f *os.File) error { return s.WriteEquityCSV(f) }
+	default:
+		return fmt.Errorf("unknown report format of %s", path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,48 @@
+package backtest
+
+// CostSummary holds the traded value and trading costs of a backtest
+type CostSummary struct {
+	TradedValue float64 // value of all fills without cost
+	Turnover    float64 // traded value relative to the average equity
+	Commission  float64
+	ExchangeFee float64
+	Cost        float64 // total cost incl. commission and fees
+	Slippage    float64 // fill prices against the arrival prices, contained in the fill prices rather than Cost, see TCA
+	NetProfit   float64 // profit of the equity after cost
+	GrossProfit float64 // profit of the equity before cost
+	CostShare   float64 // cost relative to the gross profit, 0 without gross profit
+}
+
+// Costs sums up the traded value and trading costs of all transactions
+func (s Statistic) Costs() CostSummary {
+	var c CostSummary
+	for _, f := range s.transactionHistory {
+		c.TradedValue += f.Value()
+		c.Commission += f.GetCommission()
+		c.ExchangeFee += f.GetExchangeFee()
+		c.Cost += f.GetCost()
+	}
+	for _, r := range s.TCA() {
+		c.Slippage += r.Slippage
+	}
+
+	if len(s.equity) == 0 {
+		return c
+	}
+
+	var sum float64
+	for _, e := range s.equity {
+		sum += e.equity
+	}
+	if average := sum / float64(len(s.equity)); average != 0 {
+		c.Turnover = c.TradedValue / average
+	}
+
+	c.NetProfit = s.equity[len(s.equity)-1].equity - s.equity[0].equity
+	c.GrossProfit = c.NetProfit + c.Cost
+	if c.GrossProfit > 0 {
+		c.CostShare = c.Cost / c.GrossProfit
+	}
+
+	return c
+}
@@ -0,0 +1,26 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostsSlippage(t *testing.T) {
+	s := statisticOf(0.01)
+	s.transactionHistory = []FillEvent{
+		// bought 10 at 101 with the market at 100
+		&Fill{Direction: "BOT", Qty: 10, Price: 101, Arrival: 100, OrderType: "MKT", Commission: 1, Cost: 1},
+		// sold 5 at 99 with the market at 100
+		&Fill{Direction: "SLD", Qty: 5, Price: 99, Arrival: 100, OrderType: "MKT", Commission: 1, Cost: 1},
+		// sold 4 at 103 with the market at 102, a limit price improvement
+		&Fill{Direction: "SLD", Qty: 4, Price: 103, Arrival: 102, OrderType: "LMT"},
+	}
+
+	costs := s.Costs()
+	if want := 10.0 + 5 - 4; math.Abs(costs.Slippage-want) > 1e-9 {
+		t.Errorf("slippage is %v, want %v", costs.Slippage, want)
+	}
+	if costs.Cost != 2 {
+		t.Errorf("cost is %v, want the fees of 2 without slippage", costs.Cost)
+	}
+}
@@ -0,0 +1,155 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteEquityCSV writes the equity series as CSV with a header row
+func (s *Statistic) WriteEquityCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "equity", "return", "drawdown", "buy_and_hold", "benchmark", "exposure"}); err != nil {
+		return err
+	}
+
+	for _, e := range s.equity {
+		benchmark := ""
+		if e.benchmarkValue != 0 {
+			benchmark = formatCSVFloat(e.benchmarkValue)
+		}
+		record := []string{
+			e.timestamp.Format(time.RFC3339),
+			formatCSVFloat(e.equity),
+			formatCSVFloat(e.equityReturn),
+			formatCSVFloat(e.drawdown),
+			formatCSVFloat(e.buyAndHoldValue),
+			benchmark,
+			formatCSVFloat(e.exposure),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTransactionsCSV writes the list of fills as CSV with a header row,
+// multiple tags of a fill are separated by semicolons
+func (s *Statistic) WriteTransactionsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"time", "symbol", "direction", "qty", "price", "commission", "exchange_fee", "cost", "value", "net_value", "tags"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, f := range s.transactionHistory {
+		record := []string{
+			f.GetTime().Format(time.RFC3339),
+			f.GetSymbol(),
+			f.GetDirection(),
+			formatCSVFloat(f.GetQty()),
+			formatCSVFloat(f.GetPrice()),
+			formatCSVFloat(f.GetCommission()),
+			formatCSVFloat(f.GetExchangeFee()),
+			formatCSVFloat(f.GetCost()),
+			formatCSVFloat(f.Value()),
+			formatCSVFloat(f.NetValue()),
+			strings.Join(f.GetTags(), ";"),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatCSVFloat formats a float with the minimal number of digits
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ReadBarsCSV reads OHLCV bars of a symbol from CSV with a header row. The columns are
+// found by name: time (or date, timestamp), open, high, low, close and volume, only time
+// and close are required. Times are parsed as RFC3339, dates as 2006-01-02 or unix seconds.
+func ReadBarsCSV(r io.Reader, symbol string) ([]DataEventHandler, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "date" || name == "timestamp" {
+			name = "time"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["time"]; !ok {
+		return nil, errors.New("csv has no time column")
+	}
+	if _, ok := columns["close"]; !ok {
+		return nil, errors.New("csv has no close column")
+	}
+
+	var bars []DataEventHandler
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return bars, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseCSVTime(record[columns["time"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		values := make(map[string]float64)
+		for _, name := range []string{"open", "high", "low", "close", "volume"} {
+			i, ok := columns[name]
+			if !ok || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q", line, name, record[i])
+			}
+			values[name] = v
+		}
+		// bars without open, high or low are taken as flat at the close
+		last := values["close"]
+		for _, name := range []string{"open", "high", "low"} {
+			if _, ok := values[name]; !ok {
+				values[name] = last
+			}
+		}
+		bars = append(bars, Bar{
+			Event:   Event{Time: t, Symbol: symbol},
+			BarData: BarData{Time: int(t.Unix()), Open: values["open"], High: values["high"], Low: values["low"], Close: last, Volume: values["volume"]},
+		})
+	}
+}
+
+// parseCSVTime parses a time as RFC3339, date or unix seconds
+func parseCSVTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
@@ -0,0 +1,62 @@
+package backtest
+
+import "time"
+
+// Daily returns a copy of the statistic with the equity series rolled up to the last
+// equity point of each calendar day, with returns and drawdowns recalculated between
+// the days. The statistic itself keeps the fine-grained series, so the metrics of
+// intraday tests can be reported on daily returns, e.g. s.Daily().SharpRatio(0).
+// The events and transactions are shared with the statistic.
+func (s Statistic) Daily() *Statistic {
+	daily := s
+	daily.equity = nil
+	daily.high = equityPoint{}
+	daily.low = equityPoint{}
+	daily.periodsPerYear = 0
+
+	// index of the last equity point of each day
+	var last []int
+	for i, e := range s.equity {
+		if len(last) > 0 && sameDay(s.equity[last[len(last)-1]].timestamp, e.timestamp) {
+			last[len(last)-1] = i
+			continue
+		}
+		last = append(last, i)
+	}
+
+	for _, i := range last {
+		e := s.equity[i]
+		e.equityReturn, e.drawdown = 0, 0
+		if len(daily.equity) > 0 {
+			e = daily.calcEquityReturn(e)
+			e = daily.calcDrawdown(e)
+		}
+		if e.equity >= daily.high.equity {
+			daily.high = e
+		}
+		if e.equity <= daily.low.equity {
+			daily.low = e
+		}
+		daily.equity = append(daily.equity, e)
+	}
+
+	// keep the attributed profit or loss of the same points
+	if s.attribution != nil {
+		daily.attribution = make(map[string]*attributionBook, len(s.attribution))
+		for tag, book := range s.attribution {
+			pnl := make([]float64, 0, len(last))
+			for _, i := range last {
+				pnl = append(pnl, book.pnl[i])
+			}
+			daily.attribution[tag] = &attributionBook{cash: book.cash, qty: book.qty, pnl: pnl}
+		}
+	}
+
+	return &daily
+}
+
+// sameDay reports whether both times are on the same calendar day of the first time's location
+func sameDay(a, b time.Time) bool {
+	b = b.In(a.Location())
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
@@ -0,0 +1,292 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardTemplate is the embedded page of the live dashboard
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+{{.Script}}
+<style>
+body { font-family: sans-serif; margin: 0; color: #333; }
+#status { padding: 8px 16px; background: #f4f4f4; }
+#equity { width: 100%; height: 45vh; }
+#drawdown { width: 100%; height: 25vh; }
+#trades { padding: 0 16px; height: 20vh; overflow-y: auto; font-size: 12px; }
+#controls { padding: 8px 16px; }
+#speed { width: 5em; }
+</style>
+</head>
+<body>
+<div id="status">connecting</div>
+{{if .Replay}}<div id="controls">
+<button onclick="control('pause')">Pause</button>
+<button onclick="control('resume')">Resume</button>
+<button onclick="control('step')">Step</button>
+<input id="speed" type="number" min="0" step="0.5" value="{{.Speed}}"> bars/s
+<button onclick="control('speed', document.getElementById('speed').value)">Set speed</button>
+</div>{{end}}
+<div id="equity"></div>
+<div id="drawdown"></div>
+<div id="trades"></div>
+<script>
+function control(action, value) {
+	var body = new URLSearchParams({action: action});
+	if (value !== undefined) {
+		body.set("value", value);
+	}
+	fetch("{{.Replay}}", {method: "POST", body: body});
+}
+var equity = [], drawdown = [], buys = [], sells = [];
+var equityChart = echarts.init(document.getElementById("equity"));
+var drawdownChart = echarts.init(document.getElementById("drawdown"));
+function render() {
+	equityChart.setOption({
+		tooltip: {trigger: "axis"},
+		legend: {top: 10},
+		xAxis: {type: "time"},
+		yAxis: {type: "value", scale: true},
+		series: [
+			{name: "Equity", type: "line", showSymbol: false, data: equity},
+			{name: "Buys", type: "scatter", symbol: "triangle", itemStyle: {color: "#2ca02c"}, data: buys},
+			{name: "Sells", type: "scatter", symbol: "triangle", symbolRotate: 180, itemStyle: {color: "#d62728"}, data: sells}
+		]
+	});
+	drawdownChart.setOption({
+		tooltip: {trigger: "axis"},
+		xAxis: {type: "time"},
+		yAxis: {type: "value", name: "Drawdown %"},
+		series: [{name: "Drawdown", type: "line", showSymbol: false, areaStyle: {color: "#d62728", opacity: 0.25}, lineStyle: {color: "#d62728"}, data: drawdown}]
+	});
+}
+var pending = false;
+function schedule() {
+	if (!pending) {
+		pending = true;
+		setTimeout(function() { pending = false; render(); }, 250);
+	}
+}
+var source = new EventSource("{{.Events}}");
+source.onopen = function() { document.getElementById("status").textContent = "running"; };
+source.onerror = function() { document.getElementById("status").textContent = "disconnected"; };
+source.addEventListener("equity", function(e) {
+	var p = JSON.parse(e.data);
+	var t = Date.parse(p.time);
+	equity.push([t, p.equity]);
+	drawdown.push([t, p.drawdown * 100]);
+	document.getElementById("status").textContent = "running, " + p.time + " " + p.symbol + " " + p.price.toFixed(2) + ", equity " + p.equity.toFixed(2) + ", drawdown " + (p.drawdown * 100).toFixed(2) + "%";
+	schedule();
+});
+source.addEventListener("fill", function(e) {
+	var f = JSON.parse(e.data);
+	var point = [Date.parse(f.time), equity.length ? equity[equity.length - 1][1] : 0];
+	(f.direction === "SLD" ? sells : buys).push(point);
+	var line = document.createElement("div");
+	line.textContent = f.time + " " + f.direction + " " + f.symbol + " " + f.qty + " @ " + f.price;
+	document.getElementById("trades").prepend(line);
+	schedule();
+});
+source.addEventListener("done", function() {
+	document.getElementById("status").textContent += ", finished";
+	source.close();
+});
+</script>
+</body>
+</html>
+`))
+
+// Dashboard streams the equity, drawdown and fills of a running test as server-sent
+// events to an embedded web page. Set it on the test and serve it with net/http,
+// the page is served on the root path and the events on the events path below it.
+// The page loads ECharts from its CDN, the browser needs network access.
+type Dashboard struct {
+	Title   string
+	History int // number of messages replayed to new clients, 0 for all
+
+	mu      sync.Mutex
+	clients map[chan []byte]bool
+	history [][]byte
+	high    float64
+	done    bool
+	replay  *Replay
+}
+
+// NewDashboard creates a live dashboard
+func NewDashboard(title string) *Dashboard {
+	return &Dashboard{Title: title, clients: make(map[chan []byte]bool)}
+}
+
+// SetReplay shows the controls of a replay on the dashboard page
+func (d *Dashboard) SetReplay(r *Replay) {
+	d.replay = r
+}
+
+// ServeHTTP serves the dashboard page, the event stream at the events path and the
+// replay controls at the replay path
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/events") {
+		d.serveEvents(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/replay") && d.replay != nil {
+		d.replay.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	events := r.URL.Path
+	if !strings.HasSuffix(events, "/") {
+		events += "/"
+	}
+	page := map[string]interface{}{
+		"Title":  d.Title,
+		"Script": echartsScript,
+		"Events": events + "events",
+	}
+	if d.replay != nil {
+		page["Replay"] = events + "replay"
+		page["Speed"] = d.replay.Speed()
+	}
+	dashboardTemplate.Execute(w, page)
+}
+
+// serveEvents streams the messages to a client until it disconnects or the test is done
+func (d *Dashboard) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// subscribe and replay the history under the lock, so no message is lost in between
+	ch := make(chan []byte, 256)
+	d.mu.Lock()
+	if d.clients == nil {
+		d.clients = make(map[chan []byte]bool)
+	}
+	for _, msg := range d.history {
+		w.Write(msg)
+	}
+	done := d.done
+	if !done {
+		d.clients[ch] = true
+	}
+	d.mu.Unlock()
+	flusher.Flush()
+	if done {
+		return
+	}
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.clients, ch)
+		d.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			w.Write(msg)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Update publishes the equity and drawdown of the portfolio at a data event
+func (d *Dashboard) Update(de DataEventHandler, p PortfolioHandler) {
+	equity := p.Value()
+
+	d.mu.Lock()
+	if equity > d.high {
+		d.high = equity
+	}
+	var drawdown float64
+	if d.high > 0 {
+		drawdown = (equity - d.high) / d.high
+	}
+	d.mu.Unlock()
+
+	d.publish("equity", struct {
+		Time     time.Time `json:"time"`
+		Symbol   string    `json:"symbol"`
+		Price    float64   `json:"price"`
+		Equity   float64   `json:"equity"`
+		Cash     float64   `json:"cash"`
+		Drawdown float64   `json:"drawdown"`
+	}{de.GetTime(), de.GetSymbol(), de.LatestPrice(), equity, p.Cash(), drawdown})
+}
+
+// Fill publishes a fill
+func (d *Dashboard) Fill(f FillEvent) {
+	d.publish("fill", struct {
+		Time      time.Time `json:"time"`
+		Symbol    string    `json:"symbol"`
+		Direction string    `json:"direction"`
+		Qty       float64   `json:"qty"`
+		Price     float64   `json:"price"`
+		Cost      float64   `json:"cost"`
+	}{f.GetTime(), f.GetSymbol(), f.GetDirection(), f.GetQty(), f.GetPrice(), f.GetCost()})
+}
+
+// Done publishes the end of the test and closes the event streams
+func (d *Dashboard) Done() {
+	d.publish("done", struct{}{})
+
+	d.mu.Lock()
+	d.done = true
+	for ch := range d.clients {
+		close(ch)
+		delete(d.clients, ch)
+	}
+	d.mu.Unlock()
+}
+
+// Reset removes the history, e.g. before the test is run again
+func (d *Dashboard) Reset() {
+	d.mu.Lock()
+	d.history = nil
+	d.high = 0
+	d.done = false
+	d.mu.Unlock()
+}
+
+// publish sends a message to all clients, slow clients miss messages instead of blocking the test
+func (d *Dashboard) publish(event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	msg := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.history = append(d.history, msg)
+	if d.History > 0 && len(d.history) > d.History {
+		d.history = d.history[len(d.history)-d.History:]
+	}
+	for ch := range d.clients {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
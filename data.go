@@ -29,6 +29,12 @@ type DataStreamer interface {
 	History() []DataEventHandler
 	Latest(string) DataEventHandler
 	List(string) []DataEventHandler
+	Symbols() []string
+}
+
+// StreamSorter is the interface for data handlers sorting their stream by time
+type StreamSorter interface {
+	SortStream()
 }
 
 // Data is a basic data struct
@@ -37,6 +43,23 @@ type Data struct {
 	list          map[string][]DataEventHandler
 	stream        []DataEventHandler
 	streamHistory []DataEventHandler
+	logger        Logger
+	indicators    *IndicatorCache
+}
+
+// SetLogger implements the LoggerSetter interface
+func (d *Data) SetLogger(l Logger) {
+	d.logger = l
+}
+
+// SetIndicators implements the IndicatorSetter interface
+func (d *Data) SetIndicators(c *IndicatorCache) {
+	d.indicators = c
+}
+
+// Indicators returns the indicator cache of the test, nil outside of a test
+func (d *Data) Indicators() *IndicatorCache {
+	return d.indicators
 }
 
 // Load loads data endpoints into a stream.
@@ -45,8 +68,7 @@ type Data struct {
 func (d *Data) Load(exchange string, currPair, start string, end string) error {
 	s := utils.StringToUnix(start)
 	e := utils.StringToUnix(end)
-	fmt.Println(s)
-	fmt.Println(e)
+	logger(d.logger).Debug("loading data", "exchange", exchange, "pair", currPair, "start", s, "end", e)
 	resp, err := http.Get(fmt.Sprintf("http://192.168.99.100:32368/api/history/%s/%s/%d/%d/%d", exchange, currPair, s, e, 300))
 	if err != nil {
 		return err
@@ -125,6 +147,22 @@ func (d *Data) List(symbol string) []DataEventHandler {
 	return d.list[symbol]
 }
 
+// Symbols returns all symbols contained in the loaded data, sorted by name.
+func (d *Data) Symbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, events := range [][]DataEventHandler{d.streamHistory, d.stream} {
+		for _, e := range events {
+			if !seen[e.GetSymbol()] {
+				seen[e.GetSymbol()] = true
+				symbols = append(symbols, e.GetSymbol())
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
 // SortStream sorts the dataStream
 func (d *Data) SortStream() {
 	sort.Slice(d.stream, func(i, j int) bool {
@@ -0,0 +1,38 @@
+package backtest
+
+import (
+	"errors"
+	"time"
+)
+
+// DCA is a dollar cost averaging strategy, buying a fixed notional value
+// of a symbol on a fixed interval regardless of the price
+type DCA struct {
+	Notional float64       // value to buy on each interval
+	Interval time.Duration // time between two buys
+	last     map[string]time.Time
+}
+
+// CalculateSignal creates a buy signal once per interval for each symbol
+func (s *DCA) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	if s.Notional <= 0 || s.Interval <= 0 {
+		return &Signal{}, errors.New("DCA notional and interval must be positive")
+	}
+	if s.last == nil {
+		s.last = make(map[string]time.Time)
+	}
+
+	event := Event{Time: de.GetTime(), Symbol: de.GetSymbol()}
+	signal := Signal{Event: event}
+
+	last, ok := s.last[de.GetSymbol()]
+	if ok && de.GetTime().Sub(last) < s.Interval {
+		return &signal, nil
+	}
+
+	s.last[de.GetSymbol()] = de.GetTime()
+	signal.SetDirection("buy")
+	signal.Notional = s.Notional
+
+	return &signal, nil
+}
@@ -0,0 +1,126 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Seeder is the interface for components with a random number generator
+type Seeder interface {
+	SetSeed(int64)
+}
+
+// ErrNotDeterministic is returned by CheckDeterminism if two runs differ
+var ErrNotDeterministic = errors.New("runs are not deterministic")
+
+// maxDiffs limits the differences listed in a determinism report
+const maxDiffs = 20
+
+// SetDeterministic enables the strict determinism mode. Before each run the data, strategy,
+// portfolio and exchange implementing the Seeder interface are seeded with the seed, so runs
+// over the same data replay exactly. The package random number generator is left alone, as
+// other tests may run concurrently.
+func (t *Test) SetDeterministic(seed int64) {
+	t.deterministic = true
+	t.seed = seed
+}
+
+// seedComponents seeds the random number generators in determinism mode
+func (t *Test) seedComponents() {
+	if !t.deterministic {
+		return
+	}
+	for _, c := range []interface{}{t.data, t.strategy, t.portfolio, t.exchange} {
+		if s, ok := c.(Seeder); ok {
+			s.SetSeed(t.seed)
+		}
+	}
+}
+
+// DeterminismReport lists the differences between two runs of the same test
+type DeterminismReport struct {
+	Fills  [2]int // number of fills of each run
+	Points [2]int // number of equity points of each run
+	Diffs  []string
+}
+
+// Deterministic returns true if the runs did not differ
+func (r DeterminismReport) Deterministic() bool {
+	return len(r.Diffs) == 0
+}
+
+// String returns the differences as text
+func (r DeterminismReport) String() string {
+	if r.Deterministic() {
+		return fmt.Sprintf("runs are identical: %d fills, %d equity points", r.Fills[0], r.Points[0])
+	}
+	return fmt.Sprintf("runs differ:\n%s", strings.Join(r.Diffs, "\n"))
+}
+
+// CheckDeterminism runs two tests created by setup and compares their fills, equity
+// points, final values and warnings. It returns ErrNotDeterministic with the report of
+// the differences if the runs differ. Setup has to create isolated tests on the same data.
+func CheckDeterminism(setup func() (*Test, error)) (DeterminismReport, error) {
+	var runs [2]Results
+	for i := range runs {
+		test, err := setup()
+		if err != nil {
+			return DeterminismReport{}, err
+		}
+		if runs[i], err = test.Run(); err != nil {
+			return DeterminismReport{}, fmt.Errorf("run %d: %v", i+1, err)
+		}
+	}
+
+	var fills, points [2][]string
+	for i, results := range runs {
+		s, ok := results.Statistic.(*Statistic)
+		if !ok {
+			return DeterminismReport{}, errors.New("determinism check needs a *Statistic")
+		}
+		for _, f := range s.Transactions() {
+			fills[i] = append(fills[i], fmt.Sprintf("%+v", f))
+		}
+		for _, p := range s.Equity() {
+			points[i] = append(points[i], fmt.Sprintf("%+v", p))
+		}
+	}
+
+	r := DeterminismReport{
+		Fills:  [2]int{len(fills[0]), len(fills[1])},
+		Points: [2]int{len(points[0]), len(points[1])},
+	}
+	r.diff("fill", fills)
+	r.diff("equity point", points)
+	r.diff("value", [2][]string{{fmt.Sprint(runs[0].Value)}, {fmt.Sprint(runs[1].Value)}})
+	r.diff("cash", [2][]string{{fmt.Sprint(runs[0].Cash)}, {fmt.Sprint(runs[1].Cash)}})
+	r.diff("warnings", [2][]string{{fmt.Sprint(len(runs[0].Warnings))}, {fmt.Sprint(len(runs[1].Warnings))}})
+
+	if !r.Deterministic() {
+		return r, ErrNotDeterministic
+	}
+	return r, nil
+}
+
+// diff adds the differences of two lists of formatted values
+func (r *DeterminismReport) diff(name string, values [2][]string) {
+	if len(values[0]) != len(values[1]) {
+		r.add(fmt.Sprintf("%s count: %d != %d", name, len(values[0]), len(values[1])))
+	}
+	for i := 0; i < len(values[0]) && i < len(values[1]); i++ {
+		if values[0][i] != values[1][i] {
+			r.add(fmt.Sprintf("%s %d: %s != %s", name, i, values[0][i], values[1][i]))
+		}
+	}
+}
+
+// add adds a difference, up to the maximum listed differences
+func (r *DeterminismReport) add(diff string) {
+	switch {
+	case len(r.Diffs) < maxDiffs:
+		r.Diffs = append(r.Diffs, diff)
+	case len(r.Diffs) == maxDiffs:
+		r.Diffs = append(r.Diffs, "...")
+	}
+}
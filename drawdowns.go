@@ -0,0 +1,76 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DrawdownEpisode is a period from an equity high until the equity recovers to the high.
+// Episodes which are not recovered at the end of the test have a zero recovery time.
+type DrawdownEpisode struct {
+	Start     time.Time     // time of the equity high
+	Trough    time.Time     // time of the lowest equity
+	Recovery  time.Time     // time the equity reached the high again
+	Depth     float64       // largest drop from the high in percent as negative value
+	Duration  time.Duration // from the start to the recovery or the end of the test
+	Recovered bool
+}
+
+// RecoveryDuration returns the time from the trough to the recovery, 0 if not recovered
+func (d DrawdownEpisode) RecoveryDuration() time.Duration {
+	if !d.Recovered {
+		return 0
+	}
+	return d.Recovery.Sub(d.Trough)
+}
+
+// DrawdownEpisodes returns all drawdown episodes deeper than the threshold, e.g. 0.05
+// for drawdowns of more than 5%, sorted by depth with the deepest first
+func (s Statistic) DrawdownEpisodes(threshold float64) []DrawdownEpisode {
+	var episodes []DrawdownEpisode
+	var current *DrawdownEpisode
+
+	for i, e := range s.equity {
+		if e.drawdown >= 0 {
+			if current != nil {
+				current.Recovery = e.timestamp
+				current.Recovered = true
+				current.Duration = e.timestamp.Sub(current.Start)
+				episodes = append(episodes, *current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			start := e.timestamp
+			if i > 0 {
+				start = s.equity[i-1].timestamp
+			}
+			current = &DrawdownEpisode{Start: start, Trough: e.timestamp, Depth: e.drawdown}
+		}
+		if e.drawdown < current.Depth {
+			current.Depth = e.drawdown
+			current.Trough = e.timestamp
+		}
+	}
+
+	if current != nil {
+		if last, ok := s.lastEquityPoint(); ok {
+			current.Duration = last.timestamp.Sub(current.Start)
+		}
+		episodes = append(episodes, *current)
+	}
+
+	threshold = math.Abs(threshold)
+	filtered := episodes[:0]
+	for _, d := range episodes {
+		if -d.Depth > threshold {
+			filtered = append(filtered, d)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].Depth < filtered[j].Depth })
+	return filtered
+}
@@ -0,0 +1,139 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"time"
+)
+
+// echartsScript loads the pinned ECharts release of the interactive pages from the jsDelivr
+// CDN, so viewing them needs network access
+const echartsScript = template.HTML(`<script src="https://cdn.jsdelivr.net/npm/echarts@5.4.3/dist/echarts.min.js"></script>`)
+
+// echartsTemplate renders an ECharts option as interactive HTML page
+var echartsTemplate = template.Must(template.New("echarts").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+{{.Script}}
+</head>
+<body style="margin:0">
+<div id="chart" style="width:100%;height:100vh"></div>
+<script>
+var chart = echarts.init(document.getElementById("chart"));
+var option = {{.Option}};
+// show the trade details of fills, the value of all other series
+option.tooltip.formatter = function(params) {
+	var lines = [echarts.time.format(params[0].value[0], "{yyyy}-{MM}-{dd} {HH}:{mm}", false)];
+	params.forEach(function(p) {
+		lines.push(p.marker + p.seriesName + ": " + (p.data.trade || p.value[1]));
+	});
+	return lines.join("<br/>");
+};
+chart.setOption(option);
+window.addEventListener("resize", function() { chart.resize(); });
+</script>
+</body>
+</html>
+`))
+
+// GraphResultHTML serves the equity curve with the fills as interactive HTML chart
+func (s *Statistic) GraphResultHTML(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.WriteHTMLChart(res); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WriteHTMLChart writes the equity curve, buy and hold value, benchmark and fills as
+// interactive HTML chart with zoom, tooltips and toggleable series. The page loads ECharts
+// from its CDN, see WriteReport for a report without network access.
+func (s *Statistic) WriteHTMLChart(w io.Writer) error {
+	option, err := json.Marshal(s.echartsOption())
+	if err != nil {
+		return err
+	}
+
+	return echartsTemplate.Execute(w, struct {
+		Title  string
+		Script template.HTML
+		Option template.JS
+	}{
+		Title:  "Backtest result",
+		Script: echartsScript,
+		Option: template.JS(option),
+	})
+}
+
+// echartsOption builds the ECharts option of the equity chart
+func (s *Statistic) echartsOption() map[string]interface{} {
+	var equity, buyAndHold, benchmark [][]interface{}
+	for _, e := range s.equity {
+		t := e.timestamp.UnixNano() / 1e6
+		equity = append(equity, []interface{}{t, e.equity})
+		buyAndHold = append(buyAndHold, []interface{}{t, e.buyAndHoldValue})
+		if e.benchmarkValue != 0 {
+			benchmark = append(benchmark, []interface{}{t, e.benchmarkValue})
+		}
+	}
+
+	// fills are placed on the equity of their time, the tooltip shows the trade details
+	var buys, sells []map[string]interface{}
+	for _, f := range s.transactionHistory {
+		point := map[string]interface{}{
+			"value": []interface{}{f.GetTime().UnixNano() / 1e6, s.equityAt(f.GetTime())},
+			"trade": fmt.Sprintf("%s %s %.4f @ %.4f, cost %.4f", f.GetDirection(), f.GetSymbol(), f.GetQty(), f.GetPrice(), f.GetCost()),
+		}
+		if f.GetDirection() == "SLD" {
+			sells = append(sells, point)
+			continue
+		}
+		buys = append(buys, point)
+	}
+
+	series := []map[string]interface{}{
+		{"name": "Equity", "type": "line", "showSymbol": false, "data": equity},
+		{"name": "Buy and hold", "type": "line", "showSymbol": false, "data": buyAndHold},
+		{"name": "Buys", "type": "scatter", "symbol": "triangle", "symbolSize": 10, "itemStyle": map[string]string{"color": "#2ca02c"}, "data": buys},
+		{"name": "Sells", "type": "scatter", "symbol": "triangle", "symbolRotate": 180, "symbolSize": 10, "itemStyle": map[string]string{"color": "#d62728"}, "data": sells},
+	}
+	if len(benchmark) > 0 {
+		series = append(series, map[string]interface{}{"name": s.benchmarkName, "type": "line", "showSymbol": false, "data": benchmark})
+	}
+	if s.initialCash != 0 {
+		series[0]["markLine"] = map[string]interface{}{
+			"symbol":    "none",
+			"lineStyle": map[string]string{"type": "dashed", "color": "#999"},
+			"data":      []map[string]interface{}{{"name": "Initial cash", "yAxis": s.initialCash}},
+		}
+	}
+
+	return map[string]interface{}{
+		"tooltip": map[string]interface{}{"trigger": "axis"},
+		"legend":  map[string]interface{}{"top": 10},
+		"grid":    map[string]interface{}{"left": 60, "right": 40, "top": 50, "bottom": 80},
+		"xAxis":   map[string]interface{}{"type": "time"},
+		"yAxis":   map[string]interface{}{"type": "value", "scale": true},
+		"dataZoom": []map[string]interface{}{
+			{"type": "inside"},
+			{"type": "slider"},
+		},
+		"series": series,
+	}
+}
+
+// equityAt returns the equity of the last equity point at or before t
+func (s *Statistic) equityAt(t time.Time) float64 {
+	var equity float64
+	for _, e := range s.equity {
+		if e.timestamp.After(t) {
+			break
+		}
+		equity = e.equity
+	}
+	return equity
+}
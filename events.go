@@ -1,6 +1,7 @@
 package backtest
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -44,6 +45,18 @@ func (e Event) GetSymbol() string {
 	return e.Symbol
 }
 
+// StrategyError declares an event for a recovered panic of a strategy
+type StrategyError struct {
+	Event
+	Panic interface{} // value passed to panic
+	Stack string      // stack trace of the panic
+}
+
+// Error implements the error interface
+func (e StrategyError) Error() string {
+	return fmt.Sprintf("strategy panic on %s at %s: %v", e.Symbol, e.Time.Format(time.RFC3339), e.Panic)
+}
+
 // DataEventHandler declares a data event interface
 type DataEventHandler interface {
 	EventHandler
@@ -138,13 +151,29 @@ func (t Tick) LatestPrice() float64 {
 type SignalEvent interface {
 	EventHandler
 	Directioner
+	Strengther
 	IsSignal() bool
 }
 
+// Strengther defines a signal strength interface
+type Strengther interface {
+	SetStrength(float64)
+	GetStrength() float64
+}
+
 // Signal declares a basic signal event
 type Signal struct {
 	Event
-	Direction string // long or short
+	Direction  string   // long or short
+	Strength   float64  // conviction of the signal, 0 is treated as full strength
+	Notional   float64  // order value at full strength, 0 to use the default size
+	Qty        float64  // fixed qty of the order, 0 to use the size handler
+	Fraction   float64  // fraction of the open position to close, 0 if unset
+	Limit      float64  // limit price of the entry, 0 for a market order
+	TTL        int      // number of bars a limit entry stays pending, 0 for no expiry
+	Tags       []string // free-form labels propagated to orders and fills, e.g. the entry reason
+	StopLoss   float64  // stop loss price attached to the entry, 0 if unset
+	TakeProfit float64  // take profit price attached to the entry, 0 if unset
 }
 
 // IsSignal implements the Signal interface.
@@ -162,6 +191,192 @@ func (s Signal) GetDirection() string {
 	return s.Direction
 }
 
+// SetStrength sets the Strength field of a Signal
+func (s *Signal) SetStrength(f float64) {
+	s.Strength = f
+}
+
+// GetStrength returns the Strength of a Signal, an unset strength returns 1
+func (s Signal) GetStrength() float64 {
+	if s.Strength == 0 {
+		return 1
+	}
+	return s.Strength
+}
+
+// Notionaler defines an interface for signals requesting a fixed order value
+type Notionaler interface {
+	GetNotional() float64
+}
+
+// GetNotional returns the Notional order value of a Signal
+func (s Signal) GetNotional() float64 {
+	return s.Notional
+}
+
+// SetQty sets the fixed Qty of a Signal
+func (s *Signal) SetQty(f float64) {
+	s.Qty = f
+}
+
+// GetQty returns the fixed Qty of a Signal
+func (s Signal) GetQty() float64 {
+	return s.Qty
+}
+
+// Fractioner defines an interface for signals closing a fraction of the open position
+type Fractioner interface {
+	GetFraction() float64
+}
+
+// GetFraction returns the Fraction of the open position a Signal closes
+func (s Signal) GetFraction() float64 {
+	return s.Fraction
+}
+
+// Tagger defines an interface for events carrying metadata tags
+type Tagger interface {
+	GetTags() []string
+}
+
+// GetTags returns the Tags of a Signal
+func (s Signal) GetTags() []string {
+	return s.Tags
+}
+
+// Limiter defines an interface for signals requesting a limit entry
+type Limiter interface {
+	GetLimit() float64
+}
+
+// GetLimit returns the Limit price of a Signal
+func (s Signal) GetLimit() float64 {
+	return s.Limit
+}
+
+// Expirer defines an interface for signals with an expiry
+type Expirer interface {
+	GetTTL() int
+}
+
+// GetTTL returns the number of bars the order of a Signal stays pending
+func (s Signal) GetTTL() int {
+	return s.TTL
+}
+
+// Bracketer defines an interface for signals carrying stop loss and take profit prices
+type Bracketer interface {
+	GetStopLoss() float64
+	GetTakeProfit() float64
+}
+
+// GetStopLoss returns the StopLoss price of a Signal
+func (s Signal) GetStopLoss() float64 {
+	return s.StopLoss
+}
+
+// GetTakeProfit returns the TakeProfit price of a Signal
+func (s Signal) GetTakeProfit() float64 {
+	return s.TakeProfit
+}
+
+// BasketSignalEvent declares a signal event spanning multiple symbols.
+type BasketSignalEvent interface {
+	SignalEvent
+	GetLegs() []Leg
+}
+
+// Leg declares a single symbol of a basket signal
+type Leg struct {
+	Symbol    string
+	Direction string  // buy or sell
+	Ratio     float64 // hedge ratio of the leg relative to the basket size, positive unless the qty is fixed
+	Qty       float64 // fixed qty of the leg, 0 to size the leg by its ratio
+	Limit     float64 // limit price of the leg, 0 for a market order
+}
+
+// BasketSignal declares a signal to trade multiple symbols together,
+// e.g. long ETH and short BTC with a hedge ratio
+type BasketSignal struct {
+	Signal
+	Legs []Leg
+}
+
+// AddLeg appends a leg to the basket signal
+func (b *BasketSignal) AddLeg(symbol, direction string, ratio float64) {
+	b.Legs = append(b.Legs, Leg{Symbol: symbol, Direction: direction, Ratio: ratio})
+}
+
+// GetLegs returns the legs of a basket signal
+func (b BasketSignal) GetLegs() []Leg {
+	return b.Legs
+}
+
+// CancelOrderEvent declares a signal to cancel pending orders.
+type CancelOrderEvent interface {
+	SignalEvent
+	IsCancelOrder() bool
+	GetOrderID() int
+}
+
+// CancelOrder declares a signal to cancel a pending order by its id,
+// an id of 0 cancels all pending orders of the symbol
+type CancelOrder struct {
+	Signal
+	OrderID int
+}
+
+// IsCancelOrder declares a cancel order event
+func (c CancelOrder) IsCancelOrder() bool {
+	return true
+}
+
+// GetOrderID returns the id of the order to cancel
+func (c CancelOrder) GetOrderID() int {
+	return c.OrderID
+}
+
+// ModifyOrderEvent declares a signal to modify a pending order.
+type ModifyOrderEvent interface {
+	SignalEvent
+	IsModifyOrder() bool
+	GetOrderID() int
+	Modify(*Order)
+}
+
+// ModifyOrder declares a signal to modify a pending order by its id,
+// an id of 0 modifies all pending orders of the symbol. Zero values are left unchanged.
+type ModifyOrder struct {
+	Signal
+	OrderID  int
+	NewQty   float64
+	NewLimit float64
+	NewStop  float64
+}
+
+// IsModifyOrder declares a modify order event
+func (m ModifyOrder) IsModifyOrder() bool {
+	return true
+}
+
+// GetOrderID returns the id of the order to modify
+func (m ModifyOrder) GetOrderID() int {
+	return m.OrderID
+}
+
+// Modify applies the new values to an order
+func (m ModifyOrder) Modify(o *Order) {
+	if m.NewQty > 0 {
+		o.Qty = m.NewQty
+	}
+	if m.NewLimit > 0 && o.OrderType == "LMT" {
+		o.Limit = m.NewLimit
+	}
+	if m.NewStop > 0 && o.OrderType == "STP" {
+		o.Stop = m.NewStop
+	}
+}
+
 // OrderEvent declares the order event interface.
 type OrderEvent interface {
 	EventHandler
@@ -185,10 +400,34 @@ type Quantifier interface {
 // Order declares a basic order event
 type Order struct {
 	Event
-	Direction string  // buy or sell
-	Qty       float64 // quantity of the order
-	OrderType string  // market or limit
-	Limit     float64 // limit for the order
+	ID         int      // set by the exchange when the order is booked
+	ParentID   int      // id of the entry order for bracket orders
+	Direction  string   // buy or sell
+	Qty        float64  // quantity of the order
+	OrderType  string   // MKT for market, LMT for limit or STP for stop
+	Limit      float64  // limit for the order
+	Stop       float64  // stop price for the order
+	StopLoss   float64  // stop loss price of the bracket attached to the entry
+	TakeProfit float64  // take profit price of the bracket attached to the entry
+	TTL        int      // number of bars the order stays pending, 0 for no expiry
+	Tags       []string // tags of the originating signal
+	Arrival    float64  // market price when the order was created
+	bars       int      // number of bars the order is pending
+}
+
+// GetTags returns the Tags of an Order
+func (o Order) GetTags() []string {
+	return o.Tags
+}
+
+// GetArrivalPrice returns the market price when the order was created
+func (o Order) GetArrivalPrice() float64 {
+	return o.Arrival
+}
+
+// GetOrderType returns the OrderType field of an Order
+func (o Order) GetOrderType() string {
+	return o.OrderType
 }
 
 // IsOrder declares an order event.
@@ -221,6 +460,7 @@ type FillEvent interface {
 	EventHandler
 	Directioner
 	Quantifier
+	Tagger
 	IsFill() bool
 	GetPrice() float64
 	GetCommission() float64
@@ -239,7 +479,32 @@ type Fill struct {
 	Price       float64
 	Commission  float64
 	ExchangeFee float64
-	Cost        float64 // the total cost of the filled order incl commission and fees
+	Cost        float64  // the total cost of the filled order incl commission and fees
+	Tags        []string // tags of the originating signal
+	OrderType   string   // type of the filled order
+	Arrival     float64  // market price when the filled order was created
+}
+
+// ArrivalPricer is the optional interface of orders and fills which know the market
+// price at the creation of the order, used for transaction cost analysis
+type ArrivalPricer interface {
+	GetArrivalPrice() float64
+	GetOrderType() string
+}
+
+// GetTags returns the Tags of a Fill
+func (f Fill) GetTags() []string {
+	return f.Tags
+}
+
+// GetArrivalPrice returns the market price when the filled order was created
+func (f Fill) GetArrivalPrice() float64 {
+	return f.Arrival
+}
+
+// GetOrderType returns the type of the filled order
+func (f Fill) GetOrderType() string {
+	return f.OrderType
 }
 
 // IsFill declares a fill event.
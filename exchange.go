@@ -1,7 +1,9 @@
 package backtest
 
 import (
+	"errors"
 	"math"
+	"time"
 )
 
 // Implementing all orders as price takers
@@ -10,6 +12,15 @@ import (
 // ExecutionHandler is the basic interface for executing orders
 type ExecutionHandler interface {
 	ExecuteOrder(OrderEvent, DataHandler) (*Fill, error)
+	OrderBook
+}
+
+// OrderBook handles the pending limit and stop orders of an exchange
+type OrderBook interface {
+	OnData(DataEventHandler) ([]*Fill, error)
+	PendingOrders() []*Order
+	CancelOrder(CancelOrderEvent) error
+	ModifyOrder(ModifyOrderEvent) error
 }
 
 // Exchange is a basic execution handler implementation
@@ -17,19 +28,220 @@ type Exchange struct {
 	Symbol         string
 	ExchangeFee    float64
 	CommissionRate float64
+	orders         []*Order // pending limit and stop orders
+	lastID         int
+	logger         Logger
+}
+
+// SetLogger implements the LoggerSetter interface
+func (e *Exchange) SetLogger(l Logger) {
+	e.logger = l
 }
 
-// ExecuteOrder executes an order event
+// ExecuteOrder executes an order event. Market orders are filled directly,
+// limit and stop orders are booked as pending and return no fill.
 func (e *Exchange) ExecuteOrder(order OrderEvent, data DataHandler) (*Fill, error) {
+	o, ok := order.(*Order)
+	if !ok {
+		return nil, errors.New("could not execute order, unknown order type")
+	}
+	if o.ID == 0 {
+		e.lastID++
+		o.ID = e.lastID
+	}
+
+	switch o.OrderType {
+	case "LMT", "STP":
+		e.orders = append(e.orders, o)
+		logger(e.logger).Debug("order booked", "id", o.ID, "type", o.OrderType, "symbol", o.GetSymbol(), "qty", o.Qty)
+		return nil, nil
+	}
+
 	// fetch latest known data event for the symbol
 	latest := data.Latest(order.GetSymbol())
 	// simple implementation, creates a direct fill from the order
 	// based on the last known data price
+	f := e.fill(o, order.GetTime(), latest.LatestPrice())
+
+	// book stop loss and take profit of the entry as bracket
+	e.orders = append(e.orders, e.bracket(o, f.Price)...)
+
+	return f, nil
+}
+
+// OnData checks the pending orders against a data event and fills triggered orders
+func (e *Exchange) OnData(data DataEventHandler) ([]*Fill, error) {
+	var fills []*Fill
+	var pending []*Order
+	filledParents := make(map[int]bool)
+
+	for _, o := range e.orders {
+		if o.GetSymbol() != data.GetSymbol() || filledParents[o.ParentID] {
+			pending = append(pending, o)
+			continue
+		}
+
+		price, ok := triggerPrice(o, data)
+		if !ok {
+			// cancel orders not filled within their time to live
+			o.bars++
+			if o.TTL > 0 && o.bars >= o.TTL {
+				logger(e.logger).Info("order expired", "id", o.ID, "symbol", o.GetSymbol(), "bars", o.bars)
+				continue
+			}
+			pending = append(pending, o)
+			continue
+		}
+
+		fills = append(fills, e.fill(o, data.GetTime(), price))
+		pending = append(pending, e.bracket(o, price)...)
+		if o.ParentID != 0 {
+			filledParents[o.ParentID] = true
+		}
+	}
+
+	// cancel the remaining orders of a filled bracket
+	e.orders = nil
+	for _, o := range pending {
+		if o.ParentID != 0 && filledParents[o.ParentID] {
+			logger(e.logger).Debug("bracket order cancelled", "id", o.ID, "parent", o.ParentID, "symbol", o.GetSymbol())
+			continue
+		}
+		e.orders = append(e.orders, o)
+	}
+
+	return fills, nil
+}
+
+// PendingOrders returns the pending orders of the exchange
+func (e *Exchange) PendingOrders() []*Order {
+	return e.orders
+}
+
+// CancelOrder removes pending orders by id or all pending orders of the symbol
+func (e *Exchange) CancelOrder(c CancelOrderEvent) error {
+	var pending []*Order
+	var found bool
+	for _, o := range e.orders {
+		if matchOrder(o, c.GetOrderID(), c.GetSymbol()) {
+			found = true
+			continue
+		}
+		pending = append(pending, o)
+	}
+	e.orders = pending
+
+	if !found {
+		return errors.New("no pending order to cancel")
+	}
+	return nil
+}
+
+// ModifyOrder changes pending orders by id or all pending orders of the symbol
+func (e *Exchange) ModifyOrder(m ModifyOrderEvent) error {
+	var found bool
+	for _, o := range e.orders {
+		if matchOrder(o, m.GetOrderID(), m.GetSymbol()) {
+			m.Modify(o)
+			found = true
+		}
+	}
+
+	if !found {
+		return errors.New("no pending order to modify")
+	}
+	return nil
+}
+
+// matchOrder checks if an order has the id, or the symbol if the id is 0
+func matchOrder(o *Order, id int, symbol string) bool {
+	if id != 0 {
+		return o.ID == id
+	}
+	return o.GetSymbol() == symbol
+}
+
+// bracket returns the stop loss and take profit orders of an entry order filled at price
+func (e *Exchange) bracket(entry *Order, price float64) []*Order {
+	if entry.StopLoss == 0 && entry.TakeProfit == 0 {
+		return nil
+	}
+
+	direction := "sell"
+	if entry.Direction == "sell" {
+		direction = "buy"
+	}
+	event := Event{Time: entry.GetTime(), Symbol: entry.GetSymbol()}
+
+	var orders []*Order
+	if entry.StopLoss != 0 {
+		e.lastID++
+		orders = append(orders, &Order{
+			Event:     event,
+			ID:        e.lastID,
+			ParentID:  entry.ID,
+			Direction: direction,
+			Qty:       entry.Qty,
+			OrderType: "STP",
+			Stop:      entry.StopLoss,
+			Tags:      entry.Tags,
+			Arrival:   price,
+		})
+	}
+
+	if entry.TakeProfit != 0 {
+		e.lastID++
+		orders = append(orders, &Order{
+			Event:     event,
+			ID:        e.lastID,
+			ParentID:  entry.ID,
+			Direction: direction,
+			Qty:       entry.Qty,
+			OrderType: "LMT",
+			Limit:     entry.TakeProfit,
+			Tags:      entry.Tags,
+			Arrival:   price,
+		})
+	}
+	return orders
+}
+
+// triggerPrice checks if a pending order is triggered by a data event and returns its fill price.
+// Bars are checked against their high and low, other data events against their latest price.
+func triggerPrice(o *Order, data DataEventHandler) (price float64, ok bool) {
+	open, high, low := data.LatestPrice(), data.LatestPrice(), data.LatestPrice()
+	if bar, isBar := data.(Bar); isBar {
+		open, high, low = bar.Open, bar.High, bar.Low
+	}
+
+	switch {
+	case o.OrderType == "LMT" && o.Direction == "buy" && low <= o.Limit:
+		return math.Min(open, o.Limit), true
+	case o.OrderType == "LMT" && o.Direction == "sell" && high >= o.Limit:
+		return math.Max(open, o.Limit), true
+	case o.OrderType == "STP" && o.Direction == "buy" && high >= o.Stop:
+		return math.Max(open, o.Stop), true
+	case o.OrderType == "STP" && o.Direction == "sell" && low <= o.Stop:
+		return math.Min(open, o.Stop), true
+	}
+
+	return price, false
+}
+
+// fill creates a fill event for an order at the given time and price
+func (e *Exchange) fill(order OrderEvent, t time.Time, price float64) *Fill {
 	f := &Fill{
-		Event:    Event{Time: order.GetTime(), Symbol: order.GetSymbol()},
+		Event:    Event{Time: t, Symbol: order.GetSymbol()},
 		Exchange: e.Symbol,
 		Qty:      order.GetQty(),
-		Price:    latest.LatestPrice(), // last price from data event
+		Price:    price,
+	}
+	if t, ok := order.(Tagger); ok {
+		f.Tags = t.GetTags()
+	}
+	if a, ok := order.(ArrivalPricer); ok {
+		f.OrderType = a.GetOrderType()
+		f.Arrival = a.GetArrivalPrice()
 	}
 
 	switch order.GetDirection() {
@@ -43,7 +255,8 @@ func (e *Exchange) ExecuteOrder(order OrderEvent, data DataHandler) (*Fill, erro
 	f.ExchangeFee = e.calculateExchangeFee()
 	f.Cost = e.calculateCost(f.Commission, f.ExchangeFee)
 
-	return f, nil
+	logger(e.logger).Debug("order filled", "symbol", f.Symbol, "direction", f.Direction, "qty", f.Qty, "price", f.Price)
+	return f
 }
 
 // calculateComission() calculates the commission for a stock trade
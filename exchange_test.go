@@ -0,0 +1,154 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+)
+
+// bookBracket fills a market buy of 10 A at 100 with a stop loss at 95 and a take profit
+// at 110, and returns the exchange with the booked bracket
+func bookBracket(t *testing.T) *Exchange {
+	e := &Exchange{}
+	entry := &Order{
+		Event:      Event{Time: queueStart, Symbol: "A"},
+		Direction:  "buy",
+		Qty:        10,
+		OrderType:  "MKT",
+		StopLoss:   95,
+		TakeProfit: 110,
+	}
+	fill, err := e.ExecuteOrder(entry, basketData(map[string]float64{"A": 100}))
+	if err != nil || fill == nil {
+		t.Fatalf("entry not filled: %v", err)
+	}
+	if n := len(e.PendingOrders()); n != 2 {
+		t.Fatalf("booked %d bracket orders, want 2", n)
+	}
+	return e
+}
+
+// testBar returns a bar of A one minute after the start of the queue tests
+func testBar(open, high, low, close float64) Bar {
+	return Bar{
+		Event:   Event{Time: queueStart.Add(time.Minute), Symbol: "A"},
+		BarData: BarData{Open: open, High: high, Low: low, Close: close},
+	}
+}
+
+func TestBracketStopCancelsTakeProfit(t *testing.T) {
+	e := bookBracket(t)
+
+	fills, err := e.OnData(testBar(99, 100, 94, 96))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 || fills[0].GetDirection() != "SLD" || fills[0].GetPrice() != 95 {
+		t.Fatalf("got fills %+v, want the stop loss sold at 95", fills)
+	}
+	if pending := e.PendingOrders(); len(pending) != 0 {
+		t.Errorf("take profit still pending after the stop loss filled: %+v", pending)
+	}
+
+	// the cancelled take profit doesn't fill later
+	if fills, _ := e.OnData(testBar(108, 112, 107, 111)); len(fills) != 0 {
+		t.Errorf("got fills %+v after the bracket closed", fills)
+	}
+}
+
+func TestBracketTakeProfitCancelsStop(t *testing.T) {
+	e := bookBracket(t)
+
+	fills, err := e.OnData(testBar(105, 111, 104, 109))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 || fills[0].GetPrice() != 110 {
+		t.Fatalf("got fills %+v, want the take profit sold at 110", fills)
+	}
+	if pending := e.PendingOrders(); len(pending) != 0 {
+		t.Errorf("stop loss still pending after the take profit filled: %+v", pending)
+	}
+}
+
+func TestBracketBothTriggered(t *testing.T) {
+	e := bookBracket(t)
+
+	// a bar ranging over both legs fills only one of them
+	fills, err := e.OnData(testBar(100, 112, 94, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 {
+		t.Errorf("got %d fills of a bracket, want 1", len(fills))
+	}
+	if pending := e.PendingOrders(); len(pending) != 0 {
+		t.Errorf("orders still pending: %+v", pending)
+	}
+}
+
+// bookOrder books a pending order of 10 A on a new exchange
+func bookOrder(t *testing.T, o *Order) *Exchange {
+	e := &Exchange{}
+	o.Event = Event{Time: queueStart, Symbol: "A"}
+	o.Qty = 10
+	if fill, err := e.ExecuteOrder(o, basketData(map[string]float64{"A": 100})); err != nil || fill != nil {
+		t.Fatalf("order not booked: %v, %+v", err, fill)
+	}
+	return e
+}
+
+func TestLimitOrderExpires(t *testing.T) {
+	e := bookOrder(t, &Order{Direction: "buy", OrderType: "LMT", Limit: 95, TTL: 2})
+
+	for i := 0; i < 2; i++ {
+		if len(e.PendingOrders()) != 1 {
+			t.Fatalf("limit order not pending after %d bars", i)
+		}
+		if fills, _ := e.OnData(testBar(100, 101, 96, 100)); len(fills) != 0 {
+			t.Fatalf("limit order filled above its limit: %+v", fills)
+		}
+	}
+	if pending := e.PendingOrders(); len(pending) != 0 {
+		t.Fatalf("limit order pending after its time to live: %+v", pending)
+	}
+	// the expired order doesn't fill later
+	if fills, _ := e.OnData(testBar(94, 95, 90, 92)); len(fills) != 0 {
+		t.Errorf("expired limit order filled: %+v", fills)
+	}
+}
+
+func TestLimitOrderFillsWithinTTL(t *testing.T) {
+	e := bookOrder(t, &Order{Direction: "buy", OrderType: "LMT", Limit: 95, TTL: 2})
+
+	e.OnData(testBar(100, 101, 96, 100))
+	fills, _ := e.OnData(testBar(97, 98, 94, 96))
+	if len(fills) != 1 || fills[0].GetPrice() != 95 {
+		t.Errorf("got fills %+v, want the limit order filled at 95 on its last bar", fills)
+	}
+}
+
+func TestGapFillsAtOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		order *Order
+		bar   Bar
+		price float64
+	}{
+		{"buy limit gapped down", &Order{Direction: "buy", OrderType: "LMT", Limit: 95}, testBar(90, 92, 88, 91), 90},
+		{"sell limit gapped up", &Order{Direction: "sell", OrderType: "LMT", Limit: 105}, testBar(110, 112, 108, 111), 110},
+		{"sell stop gapped down", &Order{Direction: "sell", OrderType: "STP", Stop: 95}, testBar(90, 92, 88, 91), 90},
+		{"buy stop gapped up", &Order{Direction: "buy", OrderType: "STP", Stop: 105}, testBar(110, 112, 108, 111), 110},
+		{"buy limit touched", &Order{Direction: "buy", OrderType: "LMT", Limit: 95}, testBar(99, 100, 94, 96), 95},
+		{"sell stop touched", &Order{Direction: "sell", OrderType: "STP", Stop: 95}, testBar(99, 100, 94, 96), 95},
+	}
+	for _, tt := range tests {
+		e := bookOrder(t, tt.order)
+		fills, err := e.OnData(tt.bar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fills) != 1 || fills[0].GetPrice() != tt.price {
+			t.Errorf("%s: got fills %+v, want a fill at %v", tt.name, fills, tt.price)
+		}
+	}
+}
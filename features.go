@@ -0,0 +1,169 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Feature declares a named value calculated on every data event
+type Feature struct {
+	Name  string
+	Value func(DataEventHandler, DataHandler, PortfolioHandler) (float64, bool)
+}
+
+// PriceFeature is the latest price of the data event
+func PriceFeature() Feature {
+	return Feature{Name: "price", Value: func(de DataEventHandler, d DataHandler, p PortfolioHandler) (float64, bool) {
+		return de.LatestPrice(), true
+	}}
+}
+
+// SMAFeature is the simple moving average over n bars
+func SMAFeature(n int) Feature {
+	return Feature{Name: fmt.Sprintf("sma_%d", n), Value: func(de DataEventHandler, d DataHandler, p PortfolioHandler) (float64, bool) {
+		return IndicatorsOf(d).SMA(d, de.GetSymbol(), n)
+	}}
+}
+
+// VolatilityFeature is the standard deviation of the per bar returns over n bars
+func VolatilityFeature(n int) Feature {
+	return Feature{Name: fmt.Sprintf("volatility_%d", n), Value: func(de DataEventHandler, d DataHandler, p PortfolioHandler) (float64, bool) {
+		return IndicatorsOf(d).Volatility(d, de.GetSymbol(), n)
+	}}
+}
+
+// PositionFeature is the qty of the open position on the symbol
+func PositionFeature() Feature {
+	return Feature{Name: "position", Value: func(de DataEventHandler, d DataHandler, p PortfolioHandler) (float64, bool) {
+		pos, _ := p.Position(de.GetSymbol())
+		return pos.Qty, true
+	}}
+}
+
+// CashFeature is the current cash of the portfolio
+func CashFeature() Feature {
+	return Feature{Name: "cash", Value: func(de DataEventHandler, d DataHandler, p PortfolioHandler) (float64, bool) {
+		return p.Cash(), true
+	}}
+}
+
+// FeatureExporter writes a feature vector for every data event as CSV row, labeled
+// with the forward return of the symbol over the horizon. Rows are buffered until
+// the price of the horizon is known, call Flush after the run to write the rest.
+type FeatureExporter struct {
+	Features []Feature
+	Horizon  int // number of bars of the forward return label
+
+	w           *csv.Writer
+	wroteHeader bool
+	pending     map[string][]featureRow
+}
+
+type featureRow struct {
+	time   time.Time
+	symbol string
+	price  float64
+	values []string
+}
+
+// NewFeatureExporter creates a feature exporter writing to w, the horizon is at least
+// one bar
+func NewFeatureExporter(w io.Writer, horizon int, features ...Feature) (*FeatureExporter, error) {
+	if horizon < 1 {
+		return nil, fmt.Errorf("forward return horizon of %d bars, want at least 1", horizon)
+	}
+	return &FeatureExporter{
+		Features: features,
+		Horizon:  horizon,
+		w:        csv.NewWriter(w),
+		pending:  make(map[string][]featureRow),
+	}, nil
+}
+
+// Export calculates the features for a data event and writes all rows whose label is known
+func (f *FeatureExporter) Export(de DataEventHandler, d DataHandler, p PortfolioHandler) error {
+	if f.Horizon < 1 {
+		return fmt.Errorf("forward return horizon of %d bars, want at least 1", f.Horizon)
+	}
+	if err := f.writeHeader(); err != nil {
+		return err
+	}
+
+	row := featureRow{time: de.GetTime(), symbol: de.GetSymbol(), price: de.LatestPrice()}
+	for _, feature := range f.Features {
+		value := ""
+		if v, ok := feature.Value(de, d, p); ok {
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		row.values = append(row.values, value)
+	}
+
+	rows := append(f.pending[de.GetSymbol()], row)
+	// the oldest row is labeled once horizon newer rows are known
+	for len(rows) > f.Horizon {
+		label := ""
+		if rows[0].price != 0 {
+			label = strconv.FormatFloat((rows[f.Horizon].price-rows[0].price)/rows[0].price, 'f', -1, 64)
+		}
+		if err := f.writeRow(rows[0], label); err != nil {
+			return err
+		}
+		rows = rows[1:]
+	}
+	f.pending[de.GetSymbol()] = rows
+
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// Flush writes the buffered rows without a forward return label, ordered by symbol
+func (f *FeatureExporter) Flush() error {
+	if err := f.writeHeader(); err != nil {
+		return err
+	}
+
+	symbols := make([]string, 0, len(f.pending))
+	for symbol := range f.pending {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		for _, row := range f.pending[symbol] {
+			if err := f.writeRow(row, ""); err != nil {
+				return err
+			}
+		}
+		delete(f.pending, symbol)
+	}
+
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// writeHeader writes the column names once
+func (f *FeatureExporter) writeHeader() error {
+	if f.wroteHeader {
+		return nil
+	}
+	f.wroteHeader = true
+
+	header := []string{"time", "symbol"}
+	for _, feature := range f.Features {
+		header = append(header, feature.Name)
+	}
+	header = append(header, fmt.Sprintf("forward_return_%d", f.Horizon))
+
+	return f.w.Write(header)
+}
+
+// writeRow writes a labeled row
+func (f *FeatureExporter) writeRow(row featureRow, label string) error {
+	record := []string{row.time.Format(time.RFC3339), row.symbol}
+	record = append(record, row.values...)
+	record = append(record, label)
+	return f.w.Write(record)
+}
@@ -0,0 +1,61 @@
+package backtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFeatureExporterHorizon(t *testing.T) {
+	for _, horizon := range []int{-1, 0} {
+		if _, err := NewFeatureExporter(&bytes.Buffer{}, horizon, PriceFeature()); err == nil {
+			t.Errorf("horizon %d accepted", horizon)
+		}
+	}
+}
+
+func TestFeatureExporterFlushOrder(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFeatureExporter(&buf, 2, PriceFeature())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stream []DataEventHandler
+	for _, symbol := range []string{"D", "B", "A", "C"} {
+		stream = append(stream, closeBars(symbol, 10)...)
+	}
+	for _, e := range stream {
+		if err := f.Export(e, &Data{}, &Portfolio{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var symbols []string
+	for _, line := range lines[1:] {
+		symbols = append(symbols, strings.Split(line, ",")[1])
+	}
+	if got := strings.Join(symbols, ","); got != "A,B,C,D" {
+		t.Errorf("flushed the symbols in the order %s, want A,B,C,D", got)
+	}
+}
+
+func TestFeatureExporterLabel(t *testing.T) {
+	var buf bytes.Buffer
+	f, err := NewFeatureExporter(&buf, 1, PriceFeature())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range closeBars("A", 10, 11) {
+		if err := f.Export(e, &Data{}, &Portfolio{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], ",10,0.1") {
+		t.Errorf("got rows %q, want the first bar labeled with a return of 0.1", lines)
+	}
+}
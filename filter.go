@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"errors"
+	"time"
+)
+
+// SignalFilter is the basic interface for filters between the strategy and the portfolio.
+// A filter can modify a signal or veto it by returning an error.
+type SignalFilter interface {
+	Filter(SignalEvent, DataHandler, PortfolioHandler) (SignalEvent, error)
+}
+
+// SignalFilterFunc is an adapter to use ordinary functions as signal filters
+type SignalFilterFunc func(SignalEvent, DataHandler, PortfolioHandler) (SignalEvent, error)
+
+// Filter calls f(s, d, p)
+func (f SignalFilterFunc) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	return f(s, d, p)
+}
+
+// FilterChain applies multiple filters in order, the first veto stops the chain
+type FilterChain []SignalFilter
+
+// Filter passes the signal through all filters of the chain
+func (c FilterChain) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	var err error
+	for _, f := range c {
+		s, err = f.Filter(s, d, p)
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// VolatilityFilter vetoes signals when the volatility of the per bar returns
+// over the lookback period is outside of the given bounds
+type VolatilityFilter struct {
+	Lookback int     // number of bars to calculate the volatility over
+	Min      float64 // minimum volatility, 0 for no lower bound
+	Max      float64 // maximum volatility, 0 for no upper bound
+}
+
+// Filter implements the SignalFilter interface
+func (f VolatilityFilter) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	vol, ok := IndicatorsOf(d).Volatility(d, s.GetSymbol(), f.Lookback)
+	if !ok {
+		return s, errors.New("not enough data to calculate volatility")
+	}
+
+	if f.Min > 0 && vol < f.Min {
+		return s, errors.New("volatility below minimum")
+	}
+	if f.Max > 0 && vol > f.Max {
+		return s, errors.New("volatility above maximum")
+	}
+	return s, nil
+}
+
+// TimeOfDayFilter vetoes signals outside of a daily trading window.
+// A window with Start after End spans midnight.
+type TimeOfDayFilter struct {
+	Start    time.Duration // offset from midnight the window opens
+	End      time.Duration // offset from midnight the window closes
+	Location *time.Location
+}
+
+// Filter implements the SignalFilter interface
+func (f TimeOfDayFilter) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	t := s.GetTime()
+	if f.Location != nil {
+		t = t.In(f.Location)
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+
+	inWindow := offset >= f.Start && offset < f.End
+	if f.Start > f.End {
+		inWindow = offset >= f.Start || offset < f.End
+	}
+
+	if !inWindow {
+		return s, errors.New("signal outside of trading window")
+	}
+	return s, nil
+}
+
+// TrendFilter only passes buy signals above and sell signals below
+// the simple moving average over the lookback period
+type TrendFilter struct {
+	Lookback int
+}
+
+// Filter implements the SignalFilter interface
+func (f TrendFilter) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	sma, ok := IndicatorsOf(d).SMA(d, s.GetSymbol(), f.Lookback)
+	if !ok {
+		return s, errors.New("not enough data to calculate trend")
+	}
+	price := d.Latest(s.GetSymbol()).LatestPrice()
+
+	switch {
+	case s.GetDirection() == "buy" && price < sma:
+		return s, errors.New("buy signal against the trend")
+	case s.GetDirection() == "sell" && price > sma:
+		return s, errors.New("sell signal against the trend")
+	}
+	return s, nil
+}
+
+// CooldownFilter vetoes entry signals on a symbol within a minimum number of bars
+// or a minimum duration after the last filled entry, counted from the signal of the
+// entry. Exits are always passed. Entries vetoed later, e.g. by the portfolio, don't
+// start a cooldown.
+type CooldownFilter struct {
+	Bars     int           // minimum number of bars between entries, 0 to disable
+	Duration time.Duration // minimum duration between entries, 0 to disable
+
+	lastBar  map[string]int
+	lastTime map[string]time.Time
+	pending  map[string]cooldownEntry // passed entries waiting for their fill
+}
+
+// cooldownEntry is a passed entry signal
+type cooldownEntry struct {
+	fill string // direction of the fill of the entry, BOT or SLD
+	bar  int
+	time time.Time
+}
+
+// Filter implements the SignalFilter interface
+func (f *CooldownFilter) Filter(s SignalEvent, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	if f.lastBar == nil {
+		f.lastBar = make(map[string]int)
+		f.lastTime = make(map[string]time.Time)
+		f.pending = make(map[string]cooldownEntry)
+	}
+
+	// signals reducing the open position are exits
+	pos, _ := p.Position(s.GetSymbol())
+	if (pos.Qty > 0 && s.GetDirection() == "sell") || (pos.Qty < 0 && s.GetDirection() == "buy") {
+		return s, nil
+	}
+	if s.GetDirection() == "" {
+		return s, nil
+	}
+
+	bar := len(d.List(s.GetSymbol()))
+	if last, ok := f.lastBar[s.GetSymbol()]; ok && f.Bars > 0 && bar-last < f.Bars {
+		return s, errors.New("entry within cooldown bars")
+	}
+	if last, ok := f.lastTime[s.GetSymbol()]; ok && f.Duration > 0 && s.GetTime().Sub(last) < f.Duration {
+		return s, errors.New("entry within cooldown duration")
+	}
+
+	entry := cooldownEntry{fill: "BOT", bar: bar, time: s.GetTime()}
+	if s.GetDirection() == "sell" {
+		entry.fill = "SLD"
+	}
+	f.pending[s.GetSymbol()] = entry
+	return s, nil
+}
+
+// OnFill implements the FillListener interface and starts the cooldown of a filled entry
+func (f *CooldownFilter) OnFill(fill FillEvent) {
+	entry, ok := f.pending[fill.GetSymbol()]
+	if !ok || entry.fill != fill.GetDirection() {
+		return
+	}
+	delete(f.pending, fill.GetSymbol())
+	f.lastBar[fill.GetSymbol()] = entry.bar
+	f.lastTime[fill.GetSymbol()] = entry.time
+}
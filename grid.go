@@ -0,0 +1,114 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+)
+
+// Grid is a grid trading strategy. It places buy limit orders on evenly spaced price
+// levels below the market and replenishes the grid on each fill: a filled buy is
+// followed by a sell one level higher, a filled sell by a buy one level lower.
+// The initial grid is placed again until its orders are booked, so the portfolio has to
+// view the pending orders of the exchange, like Portfolio does.
+type Grid struct {
+	Lower  float64 // lowest price level of the grid
+	Upper  float64 // highest price level of the grid
+	Levels int     // number of price levels including lower and upper
+	Size   float64 // qty per level
+	placed bool
+	fills  []FillEvent
+	// pending directions of the orders per level, empty if no order is placed
+	pending []string
+}
+
+// CalculateSignal places the initial grid and replenishes filled levels
+func (g *Grid) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
+	if g.Levels < 2 || g.Upper <= g.Lower || g.Size <= 0 {
+		return &Signal{}, errors.New("invalid grid configuration")
+	}
+
+	signal := &BasketSignal{Signal: Signal{Event: Event{Time: de.GetTime(), Symbol: de.GetSymbol()}}}
+
+	// the initial grid is placed once its orders are booked, it may be rejected, e.g.
+	// during the warmup, by a filter or in an exchange outage
+	if !g.placed && g.pending != nil && (len(g.fills) > 0 || len(p.OpenOrders(de.GetSymbol())) > 0) {
+		g.placed = true
+	}
+	if !g.placed {
+		g.pending = make([]string, g.Levels)
+		price := de.LatestPrice()
+		for i := 0; i < g.Levels; i++ {
+			if g.level(i) < price {
+				g.addLeg(signal, i, "buy")
+			}
+		}
+	}
+
+	for _, fill := range g.fills {
+		i, ok := g.filledLevel(fill)
+		if !ok {
+			continue
+		}
+		g.pending[i] = ""
+		switch {
+		case fill.GetDirection() == "BOT" && i+1 < g.Levels:
+			g.addLeg(signal, i+1, "sell")
+		case fill.GetDirection() == "SLD" && i > 0:
+			g.addLeg(signal, i-1, "buy")
+		}
+	}
+	g.fills = nil
+
+	if len(signal.Legs) == 0 {
+		return signal, errors.New("no grid levels to place")
+	}
+
+	return signal, nil
+}
+
+// OnFill records the fills of the grid orders to replenish them on the next data event
+func (g *Grid) OnFill(fill FillEvent) {
+	g.fills = append(g.fills, fill)
+}
+
+// level returns the price of level i
+func (g *Grid) level(i int) float64 {
+	step := (g.Upper - g.Lower) / float64(g.Levels-1)
+	return g.Lower + float64(i)*step
+}
+
+// addLeg adds a limit order for level i to the signal
+func (g *Grid) addLeg(signal *BasketSignal, i int, direction string) {
+	g.pending[i] = direction
+	signal.Legs = append(signal.Legs, Leg{
+		Symbol:    signal.GetSymbol(),
+		Direction: direction,
+		Qty:       g.Size,
+		Limit:     g.level(i),
+	})
+}
+
+// filledLevel matches a fill to the pending level it most likely belongs to.
+// Limit orders fill at or better than their level, so a buy is matched to the lowest
+// pending buy level at or above the fill price, a sell to the highest pending sell
+// level at or below the fill price.
+func (g *Grid) filledLevel(fill FillEvent) (int, bool) {
+	index := -1
+	best := math.Inf(1)
+	for i, direction := range g.pending {
+		var distance float64
+		switch {
+		case direction == "buy" && fill.GetDirection() == "BOT" && g.level(i) >= fill.GetPrice():
+			distance = g.level(i) - fill.GetPrice()
+		case direction == "sell" && fill.GetDirection() == "SLD" && g.level(i) <= fill.GetPrice():
+			distance = fill.GetPrice() - g.level(i)
+		default:
+			continue
+		}
+		if distance < best {
+			best = distance
+			index = i
+		}
+	}
+	return index, index >= 0
+}
@@ -0,0 +1,32 @@
+package backtest
+
+import "errors"
+
+// EventHook is called with the events flowing through the engine. Events held by pointer,
+// like orders and signals, can be changed by the hook.
+type EventHook func(EventHandler) error
+
+// ErrSkipEvent is returned by a pre-event hook to drop the event without stopping the test
+var ErrSkipEvent = errors.New("skip event")
+
+// AddPreHook appends hooks called before an event is processed. A hook returning
+// ErrSkipEvent drops the event, any other error stops the test and is returned from Run.
+func (t *Test) AddPreHook(hooks ...EventHook) {
+	t.preHooks = append(t.preHooks, hooks...)
+}
+
+// AddPostHook appends hooks called after an event is processed, an error stops the test
+// and is returned from Run
+func (t *Test) AddPostHook(hooks ...EventHook) {
+	t.postHooks = append(t.postHooks, hooks...)
+}
+
+// runHooks calls the hooks in order until one returns an error
+func runHooks(hooks []EventHook, e EventHandler) error {
+	for _, hook := range hooks {
+		if err := hook(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
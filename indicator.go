@@ -0,0 +1,201 @@
+package backtest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// IndicatorKey identifies an indicator value of a symbol at a point in time
+type IndicatorKey struct {
+	Symbol    string
+	Indicator string
+	Params    string
+	Time      time.Time
+	Start     time.Time // first data event of values depending on the whole history, e.g. EMA
+}
+
+// IndicatorCache shares computed indicator values between the strategies, filters and
+// other components of a test. Each run of a test computes with a cache of its own, which
+// the filters and strategies reach through the data handler, see IndicatorsOf. A cache
+// shared between runs with Test.SetIndicators must only be shared over the same data.
+type IndicatorCache struct {
+	mu     sync.RWMutex
+	values map[IndicatorKey]float64
+}
+
+// maxIndicatorValues bounds the values of a cache, e.g. of a live test running for weeks,
+// a full cache is emptied
+const maxIndicatorValues = 1 << 20
+
+// NewIndicatorCache creates an empty indicator cache
+func NewIndicatorCache() *IndicatorCache {
+	return &IndicatorCache{values: make(map[IndicatorKey]float64)}
+}
+
+// IndicatorSetter is the interface for data handlers sharing the indicator cache of a test
+type IndicatorSetter interface {
+	SetIndicators(*IndicatorCache)
+}
+
+// IndicatorsOf returns the indicator cache of a data handler, nil if it has none. The nil
+// cache computes the values without caching them.
+func IndicatorsOf(d DataHandler) *IndicatorCache {
+	if c, ok := d.(interface{ Indicators() *IndicatorCache }); ok {
+		return c.Indicators()
+	}
+	return nil
+}
+
+// Get returns the cached value for the key, or computes and caches it.
+// Values which could not be computed are not cached.
+func (c *IndicatorCache) Get(key IndicatorKey, compute func() (float64, bool)) (float64, bool) {
+	if v, ok := c.lookup(key); ok {
+		return v, true
+	}
+	v, ok := compute()
+	if !ok {
+		return v, false
+	}
+	c.put(key, v)
+	return v, true
+}
+
+// lookup returns the cached value for the key
+func (c *IndicatorCache) lookup(key IndicatorKey) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+// put caches the value for the key
+func (c *IndicatorCache) put(key IndicatorKey, v float64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if len(c.values) >= maxIndicatorValues {
+		c.values = make(map[IndicatorKey]float64)
+	}
+	c.values[key] = v
+	c.mu.Unlock()
+}
+
+// Len returns the number of cached values
+func (c *IndicatorCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.values)
+}
+
+// Reset removes all cached values
+func (c *IndicatorCache) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.values = make(map[IndicatorKey]float64)
+	c.mu.Unlock()
+}
+
+// SMA returns the simple moving average of the last n prices of a symbol
+func (c *IndicatorCache) SMA(d DataHandler, symbol string, n int) (float64, bool) {
+	events, key, ok := lastEvents(d, symbol, n)
+	if !ok {
+		return 0, false
+	}
+	key.Indicator = "sma"
+	key.Params = fmt.Sprint(n)
+
+	return c.Get(key, func() (float64, bool) {
+		return stat.Mean(prices(events), nil), true
+	})
+}
+
+// Volatility returns the standard deviation of the last n per bar returns of a symbol
+func (c *IndicatorCache) Volatility(d DataHandler, symbol string, n int) (float64, bool) {
+	events, key, ok := lastEvents(d, symbol, n+1)
+	if !ok || n < 2 {
+		return 0, false
+	}
+	key.Indicator = "volatility"
+	key.Params = fmt.Sprint(n)
+
+	return c.Get(key, func() (float64, bool) {
+		prices := prices(events)
+		var returns []float64
+		for i := 1; i < len(prices); i++ {
+			if prices[i-1] == 0 {
+				continue
+			}
+			returns = append(returns, (prices[i]-prices[i-1])/prices[i-1])
+		}
+		if len(returns) < 2 {
+			return 0, false
+		}
+		return stat.StdDev(returns, nil), true
+	})
+}
+
+// lastEvents returns the last n data events of a symbol and the key of the latest one,
+// without copying them, so cached values are found without touching the prices
+func lastEvents(d DataHandler, symbol string, n int) (events []DataEventHandler, key IndicatorKey, ok bool) {
+	list := d.List(symbol)
+	if n < 1 || len(list) < n {
+		return nil, key, false
+	}
+	key = IndicatorKey{Symbol: symbol, Time: list[len(list)-1].GetTime()}
+
+	return list[len(list)-n:], key, true
+}
+
+// lastPrices returns the last n prices of a symbol and the key of the latest data event
+func lastPrices(d DataHandler, symbol string, n int) ([]float64, IndicatorKey, bool) {
+	events, key, ok := lastEvents(d, symbol, n)
+	if !ok {
+		return nil, key, false
+	}
+	return prices(events), key, true
+}
+
+// prices returns the latest prices of the data events
+func prices(events []DataEventHandler) []float64 {
+	prices := make([]float64, len(events))
+	for i, e := range events {
+		prices[i] = e.LatestPrice()
+	}
+	return prices
+}
+
+// SetIndicators shares the indicator cache between tests, e.g. the runs of an optimization
+// over the same data. Without a shared cache each run computes with a cache of its own.
+func (t *Test) SetIndicators(c *IndicatorCache) {
+	t.indicators = c
+}
+
+// WithIndicators shares the indicator cache between tests over the same data
+func WithIndicators(c *IndicatorCache) Option {
+	return func(t *Test) { t.SetIndicators(c) }
+}
+
+// setIndicators passes the shared indicator cache or a new one to the data handler
+func (t *Test) setIndicators() {
+	s, ok := t.data.(IndicatorSetter)
+	if !ok {
+		return
+	}
+	c := t.indicators
+	if c == nil {
+		c = NewIndicatorCache()
+	}
+	s.SetIndicators(c)
+}
@@ -0,0 +1,90 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+)
+
+// countedBar is a bar counting the reads of its price
+type countedBar struct {
+	Bar
+	reads *int
+}
+
+// LatestPrice returns the close of the bar and counts the read
+func (b countedBar) LatestPrice() float64 {
+	*b.reads++
+	return b.Close
+}
+
+// countedData returns the data of a symbol with the closes, all processed, counting the
+// reads of their prices
+func countedData(symbol string, closes ...float64) (*Data, *int) {
+	reads := new(int)
+	var stream []DataEventHandler
+	for i, c := range closes {
+		event := Event{Time: queueStart.Add(time.Duration(i) * time.Minute), Symbol: symbol}
+		stream = append(stream, countedBar{Bar: Bar{Event: event, BarData: BarData{Close: c}}, reads: reads})
+	}
+	d := &Data{}
+	d.SetStream(stream)
+	for _, ok := d.Next(); ok; _, ok = d.Next() {
+	}
+	return d, reads
+}
+
+func TestIndicatorCacheHit(t *testing.T) {
+	d, reads := countedData("A", 1, 2, 3, 4, 5, 6)
+	c := NewIndicatorCache()
+
+	sma, ok := c.SMA(d, "A", 4)
+	if !ok || sma != 4.5 {
+		t.Fatalf("SMA is %v, %v, want 4.5", sma, ok)
+	}
+	if *reads != 4 {
+		t.Fatalf("computing the SMA read %d prices, want 4", *reads)
+	}
+	if _, ok := c.Volatility(d, "A", 3); !ok {
+		t.Fatal("volatility not computed")
+	}
+
+	*reads = 0
+	if hit, _ := c.SMA(d, "A", 4); hit != sma {
+		t.Errorf("cached SMA is %v, want %v", hit, sma)
+	}
+	c.Volatility(d, "A", 3)
+	if *reads != 0 {
+		t.Errorf("cache hits read %d prices, want none", *reads)
+	}
+	if c.Len() != 2 {
+		t.Errorf("cache holds %d values, want 2", c.Len())
+	}
+}
+
+func TestIndicatorCacheNil(t *testing.T) {
+	d, reads := countedData("A", 1, 2, 3)
+	var c *IndicatorCache
+	for i := 0; i < 2; i++ {
+		if sma, ok := c.SMA(d, "A", 3); !ok || sma != 2 {
+			t.Fatalf("SMA is %v, %v, want 2", sma, ok)
+		}
+	}
+	if *reads != 6 {
+		t.Errorf("nil cache read %d prices, want 6 of two computations", *reads)
+	}
+}
+
+func BenchmarkIndicatorCacheHit(b *testing.B) {
+	closes := make([]float64, 1000)
+	for i := range closes {
+		closes[i] = float64(i + 1)
+	}
+	d, _ := countedData("A", closes...)
+	c := NewIndicatorCache()
+	c.SMA(d, "A", 500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.SMA(d, "A", 500)
+	}
+}
@@ -0,0 +1,392 @@
+package backtest
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// ResultJSON is the stable schema of the exported results of a backtest
+type ResultJSON struct {
+	Metrics      MetricsJSON       `json:"metrics"`
+	Equity       []EquityJSON      `json:"equity"`
+	Transactions []TransactionJSON `json:"transactions"`
+	ReturnsTable ReturnsTable      `json:"returns_table"`
+	Symbols      []SymbolJSON      `json:"symbols"`
+	Strategies   []StrategyJSON    `json:"strategies"`
+	Drawdowns    []DrawdownJSON    `json:"drawdowns"`
+	Annotations  []AnnotationJSON  `json:"annotations"`
+}
+
+// MetricsJSON holds the result metrics, values which can't be calculated are null
+type MetricsJSON struct {
+	InitialCash         float64    `json:"initial_cash"`
+	FinalEquity         *float64   `json:"final_equity"`
+	TotalReturn         *float64   `json:"total_return"`
+	CAGR                *float64   `json:"cagr"`
+	MaxDrawdown         *float64   `json:"max_drawdown"`
+	MaxDrawdownTime     time.Time  `json:"max_drawdown_time"`
+	MaxDrawdownDuration float64    `json:"max_drawdown_duration_seconds"`
+	TimeInMarket        float64    `json:"time_in_market"`
+	AverageExposure     float64    `json:"average_exposure"`
+	UlcerIndex          float64    `json:"ulcer_index"`
+	PainIndex           float64    `json:"pain_index"`
+	RecoveryFactor      *float64   `json:"recovery_factor"`
+	SharpeRatio         *float64   `json:"sharpe_ratio"`
+	SortinoRatio        *float64   `json:"sortino_ratio"`
+	Volatility          *float64   `json:"volatility"`
+	DownsideDeviation   *float64   `json:"downside_deviation"`
+	ValueAtRisk95       *float64   `json:"var_95"`
+	ConditionalVaR95    *float64   `json:"cvar_95"`
+	Skewness            *float64   `json:"skewness"`
+	Kurtosis            *float64   `json:"excess_kurtosis"`
+	BestReturn          *float64   `json:"best_return"`
+	WorstReturn         *float64   `json:"worst_return"`
+	Beta                *float64   `json:"beta"`
+	Alpha               *float64   `json:"alpha"`
+	TrackingError       *float64   `json:"tracking_error"`
+	InformationRatio    *float64   `json:"information_ratio"`
+	Transactions        int        `json:"transactions"`
+	Events              int        `json:"events"`
+	Trades              TradesJSON `json:"trades"`
+	Costs               CostsJSON  `json:"costs"`
+}
+
+// CostsJSON holds the traded value and trading costs
+type CostsJSON struct {
+	TradedValue float64 `json:"traded_value"`
+	Turnover    float64 `json:"turnover"`
+	Commission  float64 `json:"commission"`
+	ExchangeFee float64 `json:"exchange_fee"`
+	Cost        float64 `json:"cost"`
+	Slippage    float64 `json:"slippage"`
+	NetProfit   float64 `json:"net_profit"`
+	GrossProfit float64 `json:"gross_profit"`
+	CostShare   float64 `json:"cost_share"`
+}
+
+// TradesJSON holds the statistics of the round-trip trades
+type TradesJSON struct {
+	Count        int      `json:"count"`
+	Wins         int      `json:"wins"`
+	Losses       int      `json:"losses"`
+	WinRate      float64  `json:"win_rate"`
+	ProfitFactor *float64 `json:"profit_factor"`
+	AverageWin   float64  `json:"average_win"`
+	AverageLoss  float64  `json:"average_loss"`
+	LargestWin   float64  `json:"largest_win"`
+	LargestLoss  float64  `json:"largest_loss"`
+	Expectancy   float64  `json:"expectancy"`
+	Kelly        float64  `json:"kelly"`
+	OptimalF     float64  `json:"optimal_f"`
+
+	AverageDuration    float64 `json:"average_duration_seconds"`
+	MedianDuration     float64 `json:"median_duration_seconds"`
+	MaxDuration        float64 `json:"max_duration_seconds"`
+	AverageTimeBetween float64 `json:"average_time_between_seconds"`
+}
+
+// SymbolJSON holds the performance of a single symbol
+type SymbolJSON struct {
+	Symbol      string  `json:"symbol"`
+	PnL         float64 `json:"pnl"`
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`
+	WinRate     float64 `json:"win_rate"`
+	TradedValue float64 `json:"traded_value"`
+	Cost        float64 `json:"cost"`
+}
+
+// StrategyJSON holds the performance attributed to a strategy tag
+type StrategyJSON struct {
+	Tag     string   `json:"tag"`
+	PnL     float64  `json:"pnl"`
+	Return  float64  `json:"return"`
+	Sharpe  *float64 `json:"sharpe_ratio"`
+	Fills   int      `json:"fills"`
+	Fees    float64  `json:"fees"`
+	Trades  int      `json:"trades"`
+	WinRate float64  `json:"win_rate"`
+}
+
+// DrawdownJSON is a drawdown episode, the recovery is null if not recovered
+type DrawdownJSON struct {
+	Start            time.Time  `json:"start"`
+	Trough           time.Time  `json:"trough"`
+	Recovery         *time.Time `json:"recovery"`
+	Depth            float64    `json:"depth"`
+	Duration         float64    `json:"duration_seconds"`
+	RecoveryDuration float64    `json:"recovery_duration_seconds"`
+}
+
+// AnnotationJSON is a user annotation of a point in time
+type AnnotationJSON struct {
+	Time  time.Time `json:"time"`
+	Label string    `json:"label"`
+}
+
+// EquityJSON is a single point of the equity series
+type EquityJSON struct {
+	Time       time.Time `json:"time"`
+	Equity     float64   `json:"equity"`
+	Return     float64   `json:"return"`
+	Drawdown   float64   `json:"drawdown"`
+	BuyAndHold float64   `json:"buy_and_hold"`
+	Exposure   float64   `json:"exposure"`
+	Benchmark  *float64  `json:"benchmark,omitempty"`
+}
+
+// TransactionJSON is a single fill of the transaction list
+type TransactionJSON struct {
+	Time        time.Time `json:"time"`
+	Symbol      string    `json:"symbol"`
+	Direction   string    `json:"direction"`
+	Qty         float64   `json:"qty"`
+	Price       float64   `json:"price"`
+	Commission  float64   `json:"commission"`
+	ExchangeFee float64   `json:"exchange_fee"`
+	Cost        float64   `json:"cost"`
+	Value       float64   `json:"value"`
+	NetValue    float64   `json:"net_value"`
+	Tags        []string  `json:"tags,omitempty"`
+}
+
+// JSON returns the complete results of the backtest in the stable export schema
+func (s *Statistic) JSON() ResultJSON {
+	result := ResultJSON{
+		Metrics: MetricsJSON{
+			InitialCash:         s.initialCash,
+			MaxDrawdown:         jsonFloat(s.MaxDrawdown()),
+			MaxDrawdownTime:     s.MaxDrawdownTime(),
+			MaxDrawdownDuration: s.MaxDrawdownDuration().Seconds(),
+			TimeInMarket:        s.TimeInMarket(),
+			AverageExposure:     s.AverageExposure(),
+			UlcerIndex:          s.UlcerIndex(),
+			PainIndex:           s.PainIndex(),
+			SharpeRatio:         jsonFloat(s.SharpRatio(0)),
+			Transactions:        len(s.transactionHistory),
+			Events:              len(s.eventHistory),
+		},
+		Equity:       []EquityJSON{},
+		Transactions: []TransactionJSON{},
+		ReturnsTable: s.ReturnsTable(),
+		Symbols:      []SymbolJSON{},
+		Strategies:   []StrategyJSON{},
+		Drawdowns:    []DrawdownJSON{},
+		Annotations:  []AnnotationJSON{},
+	}
+	trades := s.TradeStatistics()
+	result.Metrics.Trades = TradesJSON{
+		Count:        trades.Trades,
+		Wins:         trades.Wins,
+		Losses:       trades.Losses,
+		WinRate:      trades.WinRate,
+		ProfitFactor: jsonFloat(trades.ProfitFactor),
+		AverageWin:   trades.AverageWin,
+		AverageLoss:  trades.AverageLoss,
+		LargestWin:   trades.LargestWin,
+		LargestLoss:  trades.LargestLoss,
+		Expectancy:   trades.Expectancy,
+		Kelly:        trades.Kelly,
+		OptimalF:     trades.OptimalF,
+
+		AverageDuration:    trades.AverageDuration.Seconds(),
+		MedianDuration:     trades.MedianDuration.Seconds(),
+		MaxDuration:        trades.MaxDuration.Seconds(),
+		AverageTimeBetween: trades.AverageTimeBetween.Seconds(),
+	}
+	costs := s.Costs()
+	result.Metrics.Costs = CostsJSON{
+		TradedValue: costs.TradedValue,
+		Turnover:    costs.Turnover,
+		Commission:  costs.Commission,
+		ExchangeFee: costs.ExchangeFee,
+		Cost:        costs.Cost,
+		Slippage:    costs.Slippage,
+		NetProfit:   costs.NetProfit,
+		GrossProfit: costs.GrossProfit,
+		CostShare:   costs.CostShare,
+	}
+	if last, ok := s.lastEquityPoint(); ok {
+		result.Metrics.FinalEquity = jsonFloat(last.equity)
+	}
+	if total, err := s.TotalEquityReturn(); err == nil {
+		result.Metrics.TotalReturn = jsonFloat(total)
+	}
+	if cagr, err := s.CompoundAnnualGrowthRate(); err == nil {
+		result.Metrics.CAGR = jsonFloat(cagr)
+	}
+	if recovery, err := s.RecoveryFactor(); err == nil {
+		result.Metrics.RecoveryFactor = jsonFloat(recovery)
+	}
+	if sortino, err := s.SortinoRatioMAR(0, 0); err == nil {
+		result.Metrics.SortinoRatio = jsonFloat(sortino)
+	}
+	if vol, err := s.Volatility(); err == nil {
+		result.Metrics.Volatility = jsonFloat(vol)
+	}
+	if downside, err := s.DownsideDeviation(0); err == nil {
+		result.Metrics.DownsideDeviation = jsonFloat(downside)
+	}
+	if v, err := s.ValueAtRisk(0.95); err == nil {
+		result.Metrics.ValueAtRisk95 = jsonFloat(v)
+	}
+	if cvar, err := s.ConditionalValueAtRisk(0.95); err == nil {
+		result.Metrics.ConditionalVaR95 = jsonFloat(cvar)
+	}
+	if skew, err := s.Skewness(); err == nil {
+		result.Metrics.Skewness = jsonFloat(skew)
+	}
+	if kurtosis, err := s.Kurtosis(); err == nil {
+		result.Metrics.Kurtosis = jsonFloat(kurtosis)
+	}
+	if best, err := s.BestReturn(); err == nil {
+		result.Metrics.BestReturn = jsonFloat(best)
+	}
+	if worst, err := s.WorstReturn(); err == nil {
+		result.Metrics.WorstReturn = jsonFloat(worst)
+	}
+	if beta, err := s.Beta(); err == nil {
+		result.Metrics.Beta = jsonFloat(beta)
+	}
+	if alpha, err := s.Alpha(0); err == nil {
+		result.Metrics.Alpha = jsonFloat(alpha)
+	}
+	if trackingError, err := s.TrackingError(); err == nil {
+		result.Metrics.TrackingError = jsonFloat(trackingError)
+	}
+	if ir, err := s.InformationRatio(); err == nil {
+		result.Metrics.InformationRatio = jsonFloat(ir)
+	}
+
+	for _, e := range s.equity {
+		point := EquityJSON{
+			Time:       e.timestamp,
+			Equity:     e.equity,
+			Return:     e.equityReturn,
+			Drawdown:   e.drawdown,
+			BuyAndHold: e.buyAndHoldValue,
+			Exposure:   e.exposure,
+		}
+		if e.benchmarkValue != 0 {
+			point.Benchmark = jsonFloat(e.benchmarkValue)
+		}
+		result.Equity = append(result.Equity, point)
+	}
+
+	for _, sym := range s.SymbolStatistics() {
+		result.Symbols = append(result.Symbols, SymbolJSON{
+			Symbol:      sym.Symbol,
+			PnL:         sym.PnL,
+			Trades:      sym.Trades,
+			Wins:        sym.Wins,
+			WinRate:     sym.WinRate,
+			TradedValue: sym.TradedValue,
+			Cost:        sym.Cost,
+		})
+	}
+
+	for _, st := range s.StrategyStatistics() {
+		result.Strategies = append(result.Strategies, StrategyJSON{
+			Tag:     st.Tag,
+			PnL:     st.PnL,
+			Return:  st.Return,
+			Sharpe:  jsonFloat(st.Sharpe),
+			Fills:   st.Fills,
+			Fees:    st.Fees,
+			Trades:  st.Trades,
+			WinRate: st.WinRate,
+		})
+	}
+
+	for _, d := range s.DrawdownEpisodes(0) {
+		episode := DrawdownJSON{
+			Start:            d.Start,
+			Trough:           d.Trough,
+			Depth:            d.Depth,
+			Duration:         d.Duration.Seconds(),
+			RecoveryDuration: d.RecoveryDuration().Seconds(),
+		}
+		if d.Recovered {
+			recovery := d.Recovery
+			episode.Recovery = &recovery
+		}
+		result.Drawdowns = append(result.Drawdowns, episode)
+	}
+
+	for _, a := range s.annotations {
+		result.Annotations = append(result.Annotations, AnnotationJSON{Time: a.Time, Label: a.Label})
+	}
+
+	for _, f := range s.transactionHistory {
+		result.Transactions = append(result.Transactions, TransactionJSON{
+			Time:        f.GetTime(),
+			Symbol:      f.GetSymbol(),
+			Direction:   f.GetDirection(),
+			Qty:         f.GetQty(),
+			Price:       f.GetPrice(),
+			Commission:  f.GetCommission(),
+			ExchangeFee: f.GetExchangeFee(),
+			Cost:        f.GetCost(),
+			Value:       f.Value(),
+			NetValue:    f.NetValue(),
+			Tags:        f.GetTags(),
+		})
+	}
+
+	return result
+}
+
+// StatisticFromJSON restores a statistic from exported results, e.g. of a stored run,
+// to write its reports again. The equity series, transactions and annotations are
+// restored, the events and the strategy attribution are not.
+func StatisticFromJSON(r ResultJSON) *Statistic {
+	s := &Statistic{initialCash: r.Metrics.InitialCash}
+
+	points := make([]EquityPoint, 0, len(r.Equity))
+	for _, e := range r.Equity {
+		p := EquityPoint{
+			Timestamp:  e.Time,
+			Equity:     e.Equity,
+			Return:     e.Return,
+			Drawdown:   e.Drawdown,
+			BuyAndHold: e.BuyAndHold,
+			Exposure:   e.Exposure,
+		}
+		if e.Benchmark != nil {
+			p.Benchmark = *e.Benchmark
+		}
+		points = append(points, p)
+	}
+	s.setEquity(points)
+
+	for _, t := range r.Transactions {
+		s.transactionHistory = append(s.transactionHistory, &Fill{
+			Event:       Event{Time: t.Time, Symbol: t.Symbol},
+			Direction:   t.Direction,
+			Qty:         t.Qty,
+			Price:       t.Price,
+			Commission:  t.Commission,
+			ExchangeFee: t.ExchangeFee,
+			Cost:        t.Cost,
+			Tags:        t.Tags,
+		})
+	}
+	for _, a := range r.Annotations {
+		s.annotations = append(s.annotations, Annotation{Time: a.Time, Label: a.Label})
+	}
+	return s
+}
+
+// MarshalJSON encodes the results of the backtest, see JSON for the schema
+func (s *Statistic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.JSON())
+}
+
+// jsonFloat returns nil for values which can't be encoded as JSON number
+func jsonFloat(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,210 @@
+package backtest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KillSwitch halts a live test immediately and reports its health for monitoring. Set it
+// on the test and serve it with net/http, the health is served on the health path and the
+// kill switch on the kill path below it. Killing a test trading at a broker cancels its open
+// orders and closes its positions, a test on the simulated exchange just stops. The kill path
+// requires the token as bearer token and is disabled without one.
+type KillSwitch struct {
+	MaxStaleness time.Duration // time without data events until the feed is stale, 0 for never
+	Token        string        // bearer token of the kill path, killing over HTTP is disabled if empty
+
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc // cancels the context of the run
+	exchange ExecutionHandler
+	feed     Subscriber
+	started  time.Time
+	events   int
+	last     time.Time // wall time of the last event
+	lastData time.Time // wall time of the last data event
+	dataTime time.Time // time of the last data event
+	lastFill time.Time // wall time of the last fill
+	halted   string    // reason of the kill
+}
+
+// NewKillSwitch creates a kill switch reporting the feed as stale after maxStaleness
+// without data events
+func NewKillSwitch(maxStaleness time.Duration) *KillSwitch {
+	return &KillSwitch{MaxStaleness: maxStaleness}
+}
+
+// Health is the state of a live test
+type Health struct {
+	Status       string    `json:"status"` // ok, stale, failed, halted or stopped
+	Running      bool      `json:"running"`
+	Started      time.Time `json:"started"`
+	Events       int       `json:"events"`
+	LastEvent    time.Time `json:"last_event"`     // wall time of the last event
+	LastData     time.Time `json:"last_data"`      // wall time of the last data event
+	LastDataTime time.Time `json:"last_data_time"` // time of the last data event
+	LastFill     time.Time `json:"last_fill"`      // wall time of the last fill
+	Staleness    float64   `json:"staleness"`      // seconds since the last data event
+	FeedError    string    `json:"feed_error,omitempty"`
+	Halted       string    `json:"halted,omitempty"` // reason of the kill
+}
+
+// Kill halts the running test: new orders are rejected, the open orders at the broker are
+// cancelled, the positions at the broker are closed and the run ends with an
+// EarlyStopError. The error of the orders is returned, the test is stopped regardless.
+func (k *KillSwitch) Kill(reason string) error {
+	k.mu.Lock()
+	if !k.running {
+		k.mu.Unlock()
+		return errors.New("no run in progress")
+	}
+	if k.halted != "" {
+		k.mu.Unlock()
+		return nil
+	}
+	if reason == "" {
+		reason = "kill switch"
+	}
+	k.halted = reason
+	cancel, exchange := k.cancel, k.exchange
+	k.mu.Unlock()
+
+	var err error
+	if e, ok := exchange.(*BrokerExchange); ok {
+		ctx, done := context.WithTimeout(context.Background(), e.timeout)
+		err = e.Halt(ctx)
+		done()
+	}
+	cancel()
+	return err
+}
+
+// Halted returns the reason of the kill of the last run, empty if it was not killed
+func (k *KillSwitch) Halted() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.halted
+}
+
+// Health returns the state of the test
+func (k *KillSwitch) Health() Health {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	h := Health{
+		Status:       "ok",
+		Running:      k.running,
+		Started:      k.started,
+		Events:       k.events,
+		LastEvent:    k.last,
+		LastData:     k.lastData,
+		LastDataTime: k.dataTime,
+		LastFill:     k.lastFill,
+		Halted:       k.halted,
+	}
+	since := k.lastData
+	if since.IsZero() {
+		since = k.started
+	}
+	if k.running && !since.IsZero() {
+		h.Staleness = time.Since(since).Seconds()
+	}
+	if k.feed != nil && k.feed.Err() != nil {
+		h.FeedError = k.feed.Err().Error()
+	}
+
+	switch {
+	case k.halted != "":
+		h.Status = "halted"
+	case !k.running:
+		h.Status = "stopped"
+	case h.FeedError != "":
+		h.Status = "failed"
+	case k.MaxStaleness > 0 && h.Staleness > k.MaxStaleness.Seconds():
+		h.Status = "stale"
+	}
+	return h
+}
+
+// ServeHTTP returns the health as JSON at the health path, with status 503 unless it is ok,
+// and kills the test on POST requests to the kill path with the token and an optional reason
+func (k *KillSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/kill"):
+		if r.Method != http.MethodPost {
+			http.Error(w, "kill requires POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if k.Token == "" {
+			http.Error(w, "kill switch without token", http.StatusForbidden)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(k.Token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if err := k.Kill(r.FormValue("reason")); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case strings.HasSuffix(r.URL.Path, "/health"):
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	h := k.Health()
+	w.Header().Set("Content-Type", "application/json")
+	if h.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(h)
+}
+
+// start marks the begin of a run, which is stopped with the cancel function
+func (k *KillSwitch) start(cancel context.CancelFunc, exchange ExecutionHandler, feed Subscriber) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.running, k.cancel, k.exchange, k.feed = true, cancel, exchange, feed
+	k.started = time.Now()
+	k.events, k.halted = 0, ""
+	k.last, k.lastData, k.dataTime, k.lastFill = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+}
+
+// stop marks the end of a run
+func (k *KillSwitch) stop() {
+	k.mu.Lock()
+	k.running = false
+	k.mu.Unlock()
+}
+
+// event records a processed event
+func (k *KillSwitch) event(e EventHandler) {
+	now := time.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.events++
+	k.last = now
+	switch e.(type) {
+	case DataEventHandler:
+		k.lastData, k.dataTime = now, e.GetTime()
+	case FillEvent:
+		k.lastFill = now
+	}
+}
+
+// SetKillSwitch sets a kill switch, which can halt the test while it runs
+func (t *Test) SetKillSwitch(k *KillSwitch) {
+	t.killSwitch = k
+}
+
+// WithKillSwitch sets a kill switch, which can halt the test while it runs
+func WithKillSwitch(k *KillSwitch) Option {
+	return func(t *Test) { t.SetKillSwitch(k) }
+}
@@ -0,0 +1,131 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Feed streams live data events, e.g. the bars or ticks of an exchange websocket
+type Feed interface {
+	// Stream sends the data events of the symbols until the context is done or the feed
+	// fails, the error of a failed feed is returned
+	Stream(ctx context.Context, symbols []string, events chan<- DataEventHandler) error
+}
+
+// Subscriber is the optional interface of data handlers streaming live data. The test
+// subscribes to the symbols before the first event, the stream ends when the context of
+// the run is done and Err returns why a stream ended early.
+type Subscriber interface {
+	Subscribe(ctx context.Context, symbols []string) error
+	Err() error
+}
+
+// LiveData is a data handler for paper and live trading, it streams the events of a feed
+// as they arrive. The events of the history are streamed first, e.g. to warm up the
+// indicators of the strategy. Next blocks until the next event arrives, so timers only fire
+// and orders only fill on data events.
+type LiveData struct {
+	Data
+	feed    Feed
+	history []DataEventHandler
+	events  chan DataEventHandler
+
+	mu  sync.Mutex
+	err error
+}
+
+// liveBuffer is the number of feed events buffered while the test is busy
+const liveBuffer = 1024
+
+// NewLiveData creates a data handler streaming the events of the feed after the history
+func NewLiveData(feed Feed, history ...DataEventHandler) *LiveData {
+	d := &LiveData{feed: feed, history: history}
+	d.SetStream(history)
+	d.SortStream()
+	return d
+}
+
+// SetLogger implements the LoggerSetter interface, the logger is also set on the feed
+func (d *LiveData) SetLogger(l Logger) {
+	d.Data.SetLogger(l)
+	if s, ok := d.feed.(LoggerSetter); ok {
+		s.SetLogger(l)
+	}
+}
+
+// Load implements the DataLoader interface, live data is streamed and can't be loaded
+func (d *LiveData) Load(string, string, string, string) error {
+	return errors.New("live data is streamed from the feed and can't be loaded")
+}
+
+// Subscribe implements the Subscriber interface and starts streaming the feed
+func (d *LiveData) Subscribe(ctx context.Context, symbols []string) error {
+	if d.feed == nil {
+		return errors.New("live data without feed")
+	}
+	events := make(chan DataEventHandler, liveBuffer)
+	d.events = events
+	d.setErr(nil)
+	go func() {
+		defer close(events)
+		err := d.feed.Stream(ctx, symbols, events)
+		if err != nil && ctx.Err() == nil {
+			d.setErr(err)
+		}
+	}()
+	return nil
+}
+
+// Err implements the Subscriber interface and returns the error of a failed feed
+func (d *LiveData) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// setErr sets the error of the feed
+func (d *LiveData) setErr(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.err = err
+}
+
+// Next returns the next event of the history, then waits for the next event of the feed.
+// It returns false when the feed has ended.
+func (d *LiveData) Next() (DataEventHandler, bool) {
+	if e, ok := d.Data.Next(); ok {
+		return e, true
+	}
+	if d.events == nil {
+		return nil, false
+	}
+	e, ok := <-d.events
+	if !ok {
+		return nil, false
+	}
+	d.streamHistory = append(d.streamHistory, e)
+	d.updateLatest(e)
+	d.updateList(e)
+	return e, true
+}
+
+// Reset implements the Reseter interface, the history is streamed again on the next run
+func (d *LiveData) Reset() {
+	d.Data.Reset()
+	d.SetStream(d.history)
+	d.streamHistory = nil
+	d.events = nil
+}
+
+// WithLiveData trades the test forward on the live events of the feed after the history,
+// on the wall clock. With the simulated exchange this is paper trading with the same
+// statistics as a backtest, with WithBroker it is live trading. The history is the warmup
+// of the test, set WithWarmup after this option to change it.
+func WithLiveData(feed Feed, history ...DataEventHandler) Option {
+	return func(t *Test) {
+		t.SetData(NewLiveData(feed, history...))
+		t.SetClock(RealClock{})
+		t.SetWarmup(len(history))
+	}
+}
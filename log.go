@@ -0,0 +1,181 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger logs messages at levels with alternating key value pairs, a *slog.Logger
+// implements the interface
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LoggerSetter is the interface for components logging with the logger of the test
+type LoggerSetter interface {
+	SetLogger(Logger)
+}
+
+// LogLevel is the minimum level of the messages written by a logger
+type LogLevel int
+
+// Log levels
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name of the level
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// textLogger writes messages as lines of text
+type textLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level LogLevel
+}
+
+// NewLogger returns a logger writing the messages from the level on as lines of text,
+// e.g. time=2020-01-02T15:04:05Z level=WARN msg="order rejected" symbol=AAPL
+func NewLogger(w io.Writer, level LogLevel) Logger {
+	return &textLogger{w: w, level: level}
+}
+
+func (l *textLogger) Debug(msg string, args ...interface{}) { l.log(LevelDebug, msg, args) }
+func (l *textLogger) Info(msg string, args ...interface{})  { l.log(LevelInfo, msg, args) }
+func (l *textLogger) Warn(msg string, args ...interface{})  { l.log(LevelWarn, msg, args) }
+func (l *textLogger) Error(msg string, args ...interface{}) { l.log(LevelError, msg, args) }
+
+// log writes a message line if the level is enabled
+func (l *textLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time=%s level=%s msg=%s", time.Now().UTC().Format(time.RFC3339), level, logValue(msg))
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%s", logValue(args[i]))
+			break
+		}
+		fmt.Fprintf(&b, " %v=%s", args[i], logValue(args[i+1]))
+	}
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+// logValue formats a value, quoted if it contains spaces
+func logValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if t, ok := v.(time.Time); ok {
+		s = t.Format(time.RFC3339)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// componentLogger adds the component to the messages of a logger
+type componentLogger struct {
+	logger    Logger
+	component string
+}
+
+// WithComponent returns a logger adding the component name to all messages
+func WithComponent(l Logger, component string) Logger {
+	return componentLogger{logger: l, component: component}
+}
+
+func (l componentLogger) Debug(msg string, args ...interface{}) {
+	l.logger.Debug(msg, l.args(args)...)
+}
+
+func (l componentLogger) Info(msg string, args ...interface{}) {
+	l.logger.Info(msg, l.args(args)...)
+}
+
+func (l componentLogger) Warn(msg string, args ...interface{}) {
+	l.logger.Warn(msg, l.args(args)...)
+}
+
+func (l componentLogger) Error(msg string, args ...interface{}) {
+	l.logger.Error(msg, l.args(args)...)
+}
+
+// args prepends the component to the key value pairs
+func (l componentLogger) args(args []interface{}) []interface{} {
+	return append([]interface{}{"component", l.component}, args...)
+}
+
+// nopLogger discards all messages
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// SetLogger sets the logger of the test, which is also passed to the data, strategy,
+// portfolio and exchange implementing the LoggerSetter interface, scoped by component
+func (t *Test) SetLogger(l Logger) {
+	t.logger = l
+}
+
+// log returns the logger of the test, which discards the messages if none is set
+func (t *Test) log() Logger {
+	if t.logger == nil {
+		return nopLogger{}
+	}
+	return WithComponent(t.logger, "test")
+}
+
+// setLoggers passes the logger to the components
+func (t *Test) setLoggers() {
+	if t.logger == nil {
+		return
+	}
+	for _, c := range []struct {
+		name      string
+		component interface{}
+	}{
+		{"data", t.data},
+		{"strategy", t.strategy},
+		{"portfolio", t.portfolio},
+		{"exchange", t.exchange},
+	} {
+		if s, ok := c.component.(LoggerSetter); ok {
+			s.SetLogger(WithComponent(t.logger, c.name))
+		}
+	}
+}
+
+// logger returns the logger or one discarding the messages if it is nil
+func logger(l Logger) Logger {
+	if l == nil {
+		return nopLogger{}
+	}
+	return l
+}
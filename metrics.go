@@ -0,0 +1,162 @@
+package backtest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics exposes the progress of a running test in the Prometheus text format,
+// e.g. to monitor long running paper trading or optimization jobs. Set it on the
+// test and serve it with net/http on a metrics path.
+type Metrics struct {
+	mu        sync.Mutex
+	start     time.Time
+	events    map[string]float64
+	equity    float64
+	cash      float64
+	high      float64
+	drawdown  float64
+	positions int
+	running   bool
+}
+
+// NewMetrics creates a metrics exporter
+func NewMetrics() *Metrics {
+	return &Metrics{events: make(map[string]float64)}
+}
+
+// Start marks the begin of a run
+func (m *Metrics) Start() {
+	m.mu.Lock()
+	m.start = time.Now()
+	m.running = true
+	m.mu.Unlock()
+}
+
+// Stop marks the end of a run
+func (m *Metrics) Stop() {
+	m.mu.Lock()
+	m.running = false
+	m.mu.Unlock()
+}
+
+// Event counts a processed event by its type
+func (m *Metrics) Event(e EventHandler) {
+	m.mu.Lock()
+	if m.events == nil {
+		m.events = make(map[string]float64)
+	}
+	m.events[eventType(e)]++
+	m.mu.Unlock()
+}
+
+// Update sets the equity, drawdown and open positions of the portfolio
+func (m *Metrics) Update(p PortfolioHandler) {
+	equity := p.Value()
+	var open int
+	for _, pos := range p.Positions() {
+		if pos.Qty != 0 {
+			open++
+		}
+	}
+
+	m.mu.Lock()
+	m.equity = equity
+	m.cash = p.Cash()
+	m.positions = open
+	if equity > m.high {
+		m.high = equity
+	}
+	if m.high > 0 {
+		m.drawdown = (equity - m.high) / m.high
+	}
+	m.mu.Unlock()
+}
+
+// Reset clears the metrics, e.g. before the test is run again
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	m.events = make(map[string]float64)
+	m.equity, m.cash, m.high, m.drawdown = 0, 0, 0, 0
+	m.positions = 0
+	m.running = false
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	types := make([]string, 0, len(m.events))
+	var total float64
+	for t, n := range m.events {
+		types = append(types, t)
+		total += n
+	}
+	sort.Strings(types)
+
+	fmt.Fprintln(w, "# HELP backtest_events_total Number of processed events by type.")
+	fmt.Fprintln(w, "# TYPE backtest_events_total counter")
+	for _, t := range types {
+		fmt.Fprintf(w, "backtest_events_total{type=%q} %g\n", t, m.events[t])
+	}
+
+	var rate float64
+	if m.running {
+		if elapsed := time.Since(m.start).Seconds(); elapsed > 0 {
+			rate = total / elapsed
+		}
+	}
+	var running float64
+	if m.running {
+		running = 1
+	}
+
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"backtest_events_per_second", "Processed events per second since the start of the run.", rate},
+		{"backtest_equity", "Current value of the portfolio.", m.equity},
+		{"backtest_cash", "Current cash of the portfolio.", m.cash},
+		{"backtest_drawdown", "Current drop from the equity high as negative fraction.", m.drawdown},
+		{"backtest_open_positions", "Number of open positions.", float64(m.positions)},
+		{"backtest_running", "Whether a run is in progress.", running},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		fmt.Fprintf(w, "%s %g\n", g.name, g.value)
+	}
+}
+
+// eventType returns the metric label of an event
+func eventType(e EventHandler) string {
+	switch e.(type) {
+	case DataEventHandler:
+		return "data"
+	case *StrategyError:
+		return "error"
+	case *Discrepancy:
+		return "discrepancy"
+	case CancelOrderEvent:
+		return "cancel"
+	case ModifyOrderEvent:
+		return "modify"
+	case BasketSignalEvent:
+		return "basket"
+	case SignalEvent:
+		return "signal"
+	case OrderEvent:
+		return "order"
+	case FillEvent:
+		return "fill"
+	}
+	return "other"
+}
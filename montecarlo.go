@@ -0,0 +1,97 @@
+package backtest
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// MonteCarlo resamples a sequence of trade PnL to measure how sensitive the result
+// is to the order of the trades
+type MonteCarlo struct {
+	Runs    int        // number of resampled sequences
+	Replace bool       // draw trades with replacement instead of shuffling the sequence
+	Rand    *rand.Rand // random number generator, a randomly seeded one if nil
+}
+
+// MonteCarloResult holds the distributions of the resampled sequences, sorted ascending
+type MonteCarloResult struct {
+	EndingEquity []float64
+	MaxDrawdown  []float64 // largest drop from a previous high in percent as negative value
+}
+
+// Run resamples the PnL sequence starting with the initial equity
+func (m MonteCarlo) Run(initial float64, pnl []float64) (MonteCarloResult, error) {
+	var result MonteCarloResult
+	if m.Runs < 1 {
+		return result, errors.New("monte carlo needs at least one run")
+	}
+	if len(pnl) == 0 {
+		return result, errors.New("monte carlo needs at least one trade")
+	}
+
+	r := m.Rand
+	if r == nil {
+		r = newRand()
+	}
+
+	sequence := make([]float64, len(pnl))
+	for run := 0; run < m.Runs; run++ {
+		if m.Replace {
+			for i := range sequence {
+				sequence[i] = pnl[r.Intn(len(pnl))]
+			}
+		} else {
+			copy(sequence, pnl)
+			r.Shuffle(len(sequence), func(i, j int) {
+				sequence[i], sequence[j] = sequence[j], sequence[i]
+			})
+		}
+
+		equity, high, drawdown := initial, initial, 0.0
+		for _, p := range sequence {
+			equity += p
+			high = math.Max(high, equity)
+			if high > 0 {
+				drawdown = math.Min(drawdown, (equity-high)/high)
+			}
+		}
+		result.EndingEquity = append(result.EndingEquity, equity)
+		result.MaxDrawdown = append(result.MaxDrawdown, drawdown)
+	}
+
+	sort.Float64s(result.EndingEquity)
+	sort.Float64s(result.MaxDrawdown)
+	return result, nil
+}
+
+// EndingEquityQuantile returns the ending equity below which the fraction p of the runs end
+func (r MonteCarloResult) EndingEquityQuantile(p float64) float64 {
+	return stat.Quantile(p, stat.Empirical, r.EndingEquity, nil)
+}
+
+// MaxDrawdownQuantile returns the max drawdown which the fraction p of the runs exceed
+func (r MonteCarloResult) MaxDrawdownQuantile(p float64) float64 {
+	return stat.Quantile(p, stat.Empirical, r.MaxDrawdown, nil)
+}
+
+// ProbabilityOfLoss returns the fraction of runs ending below the initial equity
+func (r MonteCarloResult) ProbabilityOfLoss(initial float64) float64 {
+	if len(r.EndingEquity) == 0 {
+		return 0
+	}
+	losses := sort.SearchFloat64s(r.EndingEquity, initial)
+	return float64(losses) / float64(len(r.EndingEquity))
+}
+
+// MonteCarlo resamples the PnL of the round-trip trades starting with the initial cash
+func (s Statistic) MonteCarlo(m MonteCarlo) (MonteCarloResult, error) {
+	var pnl []float64
+	for _, t := range s.Trades() {
+		pnl = append(pnl, t.PnL)
+	}
+	return m.Run(s.initialCash, pnl)
+}
@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Notifier sends notifications about a running test, e.g. to a chat, see the notify
+// package for Slack and Telegram
+type Notifier interface {
+	Notify(context.Context, Notification) error
+}
+
+// NotificationKind is the event a notification is sent for
+type NotificationKind string
+
+const (
+	// NotifyFill is sent for every fill
+	NotifyFill NotificationKind = "fill"
+	// NotifyDrawdown is sent when the drawdown exceeds its limit
+	NotifyDrawdown NotificationKind = "drawdown"
+	// NotifyDone is sent when the run ends
+	NotifyDone NotificationKind = "done"
+	// NotifyError is sent for rejected events and failed runs
+	NotifyError NotificationKind = "error"
+)
+
+// Notification is a message about a test
+type Notification struct {
+	Time  time.Time
+	Kind  NotificationKind
+	Title string // short summary, prefixed with the name of the notifications
+	Text  string
+}
+
+// Notifications selects the events a test sends notifications for
+type Notifications struct {
+	Name     string  // name of the test in the titles, e.g. the strategy and account
+	Fills    bool    // notify every fill
+	Drawdown float64 // notify when the drawdown reaches the fraction, e.g. 0.1, and again after a new high, 0 for never
+	Done     bool    // notify the end of the run with its results
+	Errors   bool    // notify rejected events and failed runs
+}
+
+const (
+	// notifyBuffer is the number of notifications waiting to be sent
+	notifyBuffer = 256
+	// notifyTimeout limits the sending of a notification and the wait for the
+	// notifications still queued at the end of a run
+	notifyTimeout = 30 * time.Second
+)
+
+// notifier sends the notifications of a test in the background, so a slow chat doesn't
+// hold up the test
+type notifier struct {
+	to     Notifier
+	on     Notifications
+	logger Logger
+	queue  chan Notification
+	done   chan struct{}
+
+	high    float64 // equity high of the drawdown
+	alerted bool    // the drawdown was notified since the last high
+}
+
+// SetNotifier sends notifications for the selected events of the test to the notifier
+func (t *Test) SetNotifier(n Notifier, on Notifications) {
+	t.notifier = &notifier{to: n, on: on}
+}
+
+// WithNotifier sends notifications for the selected events of the test to the notifier
+func WithNotifier(n Notifier, on Notifications) Option {
+	return func(t *Test) { t.SetNotifier(n, on) }
+}
+
+// start starts sending the notifications of a run
+func (n *notifier) start(l Logger) {
+	n.logger = l
+	n.high, n.alerted = 0, false
+	n.queue, n.done = make(chan Notification, notifyBuffer), make(chan struct{})
+	go func() {
+		defer close(n.done)
+		for msg := range n.queue {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			if err := n.to.Notify(ctx, msg); err != nil {
+				n.logger.Warn("notification not sent", "kind", msg.Kind, "error", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// stop waits until the queued notifications are sent, at most for the timeout
+func (n *notifier) stop() {
+	close(n.queue)
+	select {
+	case <-n.done:
+	case <-time.After(notifyTimeout):
+		n.logger.Warn("notifications not sent at the end of the run")
+	}
+}
+
+// send queues a notification, it is dropped if the queue is full
+func (n *notifier) send(kind NotificationKind, title, text string) {
+	if n.on.Name != "" {
+		title = n.on.Name + ": " + title
+	}
+	select {
+	case n.queue <- Notification{Time: time.Now(), Kind: kind, Title: title, Text: text}:
+	default:
+		n.logger.Warn("notification dropped, too many queued", "kind", kind, "title", title)
+	}
+}
+
+// fill notifies a fill
+func (n *notifier) fill(f FillEvent) {
+	if !n.on.Fills {
+		return
+	}
+	title := fmt.Sprintf("%s %v %s at %v", f.GetDirection(), f.GetQty(), f.GetSymbol(), f.GetPrice())
+	n.send(NotifyFill, title, fmt.Sprintf("filled at %s", f.GetTime().Format(time.RFC3339)))
+}
+
+// update checks the drawdown of the portfolio value
+func (n *notifier) update(d DataEventHandler, value float64) {
+	if n.on.Drawdown <= 0 {
+		return
+	}
+	if value > n.high {
+		n.high, n.alerted = value, false
+	}
+	if n.high <= 0 || n.alerted {
+		return
+	}
+	if dd := (n.high - value) / n.high; dd >= n.on.Drawdown {
+		n.alerted = true
+		n.send(NotifyDrawdown, fmt.Sprintf("drawdown of %.1f%%", dd*100),
+			fmt.Sprintf("value %.2f at %s, %.2f below the high of %.2f", value, d.GetTime().Format(time.RFC3339), n.high-value, n.high))
+	}
+}
+
+// warning notifies a rejected event
+func (n *notifier) warning(w Warning) {
+	if !n.on.Errors {
+		return
+	}
+	title := "event rejected"
+	if w.Symbol != "" {
+		title += " on " + w.Symbol
+	}
+	n.send(NotifyError, title, fmt.Sprintf("%s at %s", w.Message, w.Time.Format(time.RFC3339)))
+}
+
+// finish notifies the end of a run, stopped runs end normally while other errors fail it
+func (n *notifier) finish(r Results, err error) {
+	var partial *PartialResultError
+	var early *EarlyStopError
+	if err != nil && !errors.As(err, &partial) && !errors.As(err, &early) {
+		if n.on.Errors || n.on.Done {
+			n.send(NotifyError, "run failed", err.Error())
+		}
+		return
+	}
+	if !n.on.Done {
+		return
+	}
+	text := fmt.Sprintf("value %.2f, cash %.2f, %d open positions, %d warnings", r.Value, r.Cash, len(r.Positions), len(r.Warnings))
+	if err != nil {
+		text += "\n" + err.Error()
+	}
+	n.send(NotifyDone, "run finished", text)
+}
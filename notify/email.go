@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/optimize"
+)
+
+// Email sends the notifications and the results of runs and optimizations as emails over
+// an SMTP server
+//
+//	mail := notify.NewEmail("smtp.example.com:587", user, password, "bot@example.com", "me@example.com")
+//	results, err := test.Run()
+//	...
+//	err = mail.SendReport("Nightly run", results.Statistic.(*backtest.Statistic))
+type Email struct {
+	addr string // host:port of the SMTP server
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// NewEmail creates a sender of emails from the address to the recipients over the SMTP
+// server at addr, authenticated with the username and password if the username is set
+func NewEmail(addr, username, password, from string, to ...string) *Email {
+	e := &Email{addr: addr, from: from, to: to}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e
+}
+
+// Notify implements the backtest.Notifier interface and sends a notification as plain
+// text email. The SMTP client has no deadline, the context is only checked before sending.
+func (e *Email) Notify(ctx context.Context, n backtest.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return e.Send(n.Title, "text/plain; charset=utf-8", []byte(n.Text))
+}
+
+// SendReport sends the HTML report of a run as the body of an email
+func (e *Email) SendReport(subject string, s *backtest.Statistic) error {
+	var body bytes.Buffer
+	if err := s.WriteReport(&body); err != nil {
+		return err
+	}
+	return e.Send(subject, "text/html; charset=utf-8", body.Bytes())
+}
+
+// SendSummary sends the summary metrics of a run as plain text with the equity chart
+// attached as PNG
+func (e *Email) SendSummary(subject string, s *backtest.Statistic) error {
+	var body, chart bytes.Buffer
+	if err := s.WriteResult(&body, backtest.PrintOptions{Precision: 2}); err != nil {
+		return err
+	}
+	var attachments []Attachment
+	if len(s.Equity()) > 1 {
+		if err := s.WriteEquityChart(&chart, backtest.ChartOptions{Format: backtest.ChartPNG, Width: 1024, Height: 400}); err != nil {
+			return err
+		}
+		attachments = append(attachments, Attachment{Name: "equity.png", ContentType: "image/png", Data: chart.Bytes()})
+	}
+	return e.Send(subject, "text/plain; charset=utf-8", body.Bytes(), attachments...)
+}
+
+// SendOptimization sends the best results of an optimization as plain text with all
+// results attached as CSV
+func (e *Email) SendOptimization(subject string, results []optimize.Result, top int) error {
+	var body, csv bytes.Buffer
+	fmt.Fprintf(&body, "%d runs\n\n", len(results))
+	for i, r := range results {
+		if i == top {
+			break
+		}
+		if r.Err != nil {
+			fmt.Fprintf(&body, "%3d  failed  %v  %v\n", i+1, r.Params, r.Err)
+			continue
+		}
+		fmt.Fprintf(&body, "%3d  %10.4f  %v\n", i+1, r.Score, r.Params)
+	}
+	if err := optimize.WriteCSV(&csv, results); err != nil {
+		return err
+	}
+	return e.Send(subject, "text/plain; charset=utf-8", body.Bytes(), Attachment{Name: "results.csv", ContentType: "text/csv", Data: csv.Bytes()})
+}
+
+// Send sends an email with the body of the content type and the attachments
+func (e *Email) Send(subject, contentType string, body []byte, attachments ...Attachment) error {
+	if len(e.to) == 0 {
+		return errors.New("notify: email without recipients")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(attachments) == 0 {
+		fmt.Fprintf(&msg, "Content-Type: %s\r\nContent-Transfer-Encoding: base64\r\n\r\n", contentType)
+		writeBase64(&msg, body)
+		return e.send(msg.Bytes())
+	}
+
+	parts := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	writeBase64(part, body)
+	for _, a := range attachments {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		if err != nil {
+			return err
+		}
+		writeBase64(part, a.Data)
+	}
+	if err := parts.Close(); err != nil {
+		return err
+	}
+	return e.send(msg.Bytes())
+}
+
+// send sends a message over the SMTP server
+func (e *Email) send(msg []byte) error {
+	if err := smtp.SendMail(e.addr, e.auth, e.from, e.to, msg); err != nil {
+		return fmt.Errorf("notify: %v", err)
+	}
+	return nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
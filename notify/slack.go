@@ -0,0 +1,80 @@
+// Package notify implements the backtest.Notifier interface for Slack, Telegram and email,
+// so paper and live sessions report their fills, drawdowns and errors to a chat. Emails
+// can also carry the report of a finished run or optimization.
+//
+//	slack := notify.NewSlack("https://hooks.slack.com/services/...")
+//	test := backtest.New(
+//		backtest.WithNotifier(slack, backtest.Notifications{Name: "ma_cross", Fills: true, Drawdown: 0.1, Done: true, Errors: true}),
+//		...
+//	)
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Slack posts the notifications to a Slack channel over an incoming webhook
+type Slack struct {
+	webhook string
+	client  *http.Client
+}
+
+// NewSlack creates a notifier posting to the URL of an incoming webhook
+func NewSlack(webhook string) *Slack {
+	return &Slack{webhook: webhook, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Notify implements the backtest.Notifier interface
+func (s *Slack) Notify(ctx context.Context, n backtest.Notification) error {
+	body, err := json.Marshal(map[string]string{"text": fmt.Sprintf("%s *%s*\n%s", emoji(n.Kind), n.Title, n.Text)})
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.client, s.webhook, body)
+}
+
+// emoji returns the emoji of a notification kind
+func emoji(k backtest.NotificationKind) string {
+	switch k {
+	case backtest.NotifyFill:
+		return "\U0001F4B1" // currency exchange
+	case backtest.NotifyDrawdown:
+		return "\U0001F4C9" // chart decreasing
+	case backtest.NotifyError:
+		return "⚠️" // warning
+	}
+	return "✅" // check mark
+}
+
+// post sends a JSON body and fails on an error status with the response as message. The
+// endpoint is left out of the errors, the webhook and the bot token are secrets.
+func post(ctx context.Context, client *http.Client, endpoint string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return fmt.Errorf("notify: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("notify: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
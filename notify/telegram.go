@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Telegram sends the notifications as messages of a Telegram bot to a chat
+type Telegram struct {
+	api    string // URL of the bot API with the token
+	chatID string
+	client *http.Client
+}
+
+// NewTelegram creates a notifier sending with the token of a bot to the chat, the bot must
+// be a member of the chat
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		api:    "https://api.telegram.org/bot" + token,
+		chatID: chatID,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Notify implements the backtest.Notifier interface
+func (t *Telegram) Notify(ctx context.Context, n backtest.Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":    t.chatID,
+		"text":       emoji(n.Kind) + " <b>" + escapeHTML(n.Title) + "</b>\n" + escapeHTML(n.Text),
+		"parse_mode": "HTML",
+	})
+	if err != nil {
+		return err
+	}
+	return post(ctx, t.client, t.api+"/sendMessage", body)
+}
+
+// escapeHTML escapes the characters the HTML mode of Telegram requires to be escaped
+var escapeHTML = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
@@ -0,0 +1,165 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ObjectStore stores objects by key, e.g. in a directory or a bucket of S3 or GCS, so
+// distributed workers can share datasets and results without a shared filesystem. Keys
+// are slash separated paths.
+type ObjectStore interface {
+	// Get returns the data of an object, an error matching os.ErrNotExist if it is missing
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put creates or replaces an object
+	Put(ctx context.Context, key string, data []byte) error
+	// List returns the keys of the objects starting with the prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes an object
+	Delete(ctx context.Context, key string) error
+}
+
+// OpenObjectStoreFunc opens the object store of a bucket
+type OpenObjectStoreFunc func(bucket string) (ObjectStore, error)
+
+var (
+	objectStoresMu sync.Mutex
+	objectStores   = make(map[string]OpenObjectStoreFunc)
+)
+
+// RegisterObjectStore registers the object stores of a URL scheme, e.g. s3, so locations
+// like s3://bucket/runs can be opened. The objstore package registers s3 and gs.
+func RegisterObjectStore(scheme string, open OpenObjectStoreFunc) {
+	objectStoresMu.Lock()
+	defer objectStoresMu.Unlock()
+	objectStores[scheme] = open
+}
+
+// OpenObjectStore opens the object store of a location and returns it with the key prefix
+// of the location. Locations are URLs of a registered scheme, e.g. s3://bucket/runs with
+// the prefix runs/, or directories, which are stores without prefix.
+func OpenObjectStore(location string) (ObjectStore, string, error) {
+	scheme, bucket, prefix, ok := splitLocation(location)
+	if !ok {
+		return DirStore(location), "", nil
+	}
+	objectStoresMu.Lock()
+	open, found := objectStores[scheme]
+	objectStoresMu.Unlock()
+	if !found {
+		return nil, "", fmt.Errorf("no object store registered for %s://, import the objstore package", scheme)
+	}
+	store, err := open(bucket)
+	if err != nil {
+		return nil, "", err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return store, prefix, nil
+}
+
+// OpenObject opens a file or the object at the URL of a registered object store, e.g.
+// s3://bucket/data/BTC.csv
+func OpenObject(ctx context.Context, location string) (io.ReadCloser, error) {
+	scheme, bucket, key, ok := splitLocation(location)
+	if !ok {
+		return os.Open(location)
+	}
+	store, _, err := OpenObjectStore(scheme + "://" + bucket)
+	if err != nil {
+		return nil, err
+	}
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// splitLocation splits the URL of an object store, false for file paths
+func splitLocation(location string) (scheme, bucket, key string, ok bool) {
+	if !strings.Contains(location, "://") {
+		return "", "", "", false
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" {
+		return "", "", "", false
+	}
+	return u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/"), true
+}
+
+// DirStore is an ObjectStore in a directory, the keys are file paths below it
+type DirStore string
+
+// Get implements the ObjectStore interface
+func (d DirStore) Get(_ context.Context, key string) ([]byte, error) {
+	return ioutil.ReadFile(d.path(key))
+}
+
+// Put implements the ObjectStore interface, the object is written to a temporary file
+// first, so readers never see a partial object
+func (d DirStore) Put(_ context.Context, key string, data []byte) error {
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// List implements the ObjectStore interface, temporary files are left out
+func (d DirStore) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.Walk(string(d), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == string(d) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	sort.Strings(keys)
+	return keys, err
+}
+
+// Delete implements the ObjectStore interface
+func (d DirStore) Delete(_ context.Context, key string) error {
+	return os.Remove(d.path(key))
+}
+
+// path returns the file of a key
+func (d DirStore) path(key string) string {
+	return filepath.Join(string(d), filepath.FromSlash(key))
+}
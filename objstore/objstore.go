@@ -0,0 +1,287 @@
+// Package objstore implements the backtest.ObjectStore interface for S3 and GCS buckets,
+// so distributed workers and CI jobs share datasets and run results. Both are accessed over
+// the S3 API signed with AWS signature version 4, GCS with the HMAC keys of its
+// interoperability API. Importing the package registers the s3 and gs URL schemes:
+//
+//	import _ "github.com/ivtpz/backtest-go/objstore"
+//
+//	store, err := backtest.NewResultStore("s3://research/runs")
+//
+// The credentials are taken from the environment: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION and AWS_ENDPOINT_URL for S3 and
+// S3 compatible stores, GCS_HMAC_ACCESS_KEY and GCS_HMAC_SECRET for GCS.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+func init() {
+	backtest.RegisterObjectStore("s3", func(bucket string) (backtest.ObjectStore, error) {
+		key, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if key == "" || secret == "" {
+			return nil, errors.New("objstore: s3 needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		b := NewS3(bucket, region, key, secret)
+		b.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+			b.Endpoint, b.PathStyle = endpoint, true
+		}
+		return b, nil
+	})
+	backtest.RegisterObjectStore("gs", func(bucket string) (backtest.ObjectStore, error) {
+		key, secret := os.Getenv("GCS_HMAC_ACCESS_KEY"), os.Getenv("GCS_HMAC_SECRET")
+		if key == "" || secret == "" {
+			return nil, errors.New("objstore: gs needs GCS_HMAC_ACCESS_KEY and GCS_HMAC_SECRET")
+		}
+		return NewGCS(bucket, key, secret), nil
+	})
+}
+
+// Bucket is a bucket of an S3 compatible object store
+type Bucket struct {
+	Name         string
+	Region       string
+	Endpoint     string // base URL of the API, e.g. https://s3.eu-west-1.amazonaws.com
+	PathStyle    bool   // address the bucket in the path instead of the host name
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // token of temporary credentials, if any
+	Client       *http.Client
+}
+
+// NewS3 creates an S3 bucket in the region
+func NewS3(name, region, accessKey, secretKey string) *Bucket {
+	return &Bucket{
+		Name:      name,
+		Region:    region,
+		Endpoint:  "https://s3." + region + ".amazonaws.com",
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// NewGCS creates a GCS bucket accessed with the HMAC key of a service account
+func NewGCS(name, accessKey, secretKey string) *Bucket {
+	return &Bucket{
+		Name:      name,
+		Region:    "auto",
+		Endpoint:  "https://storage.googleapis.com",
+		PathStyle: true,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Get implements the backtest.ObjectStore interface
+func (b *Bucket) Get(ctx context.Context, key string) ([]byte, error) {
+	data, status, err := b.do(ctx, http.MethodGet, key, nil, nil)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("objstore: %s: %w", key, os.ErrNotExist)
+	}
+	return data, err
+}
+
+// Put implements the backtest.ObjectStore interface
+func (b *Bucket) Put(ctx context.Context, key string, data []byte) error {
+	_, _, err := b.do(ctx, http.MethodPut, key, nil, data)
+	return err
+}
+
+// Delete implements the backtest.ObjectStore interface
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	_, _, err := b.do(ctx, http.MethodDelete, key, nil, nil)
+	return err
+}
+
+// listResult is a page of a ListObjectsV2 response
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List implements the backtest.ObjectStore interface
+func (b *Bucket) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		data, _, err := b.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page listResult
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("objstore: invalid list response: %v", err)
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// do sends a signed request for an object, or the bucket if the key is empty, and returns
+// the response body and status
+func (b *Bucket) do(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, int, error) {
+	u, err := url.Parse(b.Endpoint)
+	if err != nil {
+		return nil, 0, err
+	}
+	path := "/" + key
+	if b.PathStyle {
+		path = "/" + b.Name + path
+	} else {
+		u.Host = b.Name + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = escapePath(path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	b.sign(req, body, time.Now().UTC())
+
+	resp, err := b.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+			return nil, resp.StatusCode, fmt.Errorf("objstore: %s %s: %s: %s", method, path, apiErr.Code, apiErr.Message)
+		}
+		return nil, resp.StatusCode, fmt.Errorf("objstore: %s %s: %s", method, path, resp.Status)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// sign adds the AWS signature version 4 of a request to its headers
+func (b *Bucket) sign(req *http.Request, body []byte, now time.Time) {
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if b.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.SessionToken)
+	}
+
+	// the signed headers, lower case and sorted
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if n := strings.ToLower(name); strings.HasPrefix(n, "x-amz-") {
+			headers[n] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for n := range headers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, n := range names {
+		canonicalHeaders.WriteString(n + ":" + headers[n] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + b.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+b.SecretKey), date)
+	for _, part := range []string{b.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.AccessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath escapes the segments of a path as the signature requires, all but the
+// unreserved characters of RFC 3986
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes the query sorted by name with the escaping of the signature
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for n := range query {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, n := range names {
+		for _, v := range query[n] {
+			parts = append(parts, escape(n)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape percent-encodes all but the unreserved characters of RFC 3986
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
@@ -0,0 +1,303 @@
+// Package optimize searches the parameters of a strategy for the best results.
+//
+// A search runs a test for each parameter set, created by a Setup function from the
+// params, and ranks the results by an objective:
+//
+//	search := optimize.GridSearch{
+//		Ranges: []optimize.Range{
+//			optimize.Ints("fast", 5, 20, 5),
+//			optimize.Ints("slow", 20, 60, 10),
+//		},
+//		Objective: optimize.Sharpe,
+//	}
+//	results, err := search.Run(ctx, setup)
+//
+// RandomSearch and TPESearch sample the parameter sets instead of running the full grid,
+// within a budget of runs or wall time.
+//
+// CrossValidation runs a strategy on the splits of a Splitter and Robustness on
+// randomized start dates and prices, to validate the results out of sample.
+//
+// The Setup function usually applies the params with backtest.SetParams and creates
+// the data with Data, so the loaded data events are shared by all tests.
+package optimize
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Setup creates an isolated test for a parameter set
+type Setup func(params map[string]interface{}) (*backtest.Test, error)
+
+// Objective scores the metrics of a test, higher scores are better
+type Objective func(backtest.MetricsJSON) float64
+
+// Sharpe scores by the Sharp ratio
+func Sharpe(m backtest.MetricsJSON) float64 {
+	return value(m.SharpeRatio)
+}
+
+// Calmar scores by the CAGR relative to the max drawdown
+func Calmar(m backtest.MetricsJSON) float64 {
+	cagr, drawdown := value(m.CAGR), value(m.MaxDrawdown)
+	if drawdown == 0 || math.IsInf(cagr, 0) {
+		return math.Inf(-1)
+	}
+	return cagr / math.Abs(drawdown)
+}
+
+// NetProfit scores by the profit net of trading costs
+func NetProfit(m backtest.MetricsJSON) float64 {
+	return m.Costs.NetProfit
+}
+
+// value returns a metric or minus infinity if it could not be calculated
+func value(v *float64) float64 {
+	if v == nil {
+		return math.Inf(-1)
+	}
+	return *v
+}
+
+// Range holds the values of a parameter
+type Range struct {
+	Name   string
+	Values []interface{}
+}
+
+// Values returns a range of the given values
+func Values(name string, values ...interface{}) Range {
+	return Range{Name: name, Values: values}
+}
+
+// Ints returns a range of ints from min to max in steps
+func Ints(name string, min, max, step int) Range {
+	r := Range{Name: name}
+	if step <= 0 {
+		return r
+	}
+	for v := min; v <= max; v += step {
+		r.Values = append(r.Values, v)
+	}
+	return r
+}
+
+// Floats returns a range of floats from min to max in steps
+func Floats(name string, min, max, step float64) Range {
+	r := Range{Name: name}
+	if step <= 0 {
+		return r
+	}
+	// count the steps, so rounding errors don't drop the max
+	n := int(math.Floor((max-min)/step + 1e-9))
+	for i := 0; i <= n; i++ {
+		r.Values = append(r.Values, min+float64(i)*step)
+	}
+	return r
+}
+
+// Result is the outcome of a parameter set
+type Result struct {
+	Params  map[string]interface{}
+	Score   float64
+	Metrics backtest.MetricsJSON
+	Err     error
+}
+
+// Data returns a new data handler streaming the events, so loaded data can be reused
+// by many tests without being consumed
+func Data(events []backtest.DataEventHandler) *backtest.Data {
+	data := &backtest.Data{}
+	data.SetStream(append([]backtest.DataEventHandler(nil), events...))
+	return data
+}
+
+// GridSearch runs a test for every combination of the parameter ranges
+type GridSearch struct {
+	Ranges    []Range
+	Objective Objective             // Sharpe if nil
+	Workers   int                   // number of concurrent tests, the number of CPUs if 0
+	Store     *backtest.ResultStore // skips the runs saved in the store, if set
+}
+
+// Run runs all parameter sets and returns the results sorted by score, failed tests last
+func (g GridSearch) Run(ctx context.Context, setup Setup) ([]Result, error) {
+	if err := checkRanges(g.Ranges); err != nil {
+		return nil, err
+	}
+
+	results := evaluate(ctx, grid(g.Ranges), setup, g.Objective, backtest.Runner{Workers: g.Workers, Store: g.Store})
+	sortResults(results)
+	return results, nil
+}
+
+// checkRanges checks that there are ranges and all of them have values
+func checkRanges(ranges []Range) error {
+	if len(ranges) == 0 {
+		return errors.New("search needs at least one range")
+	}
+	for _, r := range ranges {
+		if len(r.Values) == 0 {
+			return fmt.Errorf("range %s has no values", r.Name)
+		}
+	}
+	return nil
+}
+
+// grid returns all combinations of the range values
+func grid(ranges []Range) []map[string]interface{} {
+	sets := []map[string]interface{}{{}}
+	for _, r := range ranges {
+		var next []map[string]interface{}
+		for _, set := range sets {
+			for _, v := range r.Values {
+				params := make(map[string]interface{}, len(set)+1)
+				for k, pv := range set {
+					params[k] = pv
+				}
+				params[r.Name] = v
+				next = append(next, params)
+			}
+		}
+		sets = next
+	}
+	return sets
+}
+
+// evaluate runs a test for each parameter set on the runner and scores the results in
+// the order of the sets
+func evaluate(ctx context.Context, sets []map[string]interface{}, setup Setup, objective Objective, runner backtest.Runner) []Result {
+	if objective == nil {
+		objective = Sharpe
+	}
+
+	jobs := make([]backtest.Job, len(sets))
+	for i, params := range sets {
+		params := params
+		jobs[i] = backtest.Job{
+			Params: params,
+			Setup:  func() (*backtest.Test, error) { return setup(params) },
+		}
+	}
+
+	results := make([]Result, len(sets))
+	for i, jr := range runner.Run(ctx, jobs) {
+		results[i] = result(jr, objective)
+	}
+	return results
+}
+
+// sortResults sorts the results by score, failed tests last
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Err == nil) != (results[j].Err == nil) {
+			return results[i].Err == nil
+		}
+		return results[i].Score > results[j].Score
+	})
+}
+
+// paramNames returns the sorted names of all params of the results
+func paramNames(results []Result) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range results {
+		for name := range r.Params {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteCSV writes the results as CSV with a column per param and the main metrics
+func WriteCSV(w io.Writer, results []Result) error {
+	names := paramNames(results)
+	cw := csv.NewWriter(w)
+	header := append(append([]string(nil), names...),
+		"score", "total_return", "cagr", "max_drawdown", "sharpe_ratio", "net_profit", "trades", "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		var record []string
+		for _, name := range names {
+			record = append(record, fmt.Sprint(r.Params[name]))
+		}
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		record = append(record,
+			formatFloat(r.Score),
+			formatMetric(r.Metrics.TotalReturn),
+			formatMetric(r.Metrics.CAGR),
+			formatMetric(r.Metrics.MaxDrawdown),
+			formatMetric(r.Metrics.SharpeRatio),
+			formatFloat(r.Metrics.Costs.NetProfit),
+			strconv.Itoa(r.Metrics.Trades.Count),
+			errText,
+		)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// resultJSON is the JSON schema of a result
+type resultJSON struct {
+	Params  map[string]interface{} `json:"params"`
+	Score   *float64               `json:"score"`
+	Metrics backtest.MetricsJSON   `json:"metrics"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// WriteJSON writes the results as JSON array
+func WriteJSON(w io.Writer, results []Result) error {
+	out := make([]resultJSON, 0, len(results))
+	for _, r := range results {
+		rj := resultJSON{Params: r.Params, Metrics: r.Metrics}
+		if !math.IsNaN(r.Score) && !math.IsInf(r.Score, 0) {
+			score := r.Score
+			rj.Score = &score
+		}
+		if r.Err != nil {
+			rj.Error = r.Err.Error()
+		}
+		out = append(out, rj)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(out)
+}
+
+// formatFloat formats a float with the minimal number of digits
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatMetric formats a metric, empty if it could not be calculated
+func formatMetric(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
@@ -0,0 +1,168 @@
+package optimize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Robustness runs the same test from randomized start dates and, optionally, on prices
+// with random noise, to show if the results depend on a lucky entry point or the exact
+// price path
+type Robustness struct {
+	Runs      int       // number of randomized runs, 100 if 0
+	MaxSkip   float64   // maximum fraction of the timestamps skipped at the start, 0.2 if 0
+	Noise     float64   // standard deviation of the relative price noise, no noise if 0
+	Quantile  float64   // quantile of the randomized scores the baseline is lucky above, 0.9 if 0
+	Objective Objective // Sharpe if nil
+	Workers   int       // number of concurrent tests, the number of CPUs if 0
+	Seed      int64     // seed of the start dates and noise
+}
+
+// RobustnessRun is the result of a randomized run
+type RobustnessRun struct {
+	Start time.Time
+	Result
+}
+
+// RobustnessReport holds the baseline result on the full data and the distribution of
+// the randomized results
+type RobustnessReport struct {
+	Baseline   Result
+	Runs       []RobustnessRun
+	Mean       float64 // mean score of the successful runs
+	Std        float64
+	Min        float64
+	Median     float64
+	Max        float64
+	Profitable float64 // fraction of the successful runs with a net profit
+	Lucky      bool    // the baseline score is above the quantile of the randomized scores
+}
+
+// Run runs the baseline and the randomized tests
+func (r Robustness) Run(ctx context.Context, events []backtest.DataEventHandler, setup DataSetup) (RobustnessReport, error) {
+	runs, skip, quantile := r.Runs, r.MaxSkip, r.Quantile
+	if runs <= 0 {
+		runs = 100
+	}
+	if skip == 0 {
+		skip = 0.2
+	}
+	if quantile == 0 {
+		quantile = 0.9
+	}
+	if skip < 0 || skip >= 1 || quantile < 0 || quantile > 1 {
+		return RobustnessReport{}, errors.New("max skip and quantile must be between 0 and 1")
+	}
+	objective := r.Objective
+	if objective == nil {
+		objective = Sharpe
+	}
+
+	times := timestamps(events)
+	if len(times) < 2 {
+		return RobustnessReport{}, errors.New("not enough data for randomized runs")
+	}
+
+	// draw all starts and perturbations up front, so the runs don't depend on the scheduling
+	rng := rand.New(rand.NewSource(r.Seed))
+	starts := make([]time.Time, runs)
+	jobs := []backtest.Job{{
+		Name:  "baseline",
+		Setup: func() (*backtest.Test, error) { return setup(Data(events)) },
+	}}
+	for i := range starts {
+		starts[i] = times[rng.Intn(int(skip*float64(len(times)))+1)]
+		selected := Select(events, Period{Start: starts[i], End: times[len(times)-1]})
+		if r.Noise > 0 {
+			selected = perturb(selected, r.Noise, rng)
+		}
+		jobs = append(jobs, backtest.Job{
+			Name:  fmt.Sprintf("run %d", i+1),
+			Setup: func() (*backtest.Test, error) { return setup(Data(selected)) },
+		})
+	}
+
+	results := (backtest.Runner{Workers: r.Workers}).Run(ctx, jobs)
+	report := RobustnessReport{Baseline: result(results[0], objective)}
+	var scores []float64
+	profitable := 0
+	for i, jr := range results[1:] {
+		run := RobustnessRun{Start: starts[i], Result: result(jr, objective)}
+		report.Runs = append(report.Runs, run)
+		if run.Err != nil || math.IsInf(run.Score, 0) || math.IsNaN(run.Score) {
+			continue
+		}
+		scores = append(scores, run.Score)
+		if run.Metrics.Costs.NetProfit > 0 {
+			profitable++
+		}
+	}
+	if len(scores) == 0 {
+		return report, errors.New("no randomized run succeeded")
+	}
+
+	sort.Float64s(scores)
+	report.Mean, report.Std = meanStd(scores)
+	report.Min, report.Max = scores[0], scores[len(scores)-1]
+	report.Median = quantileOf(scores, 0.5)
+	report.Profitable = float64(profitable) / float64(len(scores))
+	report.Lucky = report.Baseline.Err == nil && report.Baseline.Score > quantileOf(scores, quantile)
+	return report, ctx.Err()
+}
+
+// quantileOf returns the quantile of sorted values with linear interpolation
+func quantileOf(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// perturb returns copies of the bars and ticks with each price scaled by random noise,
+// the prices of an event share the same factor to keep their order
+func perturb(events []backtest.DataEventHandler, noise float64, rng *rand.Rand) []backtest.DataEventHandler {
+	perturbed := make([]backtest.DataEventHandler, len(events))
+	for i, e := range events {
+		f := 1 + rng.NormFloat64()*noise
+		switch ev := e.(type) {
+		case backtest.Bar:
+			perturbed[i] = scaleBar(ev, f)
+		case *backtest.Bar:
+			bar := scaleBar(*ev, f)
+			perturbed[i] = &bar
+		case backtest.Tick:
+			perturbed[i] = scaleTick(ev, f)
+		case *backtest.Tick:
+			tick := scaleTick(*ev, f)
+			perturbed[i] = &tick
+		default:
+			perturbed[i] = e
+		}
+	}
+	return perturbed
+}
+
+// scaleBar returns the bar with the prices scaled
+func scaleBar(b backtest.Bar, f float64) backtest.Bar {
+	b.Open *= f
+	b.High *= f
+	b.Low *= f
+	b.Close *= f
+	return b
+}
+
+// scaleTick returns the tick with the prices scaled
+func scaleTick(t backtest.Tick, f float64) backtest.Tick {
+	t.Bid *= f
+	t.Ask *= f
+	return t
+}
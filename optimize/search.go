@@ -0,0 +1,264 @@
+package optimize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Budget limits the runs of a search, the search stops at whichever limit is reached first
+type Budget struct {
+	MaxRuns int           // maximum number of tests, unlimited if 0
+	MaxTime time.Duration // maximum wall time of the search, unlimited if 0
+}
+
+// maxAttempts limits the samples drawn to find a parameter set not run yet
+const maxAttempts = 100
+
+// RandomSearch runs tests for parameter sets sampled at random from the ranges, useful
+// for parameter spaces too large for a full grid
+type RandomSearch struct {
+	Ranges    []Range
+	Objective Objective // Sharpe if nil
+	Workers   int       // number of concurrent tests, the number of CPUs if 0
+	Budget    Budget
+	Seed      int64                 // seed of the sampling, the same seed samples the same parameter sets
+	Store     *backtest.ResultStore // skips the runs saved in the store, if set
+}
+
+// Run runs the sampled parameter sets until the budget is used or all sets are run and
+// returns the results sorted by score, failed tests last
+func (s RandomSearch) Run(ctx context.Context, setup Setup) ([]Result, error) {
+	rng := rand.New(rand.NewSource(s.Seed))
+	propose := func(seen map[string]bool, _ []Result) (map[string]interface{}, bool) {
+		return sampleUnseen(s.Ranges, seen, func() []int { return randomIndexes(s.Ranges, rng) })
+	}
+	return search(ctx, s.Ranges, s.Budget, backtest.Runner{Workers: s.Workers, Store: s.Store}, s.Objective, setup, propose)
+}
+
+// TPESearch runs a Bayesian optimization with a tree-structured Parzen estimator. After
+// a number of random startup runs, the results are split into good and bad ones by score
+// and new parameter sets are sampled where the good results are dense and the bad are not.
+type TPESearch struct {
+	Ranges     []Range
+	Objective  Objective // Sharpe if nil
+	Workers    int       // number of concurrent tests, the number of CPUs if 0
+	Budget     Budget
+	Seed       int64                 // seed of the sampling, the same seed and workers sample the same sets
+	Startup    int                   // random runs before the estimator is used, 10 if 0
+	Gamma      float64               // fraction of results taken as good, 0.25 if 0
+	Candidates int                   // candidates sampled for each proposal, 24 if 0
+	Store      *backtest.ResultStore // skips the runs saved in the store, if set
+}
+
+// Run runs the proposed parameter sets until the budget is used or all sets are run and
+// returns the results sorted by score, failed tests last
+func (s TPESearch) Run(ctx context.Context, setup Setup) ([]Result, error) {
+	startup, gamma, candidates := s.Startup, s.Gamma, s.Candidates
+	if startup <= 0 {
+		startup = 10
+	}
+	if gamma <= 0 || gamma >= 1 {
+		gamma = 0.25
+	}
+	if candidates <= 0 {
+		candidates = 24
+	}
+
+	rng := rand.New(rand.NewSource(s.Seed))
+	propose := func(seen map[string]bool, results []Result) (map[string]interface{}, bool) {
+		random := func() []int { return randomIndexes(s.Ranges, rng) }
+		scored := scoredResults(results)
+		if len(scored) < startup {
+			return sampleUnseen(s.Ranges, seen, random)
+		}
+
+		good, bad := s.densities(scored, gamma)
+		best, bestScore := []int(nil), math.Inf(-1)
+		for i := 0; i < candidates; i++ {
+			idx := make([]int, len(s.Ranges))
+			score := 0.0
+			for p := range s.Ranges {
+				idx[p] = sampleIndex(good[p], rng)
+				score += math.Log(good[p][idx[p]]) - math.Log(bad[p][idx[p]])
+			}
+			if !seen[key(s.Ranges, idx)] && score > bestScore {
+				best, bestScore = idx, score
+			}
+		}
+		if best == nil {
+			return sampleUnseen(s.Ranges, seen, random)
+		}
+		seen[key(s.Ranges, best)] = true
+		return params(s.Ranges, best), true
+	}
+	return search(ctx, s.Ranges, s.Budget, backtest.Runner{Workers: s.Workers, Store: s.Store}, s.Objective, setup, propose)
+}
+
+// densities returns the smoothed probabilities of the values of each range among the
+// good and the bad results
+func (s TPESearch) densities(scored []Result, gamma float64) (good, bad [][]float64) {
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	n := int(math.Ceil(gamma * float64(len(scored))))
+
+	density := func(results []Result, r Range) []float64 {
+		// one prior observation per value, so unseen values keep a chance
+		d := make([]float64, len(r.Values))
+		for i := range d {
+			d[i] = 1
+		}
+		for _, res := range results {
+			if i := indexOf(r, res.Params[r.Name]); i >= 0 {
+				d[i]++
+			}
+		}
+		total := float64(len(results) + len(d))
+		for i := range d {
+			d[i] /= total
+		}
+		return d
+	}
+
+	for _, r := range s.Ranges {
+		good = append(good, density(scored[:n], r))
+		bad = append(bad, density(scored[n:], r))
+	}
+	return good, bad
+}
+
+// proposer returns the next parameter set to run, or false if there is none left
+type proposer func(seen map[string]bool, results []Result) (map[string]interface{}, bool)
+
+// search runs batches of proposed parameter sets until the budget is used
+func search(ctx context.Context, ranges []Range, budget Budget, runner backtest.Runner, objective Objective, setup Setup, propose proposer) ([]Result, error) {
+	if err := checkRanges(ranges); err != nil {
+		return nil, err
+	}
+	if budget.MaxRuns <= 0 && budget.MaxTime <= 0 {
+		return nil, errors.New("search needs a budget of runs or time")
+	}
+	workers := runner.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	runCtx := ctx
+	if budget.MaxTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, budget.MaxTime)
+		defer cancel()
+	}
+
+	seen := make(map[string]bool)
+	var results []Result
+	for runCtx.Err() == nil {
+		batch := workers
+		if budget.MaxRuns > 0 && budget.MaxRuns-len(results) < batch {
+			batch = budget.MaxRuns - len(results)
+		}
+		var sets []map[string]interface{}
+		for len(sets) < batch {
+			params, ok := propose(seen, results)
+			if !ok {
+				break
+			}
+			sets = append(sets, params)
+		}
+		if len(sets) == 0 {
+			break
+		}
+
+		for _, r := range evaluate(runCtx, sets, setup, objective, runner) {
+			// tests stopped by the time budget are not part of the results
+			if ctx.Err() == nil && errors.Is(r.Err, context.DeadlineExceeded) {
+				continue
+			}
+			results = append(results, r)
+		}
+	}
+
+	sortResults(results)
+	return results, ctx.Err()
+}
+
+// sampleUnseen samples parameter sets until one is found that was not run yet
+func sampleUnseen(ranges []Range, seen map[string]bool, sample func() []int) (map[string]interface{}, bool) {
+	for i := 0; i < maxAttempts; i++ {
+		idx := sample()
+		k := key(ranges, idx)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		return params(ranges, idx), true
+	}
+	return nil, false
+}
+
+// randomIndexes returns a uniformly sampled value index for each range
+func randomIndexes(ranges []Range, rng *rand.Rand) []int {
+	idx := make([]int, len(ranges))
+	for i, r := range ranges {
+		idx[i] = rng.Intn(len(r.Values))
+	}
+	return idx
+}
+
+// sampleIndex samples an index from the probabilities
+func sampleIndex(probabilities []float64, rng *rand.Rand) int {
+	x := rng.Float64()
+	for i, p := range probabilities {
+		x -= p
+		if x < 0 {
+			return i
+		}
+	}
+	return len(probabilities) - 1
+}
+
+// scoredResults returns the results of successful tests with a finite score
+func scoredResults(results []Result) []Result {
+	var scored []Result
+	for _, r := range results {
+		if r.Err == nil && !math.IsInf(r.Score, 0) && !math.IsNaN(r.Score) {
+			scored = append(scored, r)
+		}
+	}
+	return scored
+}
+
+// params returns the parameter set of the value indexes
+func params(ranges []Range, idx []int) map[string]interface{} {
+	p := make(map[string]interface{}, len(ranges))
+	for i, r := range ranges {
+		p[r.Name] = r.Values[idx[i]]
+	}
+	return p
+}
+
+// key identifies the parameter set of the value indexes
+func key(ranges []Range, idx []int) string {
+	parts := make([]string, len(ranges))
+	for i := range ranges {
+		parts[i] = fmt.Sprint(idx[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+// indexOf returns the index of a value in the range or -1
+func indexOf(r Range, v interface{}) int {
+	for i, rv := range r.Values {
+		if rv == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,235 @@
+package optimize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Period is a time range of the data, both ends included
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains returns true if the time is within the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && !t.After(p.End)
+}
+
+// Split is a division of the data into train and test periods. The train data may have
+// several periods, e.g. around the test period of a k-fold split.
+type Split struct {
+	Train []Period
+	Test  Period
+}
+
+// Splitter divides the data into splits
+type Splitter interface {
+	Splits(events []backtest.DataEventHandler) ([]Split, error)
+}
+
+// Holdout splits the data once into a train and a later test period
+type Holdout struct {
+	TestFraction float64 // fraction of the timestamps used for testing, 0.3 if 0
+	Purge        int     // timestamps dropped from the end of the train period
+}
+
+// Splits implements the Splitter interface
+func (h Holdout) Splits(events []backtest.DataEventHandler) ([]Split, error) {
+	fraction := h.TestFraction
+	if fraction == 0 {
+		fraction = 0.3
+	}
+	if fraction < 0 || fraction >= 1 {
+		return nil, errors.New("test fraction must be between 0 and 1")
+	}
+
+	times := timestamps(events)
+	cut := int(float64(len(times)) * (1 - fraction))
+	if cut-h.Purge < 1 || cut >= len(times) {
+		return nil, errors.New("not enough data for a holdout split")
+	}
+
+	return []Split{{
+		Train: []Period{{Start: times[0], End: times[cut-h.Purge-1]}},
+		Test:  Period{Start: times[cut], End: times[len(times)-1]},
+	}}, nil
+}
+
+// KFold splits the data into consecutive folds, each fold is the test period of a split
+// trained on the other folds. As the train data before a test fold can overlap with its
+// outcomes and the data after it is correlated with it, timestamps before the test fold
+// are purged and after it are embargoed from the train data.
+type KFold struct {
+	Folds   int // number of folds, 5 if 0
+	Purge   int // timestamps dropped from the train data before each test fold
+	Embargo int // timestamps dropped from the train data after each test fold
+}
+
+// Splits implements the Splitter interface
+func (k KFold) Splits(events []backtest.DataEventHandler) ([]Split, error) {
+	folds := k.Folds
+	if folds == 0 {
+		folds = 5
+	}
+	if folds < 2 {
+		return nil, errors.New("k-fold needs at least two folds")
+	}
+
+	times := timestamps(events)
+	if len(times) < folds {
+		return nil, fmt.Errorf("not enough data for %d folds", folds)
+	}
+
+	splits := make([]Split, 0, folds)
+	for i := 0; i < folds; i++ {
+		start, end := i*len(times)/folds, (i+1)*len(times)/folds
+		split := Split{Test: Period{Start: times[start], End: times[end-1]}}
+		if before := start - k.Purge; before > 0 {
+			split.Train = append(split.Train, Period{Start: times[0], End: times[before-1]})
+		}
+		if after := end + k.Embargo; after < len(times) {
+			split.Train = append(split.Train, Period{Start: times[after], End: times[len(times)-1]})
+		}
+		if len(split.Train) == 0 {
+			return nil, fmt.Errorf("fold %d has no train data left", i+1)
+		}
+		splits = append(splits, split)
+	}
+	return splits, nil
+}
+
+// timestamps returns the sorted distinct times of the events
+func timestamps(events []backtest.DataEventHandler) []time.Time {
+	var times []time.Time
+	seen := make(map[int64]bool)
+	for _, e := range events {
+		if t := e.GetTime(); !seen[t.UnixNano()] {
+			seen[t.UnixNano()] = true
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// Select returns the events within the periods, in their order
+func Select(events []backtest.DataEventHandler, periods ...Period) []backtest.DataEventHandler {
+	var selected []backtest.DataEventHandler
+	for _, e := range events {
+		for _, p := range periods {
+			if p.Contains(e.GetTime()) {
+				selected = append(selected, e)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// DataSetup creates an isolated test on the data
+type DataSetup func(data *backtest.Data) (*backtest.Test, error)
+
+// SplitResult holds the train and test results of a split
+type SplitResult struct {
+	Split Split
+	Train Result
+	Test  Result
+}
+
+// Validation holds the results of all splits and the scores aggregated over the
+// successful splits
+type Validation struct {
+	Splits     []SplitResult
+	TrainScore float64 // mean score on the train data
+	TestScore  float64 // mean score on the test data
+	TestStd    float64 // standard deviation of the test scores
+}
+
+// CrossValidation runs a test on the train and on the test data of each split. Train
+// data of several periods is run as one test, with the gaps between the periods.
+type CrossValidation struct {
+	Splitter  Splitter
+	Objective Objective // Sharpe if nil
+	Workers   int       // number of concurrent tests, the number of CPUs if 0
+}
+
+// Run splits the events and runs the tests of all splits
+func (cv CrossValidation) Run(ctx context.Context, events []backtest.DataEventHandler, setup DataSetup) (Validation, error) {
+	if cv.Splitter == nil {
+		return Validation{}, errors.New("cross validation needs a splitter")
+	}
+	splits, err := cv.Splitter.Splits(events)
+	if err != nil {
+		return Validation{}, err
+	}
+	objective := cv.Objective
+	if objective == nil {
+		objective = Sharpe
+	}
+
+	jobs := make([]backtest.Job, 0, 2*len(splits))
+	for i, split := range splits {
+		for _, part := range []struct {
+			name    string
+			periods []Period
+		}{
+			{"train", split.Train},
+			{"test", []Period{split.Test}},
+		} {
+			selected := Select(events, part.periods...)
+			jobs = append(jobs, backtest.Job{
+				Name:  fmt.Sprintf("split %d %s", i+1, part.name),
+				Setup: func() (*backtest.Test, error) { return setup(Data(selected)) },
+			})
+		}
+	}
+
+	v := Validation{Splits: make([]SplitResult, len(splits))}
+	results := (backtest.Runner{Workers: cv.Workers}).Run(ctx, jobs)
+	var train, test []float64
+	for i, split := range splits {
+		sr := SplitResult{Split: split, Train: result(results[2*i], objective), Test: result(results[2*i+1], objective)}
+		v.Splits[i] = sr
+		if sr.Train.Err == nil && sr.Test.Err == nil {
+			train = append(train, sr.Train.Score)
+			test = append(test, sr.Test.Score)
+		}
+	}
+	v.TrainScore, _ = meanStd(train)
+	v.TestScore, v.TestStd = meanStd(test)
+	return v, ctx.Err()
+}
+
+// result returns the scored result of a job
+func result(jr backtest.JobResult, objective Objective) Result {
+	r := Result{Params: jr.Params, Metrics: jr.Metrics, Err: jr.Err, Score: math.Inf(-1)}
+	if jr.Err == nil {
+		r.Score = objective(jr.Metrics)
+	}
+	return r
+}
+
+// meanStd returns the mean and sample standard deviation of the values
+func meanStd(values []float64) (mean, std float64) {
+	if len(values) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, v := range values {
+		std += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(std / float64(len(values)-1))
+}
@@ -0,0 +1,69 @@
+package backtest
+
+// Option configures a test created by New
+type Option func(*Test)
+
+// WithSymbols sets the symbols to include into the test
+func WithSymbols(symbols ...string) Option {
+	return func(t *Test) { t.SetSymbols(symbols) }
+}
+
+// WithData sets the data provider of the test
+func WithData(data DataHandler) Option {
+	return func(t *Test) { t.SetData(data) }
+}
+
+// WithStrategy sets the strategy of the test
+func WithStrategy(strategy StrategyHandler) Option {
+	return func(t *Test) { t.SetStrategy(strategy) }
+}
+
+// WithPortfolio sets the portfolio of the test
+func WithPortfolio(portfolio PortfolioHandler) Option {
+	return func(t *Test) { t.SetPortfolio(portfolio) }
+}
+
+// WithExchange sets the execution provider of the test
+func WithExchange(exchange ExecutionHandler) Option {
+	return func(t *Test) { t.SetExchange(exchange) }
+}
+
+// WithBroker places the orders of the test at a live broker instead of the simulated exchange
+func WithBroker(b Broker) Option {
+	return func(t *Test) { t.SetExchange(NewBrokerExchange(b)) }
+}
+
+// WithStatistics sets the statistic of the test
+func WithStatistics(statistic StatisticHandler) Option {
+	return func(t *Test) { t.SetStatistic(statistic) }
+}
+
+// WithFilters appends signal filters applied between the strategy and the portfolio
+func WithFilters(filters ...SignalFilter) Option {
+	return func(t *Test) { t.AddFilter(filters...) }
+}
+
+// WithWarmup sets the number of data events at the start of the test without trading
+func WithWarmup(bars int) Option {
+	return func(t *Test) { t.SetWarmup(bars) }
+}
+
+// WithLogger sets the logger of the test
+func WithLogger(l Logger) Option {
+	return func(t *Test) { t.SetLogger(l) }
+}
+
+// WithDeterministic enables the determinism mode with the seed
+func WithDeterministic(seed int64) Option {
+	return func(t *Test) { t.SetDeterministic(seed) }
+}
+
+// WithStopRules appends rules ending the test early
+func WithStopRules(rules ...StopRule) Option {
+	return func(t *Test) { t.AddStopRule(rules...) }
+}
+
+// WithClock sets the clock of the test
+func WithClock(c Clock) Option {
+	return func(t *Test) { t.SetClock(c) }
+}
@@ -0,0 +1,173 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Parameterizer is the interface for strategies exposing tunable parameters.
+// Params returns a pointer to the typed Params struct of the strategy, the
+// fields of the struct can be named with a `param:"name"` tag.
+type Parameterizer interface {
+	Params() interface{}
+}
+
+// Validator is the interface for params validating their values
+type Validator interface {
+	Validate() error
+}
+
+// SetParams sets multiple parameters by name and validates the params afterwards
+func SetParams(s Parameterizer, values map[string]interface{}) error {
+	for name, value := range values {
+		if err := setParam(s, name, value); err != nil {
+			return err
+		}
+	}
+	return ValidateParams(s)
+}
+
+// SetParam sets a single parameter by name and validates the params afterwards
+func SetParam(s Parameterizer, name string, value interface{}) error {
+	if err := setParam(s, name, value); err != nil {
+		return err
+	}
+	return ValidateParams(s)
+}
+
+// GetParams returns the current parameter values by name
+func GetParams(s Parameterizer) (map[string]interface{}, error) {
+	v, err := paramsValue(s)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		if name, ok := paramName(v.Type().Field(i)); ok {
+			values[name] = v.Field(i).Interface()
+		}
+	}
+	return values, nil
+}
+
+// ParamNames returns the names of all parameters in field order
+func ParamNames(s Parameterizer) ([]string, error) {
+	v, err := paramsValue(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for i := 0; i < v.NumField(); i++ {
+		if name, ok := paramName(v.Type().Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// ValidateParams validates the params if they implement the Validator interface
+func ValidateParams(s Parameterizer) error {
+	if v, ok := s.Params().(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// setParam sets a single parameter by name without validation
+func setParam(s Parameterizer, name string, value interface{}) error {
+	v, err := paramsValue(s)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if n, ok := paramName(v.Type().Field(i)); ok && n == name {
+			return setField(v.Field(i), value)
+		}
+	}
+	return fmt.Errorf("unknown param %q", name)
+}
+
+// paramsValue returns the settable struct value of the params
+func paramsValue(s Parameterizer) (v reflect.Value, err error) {
+	v = reflect.ValueOf(s.Params())
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return v, errors.New("params must be a pointer to a struct")
+	}
+	return v.Elem(), nil
+}
+
+// paramName returns the param name of an exported struct field
+func paramName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag := f.Tag.Get("param")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return strings.ToLower(f.Name), true
+}
+
+// setField converts the value to the kind of the field and sets it
+func setField(f reflect.Value, value interface{}) error {
+	// parse strings e.g. from config files or command line flags
+	if str, ok := value.(string); ok && f.Kind() != reflect.String {
+		var err error
+		switch f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			value, err = strconv.ParseInt(str, 10, 64)
+		case reflect.Float32, reflect.Float64:
+			value, err = strconv.ParseFloat(str, 64)
+		case reflect.Bool:
+			value, err = strconv.ParseBool(str)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return errors.New("param value is nil")
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f.SetInt(v.Int())
+		case reflect.Float32, reflect.Float64:
+			if v.Float() != float64(int64(v.Float())) {
+				return fmt.Errorf("param value %v is not an integer", value)
+			}
+			f.SetInt(int64(v.Float()))
+		default:
+			return fmt.Errorf("can not set %T to int param", value)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f.SetFloat(float64(v.Int()))
+		case reflect.Float32, reflect.Float64:
+			f.SetFloat(v.Float())
+		default:
+			return fmt.Errorf("can not set %T to float param", value)
+		}
+	default:
+		if !v.Type().AssignableTo(f.Type()) {
+			return fmt.Errorf("can not set %T to %s param", value, f.Type())
+		}
+		f.Set(v)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// WritePDFReport writes the report as PDF document with the same sections as the
+// HTML report: summary metrics, equity and drawdown charts, monthly returns and transactions
+func (s *Statistic) WritePDFReport(w io.Writer) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Backtest report", true)
+	pdf.AddPage()
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(width, 10, "Backtest report", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(width, 6, "Generated "+time.Now().Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+
+	pdfHeading(pdf, width, "Summary")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, m := range s.reportSummary() {
+		pdf.CellFormat(60, 6, m.Name, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 6, m.Value, "1", 1, "R", false, 0, "")
+	}
+
+	if len(s.equity) > 1 {
+		opts := ChartOptions{Format: ChartPNG, Width: 1024, Height: 400}
+		charts := []struct {
+			name  string
+			write func(io.Writer, ChartOptions) error
+		}{
+			{"Equity", s.WriteEquityChart},
+			{"Drawdown", s.WriteDrawdownChart},
+		}
+		for _, c := range charts {
+			var buf bytes.Buffer
+			if err := c.write(&buf, opts); err != nil {
+				return err
+			}
+			pdfHeading(pdf, width, c.name)
+			imageOpts := gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: false}
+			pdf.RegisterImageOptionsReader(c.name, imageOpts, &buf)
+			// keep the aspect ratio of the rendered chart
+			pdf.ImageOptions(c.name, left, pdf.GetY(), width, width*float64(opts.Height)/float64(opts.Width), true, imageOpts, 0, "")
+		}
+	}
+
+	pdfHeading(pdf, width, "Monthly returns")
+	cell := width / 14
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(244, 244, 244)
+	pdf.CellFormat(cell, 6, "Year", "1", 0, "L", true, 0, "")
+	for _, m := range monthNames() {
+		pdf.CellFormat(cell, 6, m, "1", 0, "R", true, 0, "")
+	}
+	pdf.CellFormat(cell, 6, "Year", "1", 1, "R", true, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	for _, row := range s.ReturnsTable() {
+		pdf.CellFormat(cell, 6, fmt.Sprint(row.Year), "1", 0, "L", false, 0, "")
+		for _, r := range append(row.Months[:], &row.Total) {
+			if r != nil {
+				pdfReturnColor(pdf, *r)
+			}
+			pdf.CellFormat(cell, 6, formatReturn(r), "1", 0, "R", false, 0, "")
+			pdf.SetTextColor(0, 0, 0)
+		}
+		pdf.Ln(-1)
+	}
+
+	pdfHeading(pdf, width, "Transactions")
+	columns := []struct {
+		name  string
+		width float64
+	}{
+		{"#", 10}, {"Time", 30}, {"Symbol", 25}, {"Action", 20},
+		{"Qty", 25}, {"Price", 25}, {"Cost", 25}, {"Net value", width - 160},
+	}
+	pdf.SetFont("Helvetica", "B", 8)
+	for _, c := range columns {
+		pdf.CellFormat(c.width, 6, c.name, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 8)
+	for i, t := range s.transactionHistory {
+		values := []string{
+			fmt.Sprint(i + 1),
+			t.GetTime().Format("2006-01-02 15:04"),
+			t.GetSymbol(),
+			t.GetDirection(),
+			fmt.Sprintf("%.4f", t.GetQty()),
+			fmt.Sprintf("%.4f", t.GetPrice()),
+			fmt.Sprintf("%.4f", t.GetCost()),
+			fmt.Sprintf("%.4f", t.NetValue()),
+		}
+		for j, c := range columns {
+			pdf.CellFormat(c.width, 6, values[j], "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	return pdf.Output(w)
+}
+
+// pdfHeading writes a section heading
+func pdfHeading(pdf *gofpdf.Fpdf, width float64, title string) {
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(width, 8, title, "", 1, "L", false, 0, "")
+}
+
+// pdfReturnColor colors negative returns red and positive returns green
+func pdfReturnColor(pdf *gofpdf.Fpdf, v float64) {
+	if v < 0 {
+		pdf.SetTextColor(214, 39, 40)
+		return
+	}
+	pdf.SetTextColor(44, 160, 44)
+}
@@ -3,6 +3,7 @@ package backtest
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/shopspring/decimal"
 )
@@ -11,8 +12,9 @@ import (
 // Events - fill, order
 type PortfolioHandler interface {
 	OnSignaler
+	OnBasketSignaler
 	OnFiller
-	// Investor - Intended to check if portfolio holds a given asset
+	Investor
 	Updater
 	Casher
 	Valuer
@@ -24,11 +26,28 @@ type OnSignaler interface {
 	OnSignal(SignalEvent, DataHandler) (*Order, error)
 }
 
+// OnBasketSignaler as an interface for the OnBasketSignal method
+type OnBasketSignaler interface {
+	OnBasketSignal(BasketSignalEvent, DataHandler) ([]*Order, error)
+}
+
 // OnFiller as an intercafe for the OnFill method
 type OnFiller interface {
 	OnFill(FillEvent, DataHandler) (*Fill, error)
 }
 
+// Investor gives a read-only view of the open positions and orders of the portfolio
+type Investor interface {
+	Position(string) (Position, bool)
+	Positions() []Position
+	OpenOrders(string) []Order
+}
+
+// OrderBookSetter is the interface for portfolios viewing the pending orders of an exchange
+type OrderBookSetter interface {
+	SetOrderBook(OrderBook)
+}
+
 // Casher handles basic portolio info
 type Casher interface {
 	SetInitialCash(float64)
@@ -54,14 +73,25 @@ type Portfolio struct {
 	cash         float64
 	holdings     map[string]position
 	transactions []FillEvent
-	// sizeManager  SizeHandler
+	sizeManager  SizeHandler
+	shortSelling bool
+	orderBook    OrderBook
+	logger       Logger
 	// riskManager  RiskHandler
 }
 
-// // SetSizeManager sets the size manager to be used with the portfolio
-// func (p *Portfolio) SetSizeManager(size SizeHandler) {
-// 	p.sizeManager = size
-// }
+// defaultSize is the order qty used when no size manager is set
+const defaultSize = 0.2
+
+// SetSizeManager sets the size manager to be used with the portfolio
+func (p *Portfolio) SetSizeManager(size SizeHandler) {
+	p.sizeManager = size
+}
+
+// SetShortSelling allows sell orders exceeding the current holdings
+func (p *Portfolio) SetShortSelling(allow bool) {
+	p.shortSelling = allow
+}
 
 // // SetRiskManager sets the risk manager to be used with the portfolio
 // func (p *Portfolio) SetRiskManager(risk RiskHandler) {
@@ -77,49 +107,160 @@ func (p *Portfolio) Reset() {
 
 // OnSignal handles an incomming signal event
 func (p *Portfolio) OnSignal(signal SignalEvent, data DataHandler) (*Order, error) {
-	orderType := "MKT" // default Market - may want to expand to other types later
+	orderType := "MKT" // default Market, limit entries are set by the signal
 	var limit float64
 
 	if signal.GetDirection() == "" {
 		return &Order{}, errors.New("No direction")
 	}
 
-	currQty := p.holdings[signal.GetSymbol()].qty
-	currCash := p.Cash()
-	currPrice := data.Latest(signal.GetSymbol()).LatestPrice()
-
-	if signal.GetDirection() == "sell" && currQty <= 0.2 {
-		return &Order{}, errors.New("No holdings to sell")
-	}
-
-	if signal.GetDirection() == "buy" && currCash <= 0.2*currPrice {
-		return &Order{}, errors.New("Not enough cash to buy")
-	}
-
 	initialOrder := &Order{
 		Event: Event{
 			Time:   signal.GetTime(),
 			Symbol: signal.GetSymbol(),
 		},
 		Direction: signal.GetDirection(),
-		// Qty should be set by PositionSizer
-		Qty:       0.2,
 		OrderType: orderType,
 		Limit:     limit,
 	}
 
+	if l, ok := signal.(Limiter); ok && l.GetLimit() > 0 {
+		initialOrder.OrderType = "LMT"
+		initialOrder.Limit = l.GetLimit()
+	}
+	if e, ok := signal.(Expirer); ok {
+		initialOrder.TTL = e.GetTTL()
+	}
+	if t, ok := signal.(Tagger); ok {
+		initialOrder.Tags = t.GetTags()
+	}
+
+	// attach stop loss and take profit to be booked as bracket on the entry fill
+	if b, ok := signal.(Bracketer); ok {
+		initialOrder.StopLoss = b.GetStopLoss()
+		initialOrder.TakeProfit = b.GetTakeProfit()
+	}
+
+	if p.sizeManager == nil {
+		p.sizeManager = &Size{DefaultSize: defaultSize}
+	}
+
 	// Last price for asset
-	// latest := data.Latest(signal.GetSymbol())
+	latest := data.Latest(signal.GetSymbol())
+	initialOrder.Arrival = latest.LatestPrice()
 
-	// sizedOrder, err := p.sizeManager.SizeOrder(initialOrder, latest, p)
-	// if err != nil {
-	// }
+	sizedOrder, err := p.sizeManager.SizeOrder(signal, initialOrder, latest, p)
+	if err != nil {
+		return &Order{}, err
+	}
+
+	if signal.GetDirection() == "sell" && !p.canSell(signal.GetSymbol(), sizedOrder.Qty) {
+		return &Order{}, errors.New("No holdings to sell")
+	}
+
+	price := latest.LatestPrice()
+	if sizedOrder.OrderType == "LMT" {
+		price = sizedOrder.Limit
+	}
+	if signal.GetDirection() == "buy" && p.Cash() <= sizedOrder.Qty*price {
+		return &Order{}, errors.New("Not enough cash to buy")
+	}
 
 	// order, err := p.riskManager.EvaluateOrder(sizedOrder, latest, p.holdings)
 	// if err != nil {
 	// }
 
-	return initialOrder, nil
+	return sizedOrder, nil
+}
+
+// OnBasketSignal handles an incomming basket signal event. The orders for all legs
+// are only returned if every leg can be executed.
+func (p *Portfolio) OnBasketSignal(signal BasketSignalEvent, data DataHandler) ([]*Order, error) {
+	legs := signal.GetLegs()
+	if len(legs) == 0 {
+		return nil, errors.New("No legs")
+	}
+
+	if p.sizeManager == nil {
+		p.sizeManager = &Size{DefaultSize: defaultSize}
+	}
+
+	var orders []*Order
+	var buyValue float64
+	sellQty := make(map[string]float64)
+	for _, leg := range legs {
+		if leg.Direction != "buy" && leg.Direction != "sell" {
+			return nil, fmt.Errorf("No direction for leg %s", leg.Symbol)
+		}
+		// legs without fixed qty are sized by their ratio, a zero strength would size
+		// them at the default size
+		if leg.Ratio < 0 || (leg.Qty <= 0 && leg.Ratio == 0) {
+			return nil, fmt.Errorf("Invalid hedge ratio %v for leg %s", leg.Ratio, leg.Symbol)
+		}
+
+		latest := data.Latest(leg.Symbol)
+		if latest == nil {
+			return nil, fmt.Errorf("No data for leg %s", leg.Symbol)
+		}
+
+		legSignal := &Signal{
+			Event:     Event{Time: signal.GetTime(), Symbol: leg.Symbol},
+			Direction: leg.Direction,
+			Strength:  signal.GetStrength() * leg.Ratio,
+		}
+		order := &Order{
+			Event:     legSignal.Event,
+			Direction: leg.Direction,
+			Qty:       leg.Qty,
+			OrderType: "MKT",
+			Arrival:   latest.LatestPrice(),
+		}
+		if t, ok := signal.(Tagger); ok {
+			order.Tags = t.GetTags()
+		}
+
+		price := latest.LatestPrice()
+		if leg.Limit > 0 {
+			order.OrderType = "LMT"
+			order.Limit = leg.Limit
+			price = leg.Limit
+		}
+
+		sizedOrder := order
+		if leg.Qty <= 0 {
+			var err error
+			sizedOrder, err = p.sizeManager.SizeOrder(legSignal, order, latest, p)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if leg.Direction == "sell" {
+			sellQty[leg.Symbol] += sizedOrder.Qty
+			if !p.canSell(leg.Symbol, sellQty[leg.Symbol]) {
+				return nil, fmt.Errorf("No holdings to sell for leg %s", leg.Symbol)
+			}
+		}
+		if leg.Direction == "buy" {
+			buyValue += sizedOrder.Qty * price
+		}
+
+		orders = append(orders, sizedOrder)
+	}
+
+	if buyValue > 0 && p.Cash() <= buyValue {
+		return nil, errors.New("Not enough cash to buy")
+	}
+
+	return orders, nil
+}
+
+// canSell checks if the portfolio holds enough qty of a symbol to sell
+func (p Portfolio) canSell(symbol string, qty float64) bool {
+	if p.shortSelling {
+		return true
+	}
+	return p.holdings[symbol].qty >= qty
 }
 
 // OnFill handles an incomming fill event
@@ -151,6 +292,7 @@ func (p *Portfolio) OnFill(fill FillEvent, data DataHandler) (*Fill, error) {
 
 	// add fill to transactions
 	p.transactions = append(p.transactions, fill)
+	logger(p.logger).Debug("fill booked", "symbol", fill.GetSymbol(), "direction", fill.GetDirection(), "qty", fill.GetQty(), "price", fill.GetPrice(), "cash", p.cash)
 
 	f := fill.(*Fill)
 	return f, nil
@@ -165,6 +307,51 @@ func (p Portfolio) IsInvested(symbol string) (pos position, ok bool) {
 	return pos, false
 }
 
+// Position returns a snapshot of the open position on the given symbol
+func (p Portfolio) Position(symbol string) (Position, bool) {
+	pos, ok := p.IsInvested(symbol)
+	return pos.snapshot(), ok
+}
+
+// Positions returns snapshots of all open positions sorted by symbol
+func (p Portfolio) Positions() []Position {
+	var positions []Position
+	for _, pos := range p.holdings {
+		if pos.qty != 0 {
+			positions = append(positions, pos.snapshot())
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	return positions
+}
+
+// SetLogger implements the LoggerSetter interface
+func (p *Portfolio) SetLogger(l Logger) {
+	p.logger = l
+}
+
+// SetOrderBook sets the order book of the exchange to view the open orders
+func (p *Portfolio) SetOrderBook(book OrderBook) {
+	p.orderBook = book
+}
+
+// OpenOrders returns copies of the pending orders for a symbol, an empty symbol returns all
+func (p Portfolio) OpenOrders(symbol string) []Order {
+	if p.orderBook == nil {
+		return nil
+	}
+
+	var orders []Order
+	for _, o := range p.orderBook.PendingOrders() {
+		if symbol == "" || o.GetSymbol() == symbol {
+			orders = append(orders, *o)
+		}
+	}
+	return orders
+}
+
 // // IsLong checks if the portfolio has an open long position on the given symbol
 // func (p Portfolio) IsLong(symbol string) (pos position, ok bool) {
 // 	pos, ok = p.holdings[symbol]
@@ -214,7 +401,7 @@ func (p Portfolio) Cash() float64 {
 // Value return the current total value of the portfolio
 func (p Portfolio) Value() float64 {
 	holdingValue := decimal.NewFromFloat(0)
-	for _, pos := range p.holdings {
+	for _, pos := range p.sortedHoldings() {
 		marketValue := decimal.NewFromFloat(pos.marketValue)
 		holdingValue = holdingValue.Add(marketValue)
 	}
@@ -224,6 +411,17 @@ func (p Portfolio) Value() float64 {
 	return value
 }
 
+// sortedHoldings returns the holdings sorted by symbol, so sums over them don't depend
+// on the map order
+func (p Portfolio) sortedHoldings() []position {
+	holdings := make([]position, 0, len(p.holdings))
+	for _, pos := range p.holdings {
+		holdings = append(holdings, pos)
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].symbol < holdings[j].symbol })
+	return holdings
+}
+
 func (p Portfolio) ViewHoldings() {
 	fmt.Println(p.holdings)
 }
@@ -0,0 +1,65 @@
+package backtest
+
+import "testing"
+
+// basketData returns the processed data of the symbols, each with a single bar at the price
+func basketData(prices map[string]float64) *Data {
+	var stream []DataEventHandler
+	for symbol, price := range prices {
+		stream = append(stream, closeBars(symbol, price)...)
+	}
+	d := &Data{}
+	d.SetStream(stream)
+	for _, ok := d.Next(); ok; _, ok = d.Next() {
+	}
+	return d
+}
+
+func TestBasketSignalRatios(t *testing.T) {
+	data := basketData(map[string]float64{"A": 10, "B": 10})
+	basket := func(legs ...Leg) *BasketSignal {
+		return &BasketSignal{Signal: Signal{Event: Event{Time: queueStart}}, Legs: legs}
+	}
+
+	tests := []struct {
+		name  string
+		legs  []Leg
+		valid bool
+	}{
+		{"ratios", []Leg{{Symbol: "A", Direction: "buy", Ratio: 2}, {Symbol: "B", Direction: "buy", Ratio: 1}}, true},
+		{"fixed qty without ratio", []Leg{{Symbol: "A", Direction: "buy", Qty: 5}}, true},
+		{"zero ratio", []Leg{{Symbol: "A", Direction: "buy", Ratio: 1}, {Symbol: "B", Direction: "buy"}}, false},
+		{"negative ratio", []Leg{{Symbol: "A", Direction: "buy", Ratio: -1}}, false},
+		{"negative ratio with fixed qty", []Leg{{Symbol: "A", Direction: "buy", Ratio: -1, Qty: 5}}, false},
+	}
+	for _, tt := range tests {
+		p := &Portfolio{}
+		p.SetInitialCash(10000)
+		p.SetCash(10000)
+		orders, err := p.OnBasketSignal(basket(tt.legs...), data)
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("%s: got %d orders, want an error", tt.name, len(orders))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(orders) != len(tt.legs) {
+			t.Errorf("%s: got %d orders, want %d", tt.name, len(orders), len(tt.legs))
+		}
+	}
+
+	p := &Portfolio{}
+	p.SetInitialCash(10000)
+	p.SetCash(10000)
+	orders, err := p.OnBasketSignal(basket(tests[0].legs...), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders[1].Qty <= 0 || orders[0].Qty != 2*orders[1].Qty {
+		t.Errorf("legs of ratio 2 and 1 got qty %v and %v", orders[0].Qty, orders[1].Qty)
+	}
+}
@@ -35,6 +35,40 @@ type position struct {
 	totalProfitLoss  float64
 }
 
+// Position is a read-only snapshot of a holdings position
+type Position struct {
+	Timestamp        time.Time
+	Symbol           string
+	Qty              float64 // positive on long position, negative on short position
+	AvgPrice         float64 // average price without cost
+	AvgPriceNet      float64 // average price including cost
+	MarketPrice      float64 // last known market price
+	MarketValue      float64 // qty * price
+	Cost             float64 // commission + fees
+	CostBasis        float64 // absolute qty * avgPriceNet
+	RealProfitLoss   float64
+	UnrealProfitLoss float64
+	TotalProfitLoss  float64
+}
+
+// snapshot returns a read-only copy of the position
+func (p position) snapshot() Position {
+	return Position{
+		Timestamp:        p.timestamp,
+		Symbol:           p.symbol,
+		Qty:              p.qty,
+		AvgPrice:         p.avgPrice,
+		AvgPriceNet:      p.avgPriceNet,
+		MarketPrice:      p.marketPrice,
+		MarketValue:      p.marketValue,
+		Cost:             p.cost,
+		CostBasis:        p.costBasis,
+		RealProfitLoss:   p.realProfitLoss,
+		UnrealProfitLoss: p.unrealProfitLoss,
+		TotalProfitLoss:  p.totalProfitLoss,
+	}
+}
+
 // Create a new position based on a fill event
 func (p *position) Create(fill FillEvent) {
 	p.timestamp = fill.GetTime()
@@ -0,0 +1,110 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// PrintOptions controls the formatting of the printed results
+type PrintOptions struct {
+	Precision    int    // decimal places of amounts and percentages, ratios get two more
+	Currency     string // symbol put in front of amounts, e.g. "$"
+	Transactions bool   // list every transaction after the summary
+}
+
+// DefaultPrintOptions are the options used by PrintResult
+var DefaultPrintOptions = PrintOptions{Precision: 2, Transactions: true}
+
+// PrintResult prints the backtest statistics to the screen
+func (s Statistic) PrintResult() {
+	s.WriteResult(os.Stdout, DefaultPrintOptions)
+}
+
+// WriteResult writes the summary metrics and optionally the transactions as aligned tables
+func (s Statistic) WriteResult(w io.Writer, opts PrintOptions) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Metric\tValue")
+	fmt.Fprintln(tw, "------\t-----")
+	for _, m := range s.summary(opts) {
+		fmt.Fprintf(tw, "%s\t%s\n", m.Name, m.Value)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if !opts.Transactions || len(s.transactionHistory) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\tTime\tSymbol\tAction\tQty\tPrice\tCost\tNet value\t")
+	for i, f := range s.transactionHistory {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			i+1,
+			f.GetTime().Format("2006-01-02 15:04"),
+			f.GetSymbol(),
+			f.GetDirection(),
+			strconv.FormatFloat(f.GetQty(), 'f', -1, 64),
+			opts.money(f.GetPrice()),
+			opts.money(f.GetCost()),
+			opts.money(f.NetValue()),
+		)
+	}
+	return tw.Flush()
+}
+
+// number formats a value with the precision
+func (o PrintOptions) number(v float64) string {
+	return strconv.FormatFloat(v, 'f', o.precision(), 64)
+}
+
+// ratio formats a ratio with two more decimal places than the precision
+func (o PrintOptions) ratio(v float64) string {
+	return strconv.FormatFloat(v, 'f', o.precision()+2, 64)
+}
+
+// percent formats a fraction as percentage
+func (o PrintOptions) percent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', o.precision(), 64) + "%"
+}
+
+// money formats an amount with the currency symbol and thousands separators
+func (o PrintOptions) money(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return o.number(v)
+	}
+
+	digits := strconv.FormatFloat(math.Abs(v), 'f', o.precision(), 64)
+	integer, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		integer, fraction = digits[:i], digits[i:]
+	}
+
+	var b strings.Builder
+	if v < 0 && strings.Trim(digits, "0.") != "" {
+		b.WriteByte('-')
+	}
+	b.WriteString(o.Currency)
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(fraction)
+	return b.String()
+}
+
+// precision returns the decimal places, never negative
+func (o PrintOptions) precision() int {
+	if o.Precision < 0 {
+		return 0
+	}
+	return o.Precision
+}
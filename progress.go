@@ -0,0 +1,75 @@
+package backtest
+
+import "time"
+
+// Progress is the state of a running test passed to the progress callback
+type Progress struct {
+	Bars    int           // processed data events
+	Total   int           // all data events of the run
+	Time    time.Time     // time of the last processed data event
+	Equity  float64       // value of the portfolio
+	Elapsed time.Duration // wall time since the start of the run
+	ETA     time.Duration // estimated wall time until the end of the run
+	Done    bool          // the run has ended
+}
+
+// Fraction returns the processed share of the data events between 0 and 1
+func (p Progress) Fraction() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Bars) / float64(p.Total)
+}
+
+// SetProgress sets a callback which is called with the progress of the test at most once
+// per interval of wall time and once at the end of the run
+func (t *Test) SetProgress(interval time.Duration, fn func(Progress)) {
+	t.progressInterval = interval
+	t.progress = fn
+}
+
+// progressTracker calls the progress callback of a run
+type progressTracker struct {
+	fn       func(Progress)
+	interval time.Duration
+	started  time.Time
+	last     time.Time
+	state    Progress
+}
+
+// newProgressTracker starts tracking the progress of a run over the total data events
+func newProgressTracker(fn func(Progress), interval time.Duration, total int) *progressTracker {
+	now := time.Now()
+	return &progressTracker{fn: fn, interval: interval, started: now, last: now, state: Progress{Total: total}}
+}
+
+// update counts a processed data event and calls the callback if the interval passed
+func (p *progressTracker) update(d DataEventHandler, equity float64) {
+	p.state.Bars++
+	p.state.Time = d.GetTime()
+	p.state.Equity = equity
+
+	now := time.Now()
+	if now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	p.report(now)
+}
+
+// done calls the callback a last time at the end of the run
+func (p *progressTracker) done() {
+	p.state.Done = true
+	p.report(time.Now())
+}
+
+// report calls the callback with the elapsed and estimated remaining time
+func (p *progressTracker) report(now time.Time) {
+	p.state.Elapsed = now.Sub(p.started)
+	p.state.ETA = 0
+	if p.state.Bars > 0 && p.state.Total > p.state.Bars && !p.state.Done {
+		perBar := p.state.Elapsed / time.Duration(p.state.Bars)
+		p.state.ETA = perBar * time.Duration(p.state.Total-p.state.Bars)
+	}
+	p.fn(p.state)
+}
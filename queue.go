@@ -0,0 +1,101 @@
+package backtest
+
+import "container/heap"
+
+// Event priorities of the event queue at equal timestamps
+const (
+	PriorityData = iota
+	PrioritySignal
+	PriorityOrder
+	PriorityFill
+)
+
+// EventQueue is the queue of pending events of a test. Events are ordered by time, at
+// equal times by priority (data before signals before orders before fills) and at equal
+// priority in the order they were appended. The zero value is an empty queue.
+type EventQueue struct {
+	items queueItems
+	seq   uint64
+}
+
+// Append adds an event to the queue
+func (q *EventQueue) Append(e EventHandler) {
+	heap.Push(&q.items, queueItem{event: e, priority: EventPriority(e), seq: q.seq})
+	q.seq++
+}
+
+// Peek returns the next event without removing it from the queue
+func (q *EventQueue) Peek() (e EventHandler, ok bool) {
+	if len(q.items) == 0 {
+		return e, false
+	}
+	return q.items[0].event, true
+}
+
+// Next removes and returns the next event of the queue
+func (q *EventQueue) Next() (e EventHandler, ok bool) {
+	if len(q.items) == 0 {
+		return e, false
+	}
+	return heap.Pop(&q.items).(queueItem).event, true
+}
+
+// Len returns the number of queued events
+func (q *EventQueue) Len() int {
+	return len(q.items)
+}
+
+// Reset removes all events from the queue
+func (q *EventQueue) Reset() {
+	q.items = nil
+	q.seq = 0
+}
+
+// EventPriority returns the queue priority of an event, lower values are processed first
+func EventPriority(e EventHandler) int {
+	switch e.(type) {
+	case DataEventHandler:
+		return PriorityData
+	case *StrategyError, *Discrepancy, BasketSignalEvent, SignalEvent:
+		return PrioritySignal
+	case CancelOrderEvent, ModifyOrderEvent, OrderEvent:
+		return PriorityOrder
+	case FillEvent:
+		return PriorityFill
+	}
+	return PrioritySignal
+}
+
+// queueItem is a queued event with its ordering keys
+type queueItem struct {
+	event    EventHandler
+	priority int
+	seq      uint64
+}
+
+// queueItems implements heap.Interface
+type queueItems []queueItem
+
+func (q queueItems) Len() int { return len(q) }
+
+func (q queueItems) Less(i, j int) bool {
+	ti, tj := q[i].event.GetTime(), q[j].event.GetTime()
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q queueItems) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queueItems) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+
+func (q *queueItems) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
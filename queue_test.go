@@ -0,0 +1,156 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+)
+
+var queueStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// queueEvent returns an event of the kind at the minute after the start of the queue tests
+func queueEvent(kind, symbol string, minute int) EventHandler {
+	event := Event{Time: queueStart.Add(time.Duration(minute) * time.Minute), Symbol: symbol}
+	switch kind {
+	case "data":
+		return Bar{Event: event}
+	case "signal":
+		return &Signal{Event: event}
+	case "order":
+		return &Order{Event: event}
+	case "fill":
+		return &Fill{Event: event}
+	}
+	panic("unknown event kind " + kind)
+}
+
+// drain returns the events of the queue in the order they are taken
+func drain(q *EventQueue) []EventHandler {
+	var events []EventHandler
+	for e, ok := q.Next(); ok; e, ok = q.Next() {
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEventQueueTimeOrder(t *testing.T) {
+	var q EventQueue
+	for _, minute := range []int{3, 1, 4, 0, 2} {
+		q.Append(queueEvent("data", "A", minute))
+	}
+
+	events := drain(&q)
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5", len(events))
+	}
+	for i, e := range events {
+		if want := queueStart.Add(time.Duration(i) * time.Minute); !e.GetTime().Equal(want) {
+			t.Errorf("event %d at %v, want %v", i, e.GetTime(), want)
+		}
+	}
+}
+
+func TestEventQueuePriority(t *testing.T) {
+	var q EventQueue
+	// appended in reverse, at the same time
+	for _, kind := range []string{"fill", "order", "signal", "data"} {
+		q.Append(queueEvent(kind, "A", 0))
+	}
+	// an earlier fill goes before the data of a later time
+	q.Append(queueEvent("fill", "A", -1))
+
+	var got []int
+	for _, e := range drain(&q) {
+		got = append(got, EventPriority(e))
+	}
+	want := []int{PriorityFill, PriorityData, PrioritySignal, PriorityOrder, PriorityFill}
+	if len(got) != len(want) {
+		t.Fatalf("got priorities %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got priorities %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEventQueueFIFO(t *testing.T) {
+	var q EventQueue
+	symbols := []string{"C", "A", "D", "B", "E"}
+	for _, symbol := range symbols {
+		q.Append(queueEvent("signal", symbol, 0))
+		q.Append(queueEvent("data", symbol, 0))
+	}
+
+	events := drain(&q)
+	if len(events) != 2*len(symbols) {
+		t.Fatalf("got %d events, want %d", len(events), 2*len(symbols))
+	}
+	for i, symbol := range symbols {
+		if _, ok := events[i].(DataEventHandler); !ok || events[i].GetSymbol() != symbol {
+			t.Errorf("event %d is %T of %s, want data of %s", i, events[i], events[i].GetSymbol(), symbol)
+		}
+		j := len(symbols) + i
+		if _, ok := events[j].(SignalEvent); !ok || events[j].GetSymbol() != symbol {
+			t.Errorf("event %d is %T of %s, want signal of %s", j, events[j], events[j].GetSymbol(), symbol)
+		}
+	}
+}
+
+func TestEventQueueEmpty(t *testing.T) {
+	var q EventQueue
+	if e, ok := q.Peek(); ok || e != nil {
+		t.Errorf("Peek on empty queue returned %v, %v", e, ok)
+	}
+	if e, ok := q.Next(); ok || e != nil {
+		t.Errorf("Next on empty queue returned %v, %v", e, ok)
+	}
+
+	q.Append(queueEvent("data", "A", 0))
+	q.Next()
+	if _, ok := q.Peek(); ok {
+		t.Error("Peek on drained queue returned an event")
+	}
+	if _, ok := q.Next(); ok {
+		t.Error("Next on drained queue returned an event")
+	}
+}
+
+func TestEventQueuePeek(t *testing.T) {
+	var q EventQueue
+	q.Append(queueEvent("order", "A", 0))
+	q.Append(queueEvent("data", "B", 0))
+
+	peeked, ok := q.Peek()
+	if !ok || peeked.GetSymbol() != "B" {
+		t.Fatalf("Peek returned %v, %v, want the data of B", peeked, ok)
+	}
+	if q.Len() != 2 {
+		t.Errorf("Peek removed an event, %d left", q.Len())
+	}
+	next, ok := q.Next()
+	if !ok || next != peeked {
+		t.Errorf("Next returned %v, want the peeked event %v", next, peeked)
+	}
+}
+
+func TestEventQueueReset(t *testing.T) {
+	var q EventQueue
+	q.Append(queueEvent("data", "A", 0))
+	q.Append(queueEvent("signal", "A", 0))
+	q.Reset()
+
+	if q.Len() != 0 {
+		t.Fatalf("Len after Reset is %d, want 0", q.Len())
+	}
+	if _, ok := q.Next(); ok {
+		t.Fatal("Next after Reset returned an event")
+	}
+
+	// the queue is usable after a reset and keeps the order of appending
+	q.Append(queueEvent("signal", "X", 0))
+	q.Append(queueEvent("signal", "Y", 0))
+	events := drain(&q)
+	if len(events) != 2 || events[0].GetSymbol() != "X" || events[1].GetSymbol() != "Y" {
+		t.Errorf("got %v after Reset, want the signals of X and Y", events)
+	}
+}
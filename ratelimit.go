@@ -0,0 +1,92 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for orders exceeding the rate limit of a BrokerExchange
+var ErrRateLimited = errors.New("order rate limit exceeded")
+
+// RateLimit limits the orders a BrokerExchange places at its broker, so a misbehaving
+// strategy can't flood a venue. Orders over the limit are rejected, not delayed. The zero
+// value places orders without limit.
+type RateLimit struct {
+	PerSecond float64       // orders per second on average, unlimited if 0
+	Burst     int           // orders placed at once, PerSecond rounded up if 0
+	PerMinute int           // orders within a minute, unlimited if 0
+	Cooldown  time.Duration // time between two orders of a symbol, none if 0
+}
+
+// RateLimiter is the optional interface of brokers with a default rate limit below the
+// limits of their venue
+type RateLimiter interface {
+	RateLimit() RateLimit
+}
+
+// rateLimiter checks orders against a rate limit, a token bucket for the rate per second
+// and a sliding window for the orders per minute
+type rateLimiter struct {
+	limit RateLimit
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	minute  []time.Time          // times of the orders of the last minute
+	symbols map[string]time.Time // time of the last order by symbol
+}
+
+// newRateLimiter creates a limiter with a full bucket
+func newRateLimiter(l RateLimit) *rateLimiter {
+	return &rateLimiter{limit: l, tokens: float64(l.burst()), symbols: make(map[string]time.Time)}
+}
+
+// burst returns the size of the bucket
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Ceil(l.PerSecond))
+}
+
+// allow counts an order of the symbol at the time and returns ErrRateLimited if it
+// exceeds the limit. Forced orders are counted but never rejected.
+func (r *rateLimiter) allow(symbol string, now time.Time, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.limit.PerSecond > 0 {
+		if !r.last.IsZero() {
+			r.tokens += now.Sub(r.last).Seconds() * r.limit.PerSecond
+			r.tokens = math.Min(r.tokens, float64(r.limit.burst()))
+		}
+		r.last = now
+	}
+	for len(r.minute) > 0 && now.Sub(r.minute[0]) >= time.Minute {
+		r.minute = r.minute[1:]
+	}
+
+	if !force {
+		if r.limit.PerSecond > 0 && r.tokens < 1 {
+			return fmt.Errorf("%w, %v orders per second", ErrRateLimited, r.limit.PerSecond)
+		}
+		if r.limit.PerMinute > 0 && len(r.minute) >= r.limit.PerMinute {
+			return fmt.Errorf("%w, %d orders per minute", ErrRateLimited, r.limit.PerMinute)
+		}
+		if last, ok := r.symbols[symbol]; ok && r.limit.Cooldown > 0 && now.Sub(last) < r.limit.Cooldown {
+			return fmt.Errorf("%w, %s in cooldown for %v", ErrRateLimited, symbol, r.limit.Cooldown-now.Sub(last))
+		}
+	}
+
+	if r.limit.PerSecond > 0 {
+		r.tokens = math.Max(r.tokens-1, 0)
+	}
+	if r.limit.PerMinute > 0 {
+		r.minute = append(r.minute, now)
+	}
+	r.symbols[symbol] = now
+	return nil
+}
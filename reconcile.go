@@ -0,0 +1,177 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Reconciliation configures the periodic check of the portfolio of a live test against the
+// positions and balances reported by its broker. Partial fills, fees and manual trades let
+// the two drift apart, the differences are queued as Discrepancy events.
+type Reconciliation struct {
+	Schedule      Schedule // times of the checks, e.g. Every(time.Minute)
+	Currency      string   // asset of the cash balance, the cash is not checked if empty
+	Tolerance     float64  // difference of a qty ignored, e.g. of rounded fractional qty
+	CashTolerance float64  // difference of the cash ignored
+	AutoCorrect   bool     // book the differences into the portfolio
+}
+
+// Discrepancy is an event for a difference between the portfolio and the broker. Position
+// discrepancies are corrected with a fill tagged reconcile, cash discrepancies by setting
+// the cash of the broker.
+type Discrepancy struct {
+	Event
+	Kind     string  // position or cash
+	Expected float64 // qty or cash of the portfolio
+	Actual   float64 // qty or cash at the broker
+	Price    float64 // market price of a position, 0 if unknown
+}
+
+// Error implements the error interface
+func (d Discrepancy) Error() string {
+	if d.Kind == "cash" {
+		return fmt.Sprintf("cash is %v at the broker, %v in the portfolio", d.Actual, d.Expected)
+	}
+	return fmt.Sprintf("position of %s is %v at the broker, %v in the portfolio", d.Symbol, d.Actual, d.Expected)
+}
+
+// SetReconciliation reconciles the portfolio with the broker on the schedule, it requires
+// an exchange placing the orders at a broker, see WithBroker. Call it once per test.
+func (t *Test) SetReconciliation(r Reconciliation) {
+	t.reconcile = &r
+	t.AddTimer(r.Schedule, t.reconcilePortfolio)
+}
+
+// WithReconciliation reconciles the portfolio with the broker on the schedule
+func WithReconciliation(r Reconciliation) Option {
+	return func(t *Test) { t.SetReconciliation(r) }
+}
+
+// reconcilePortfolio is the timer comparing the portfolio to the broker. Symbols with open
+// orders are skipped, as their fills may be reported but not booked yet, and so is the cash
+// while any order is open.
+func (t *Test) reconcilePortfolio(now time.Time) []EventHandler {
+	e, ok := t.exchange.(*BrokerExchange)
+	if !ok || t.inWarmup(now) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	positions, err := e.Broker().Positions(ctx)
+	if err != nil {
+		t.log().Warn("reconciliation failed", "error", err)
+		return nil
+	}
+
+	pending := make(map[string]bool)
+	for _, o := range e.PendingOrders() {
+		pending[o.GetSymbol()] = true
+	}
+	actual := make(map[string]Position)
+	for _, p := range positions {
+		if t.includes(p.Symbol) {
+			actual[p.Symbol] = p
+		}
+	}
+	expected := make(map[string]Position)
+	for _, p := range t.portfolio.Positions() {
+		expected[p.Symbol] = p
+	}
+	symbols := make([]string, 0, len(actual)+len(expected))
+	for s := range actual {
+		symbols = append(symbols, s)
+	}
+	for s := range expected {
+		if _, ok := actual[s]; !ok {
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+
+	var events []EventHandler
+	for _, s := range symbols {
+		a, p := actual[s], expected[s]
+		if pending[s] || math.Abs(a.Qty-p.Qty) <= t.reconcile.Tolerance {
+			continue
+		}
+		price := a.MarketPrice
+		if price == 0 {
+			price = p.MarketPrice
+		}
+		if latest := t.data.Latest(s); price == 0 && latest != nil {
+			price = latest.LatestPrice()
+		}
+		events = append(events, &Discrepancy{
+			Event:    Event{Time: now, Symbol: s},
+			Kind:     "position",
+			Expected: p.Qty,
+			Actual:   a.Qty,
+			Price:    price,
+		})
+	}
+
+	if t.reconcile.Currency == "" || len(pending) > 0 {
+		return events
+	}
+	balances, err := e.Broker().Balances(ctx)
+	if err != nil {
+		t.log().Warn("reconciliation of the cash failed", "error", err)
+		return events
+	}
+	var cash float64
+	for _, b := range balances {
+		if b.Asset == t.reconcile.Currency {
+			cash = b.Total()
+		}
+	}
+	if math.Abs(cash-t.portfolio.Cash()) > t.reconcile.CashTolerance {
+		events = append(events, &Discrepancy{
+			Event:    Event{Time: now},
+			Kind:     "cash",
+			Expected: t.portfolio.Cash(),
+			Actual:   cash,
+		})
+	}
+	return events
+}
+
+// inWarmup checks if a data event at the time would be within the warmup period
+func (t *Test) inWarmup(now time.Time) bool {
+	if t.bars < t.warmupBars {
+		return true
+	}
+	return t.warmupDuration > 0 && (t.start.IsZero() || now.Sub(t.start) < t.warmupDuration)
+}
+
+// correct books a discrepancy into the portfolio, positions with a fill at the market
+// price without cost
+func (t *Test) correct(d *Discrepancy) error {
+	if d.Kind == "cash" {
+		t.portfolio.SetCash(d.Actual)
+		t.log().Info("cash corrected", "cash", d.Actual)
+		return nil
+	}
+	if d.Price == 0 {
+		t.log().Warn("position not corrected without market price", "symbol", d.Symbol)
+		return nil
+	}
+	qty := d.Actual - d.Expected
+	fill := &Fill{
+		Event:     d.Event,
+		Exchange:  "reconcile",
+		Direction: "BOT",
+		Qty:       math.Abs(qty),
+		Price:     d.Price,
+		Tags:      []string{"reconcile"},
+		OrderType: "reconcile",
+		Arrival:   d.Price,
+	}
+	if qty < 0 {
+		fill.Direction = "SLD"
+	}
+	t.log().Info("position corrected", "symbol", d.Symbol, "direction", fill.Direction, "qty", fill.Qty, "price", fill.Price)
+	return t.eventLoop(fill)
+}
@@ -0,0 +1,119 @@
+package backtest
+
+import (
+	"math"
+	"sync"
+)
+
+// Regime declares the classified market regime of a symbol
+type Regime int
+
+const (
+	// RegimeUnknown is returned until enough data is available
+	RegimeUnknown Regime = iota
+	// RegimeTrendUp is a directional market with rising prices
+	RegimeTrendUp
+	// RegimeTrendDown is a directional market with falling prices
+	RegimeTrendDown
+	// RegimeRange is a sideways market without clear direction
+	RegimeRange
+	// RegimeHighVolatility is a market with volatility above the threshold
+	RegimeHighVolatility
+)
+
+// String returns the name of the regime
+func (r Regime) String() string {
+	switch r {
+	case RegimeTrendUp:
+		return "trend up"
+	case RegimeTrendDown:
+		return "trend down"
+	case RegimeRange:
+		return "range"
+	case RegimeHighVolatility:
+		return "high volatility"
+	}
+	return "unknown"
+}
+
+// RegimeDetector classifies the market regime of each symbol with rolling statistics.
+// High volatility takes precedence, otherwise the efficiency ratio of the price path
+// (net change divided by the sum of absolute changes) separates trends from ranges.
+// Set it on the test to update it on every data event, strategies query it with Current.
+type RegimeDetector struct {
+	Lookback            int     // number of bars of the rolling window
+	TrendThreshold      float64 // minimum efficiency ratio between 0 and 1 for a trend
+	VolatilityThreshold float64 // per bar volatility above which the regime is high volatility, 0 to disable
+
+	mu      sync.RWMutex
+	current map[string]Regime
+}
+
+// Update classifies the regime of the symbol of the data event
+func (r *RegimeDetector) Update(de DataEventHandler, d DataHandler) Regime {
+	regime := r.Detect(d, de.GetSymbol())
+
+	r.mu.Lock()
+	if r.current == nil {
+		r.current = make(map[string]Regime)
+	}
+	r.current[de.GetSymbol()] = regime
+	r.mu.Unlock()
+
+	return regime
+}
+
+// Current returns the last classified regime of a symbol
+func (r *RegimeDetector) Current(symbol string) Regime {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current[symbol]
+}
+
+// Reset removes all classified regimes
+func (r *RegimeDetector) Reset() {
+	r.mu.Lock()
+	r.current = nil
+	r.mu.Unlock()
+}
+
+// Detect classifies the regime of a symbol on the data known so far
+func (r *RegimeDetector) Detect(d DataHandler, symbol string) Regime {
+	if r.Lookback < 2 {
+		return RegimeUnknown
+	}
+
+	if r.VolatilityThreshold > 0 {
+		vol, ok := IndicatorsOf(d).Volatility(d, symbol, r.Lookback)
+		if !ok {
+			return RegimeUnknown
+		}
+		if vol > r.VolatilityThreshold {
+			return RegimeHighVolatility
+		}
+	}
+
+	prices, _, ok := lastPrices(d, symbol, r.Lookback+1)
+	if !ok {
+		return RegimeUnknown
+	}
+
+	var path float64
+	for i := 1; i < len(prices); i++ {
+		path += math.Abs(prices[i] - prices[i-1])
+	}
+	if path == 0 {
+		return RegimeRange
+	}
+
+	change := prices[len(prices)-1] - prices[0]
+	efficiency := math.Abs(change) / path
+
+	switch {
+	case efficiency >= r.TrendThreshold && change > 0:
+		return RegimeTrendUp
+	case efficiency >= r.TrendThreshold && change < 0:
+		return RegimeTrendDown
+	}
+	return RegimeRange
+}
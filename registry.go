@@ -0,0 +1,390 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StrategyFactory creates a strategy from its config params
+type StrategyFactory func(params map[string]interface{}) (StrategyHandler, error)
+
+// SizerFactory creates a size handler from its config params
+type SizerFactory func(params map[string]interface{}) (SizeHandler, error)
+
+// FilterFactory creates a signal filter from its config params, e.g. a risk manager
+// vetoing signals
+type FilterFactory func(params map[string]interface{}) (SignalFilter, error)
+
+// DataFactory loads the data events of a data source from its config params
+type DataFactory func(params map[string]interface{}) ([]DataEventHandler, error)
+
+// Registry holds the components a test can be assembled from by name, so tests can be
+// built from a config file or API request without writing Go for every experiment
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]map[string]interface{} // factories by kind and name
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]map[string]interface{})}
+}
+
+// DefaultRegistry is the registry with the built-in components, packages can register
+// their own components to it in an init function
+var DefaultRegistry = builtinRegistry()
+
+// RegisterStrategy registers a strategy factory, it panics if the name is taken
+func (r *Registry) RegisterStrategy(name string, f StrategyFactory) {
+	r.register("strategy", name, f)
+}
+
+// RegisterSizer registers a size handler factory, it panics if the name is taken
+func (r *Registry) RegisterSizer(name string, f SizerFactory) {
+	r.register("sizer", name, f)
+}
+
+// RegisterFilter registers a signal filter factory, it panics if the name is taken
+func (r *Registry) RegisterFilter(name string, f FilterFactory) {
+	r.register("filter", name, f)
+}
+
+// RegisterData registers a data factory, it panics if the name is taken
+func (r *Registry) RegisterData(name string, f DataFactory) {
+	r.register("data", name, f)
+}
+
+// RegisterStrategy registers a strategy factory to the default registry
+func RegisterStrategy(name string, f StrategyFactory) {
+	DefaultRegistry.RegisterStrategy(name, f)
+}
+
+// RegisterSizer registers a size handler factory to the default registry
+func RegisterSizer(name string, f SizerFactory) {
+	DefaultRegistry.RegisterSizer(name, f)
+}
+
+// RegisterFilter registers a signal filter factory to the default registry
+func RegisterFilter(name string, f FilterFactory) {
+	DefaultRegistry.RegisterFilter(name, f)
+}
+
+// RegisterData registers a data factory to the default registry
+func RegisterData(name string, f DataFactory) {
+	DefaultRegistry.RegisterData(name, f)
+}
+
+// register adds a factory of a kind
+func (r *Registry) register(kind, name string, f interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		panic(fmt.Sprintf("backtest: %s registered without name", kind))
+	}
+	if r.factories[kind] == nil {
+		r.factories[kind] = make(map[string]interface{})
+	}
+	if _, ok := r.factories[kind][name]; ok {
+		panic(fmt.Sprintf("backtest: %s %q registered twice", kind, name))
+	}
+	r.factories[kind][name] = f
+}
+
+// factory returns the factory of a kind by name
+func (r *Registry) factory(kind, name string) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.factories[kind][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s %q", kind, name)
+	}
+	return f, nil
+}
+
+// Strategy creates the strategy of the component spec
+func (r *Registry) Strategy(c ComponentSpec) (StrategyHandler, error) {
+	f, err := r.factory("strategy", c.Name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := f.(StrategyFactory)(c.Params)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %v", c.Name, err)
+	}
+	return s, nil
+}
+
+// Sizer creates the size handler of the component spec
+func (r *Registry) Sizer(c ComponentSpec) (SizeHandler, error) {
+	f, err := r.factory("sizer", c.Name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := f.(SizerFactory)(c.Params)
+	if err != nil {
+		return nil, fmt.Errorf("sizer %s: %v", c.Name, err)
+	}
+	return s, nil
+}
+
+// Filter creates the signal filter of the component spec
+func (r *Registry) Filter(c ComponentSpec) (SignalFilter, error) {
+	f, err := r.factory("filter", c.Name)
+	if err != nil {
+		return nil, err
+	}
+	s, err := f.(FilterFactory)(c.Params)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %v", c.Name, err)
+	}
+	return s, nil
+}
+
+// Data loads the data events of the component spec
+func (r *Registry) Data(c ComponentSpec) ([]DataEventHandler, error) {
+	f, err := r.factory("data", c.Name)
+	if err != nil {
+		return nil, err
+	}
+	events, err := f.(DataFactory)(c.Params)
+	if err != nil {
+		return nil, fmt.Errorf("data %s: %v", c.Name, err)
+	}
+	return events, nil
+}
+
+// Names returns the sorted names of the registered components of a kind: strategy,
+// sizer, filter or data
+func (r *Registry) Names(kind string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for name := range r.factories[kind] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParamStrategy returns a factory creating a strategy with the constructor and setting
+// its params by name, e.g. for strategies implementing the Parameterizer interface
+func ParamStrategy(new func() Parameterizer) StrategyFactory {
+	return func(params map[string]interface{}) (StrategyHandler, error) {
+		p := new()
+		s, ok := p.(StrategyHandler)
+		if !ok {
+			return nil, fmt.Errorf("%T is no strategy", p)
+		}
+		if err := SetParams(p, params); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
+// DecodeParams decodes config params into the struct pointed to by v, using the json
+// tags of its fields. Unknown params are an error, so typos in configs are not ignored.
+func DecodeParams(params map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// paramDuration is a duration param given as string, e.g. "24h", or in seconds
+type paramDuration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (d *paramDuration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*d = paramDuration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = paramDuration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", b)
+	}
+	return nil
+}
+
+// MACross is a moving average crossover strategy, buying when the fast average crosses
+// above the slow one and closing the position when it crosses below
+type MACross struct {
+	*BaseStrategy
+	params MACrossParams
+}
+
+// MACrossParams are the params of the MACross strategy
+type MACrossParams struct {
+	Fast int `param:"fast"` // bars of the fast moving average
+	Slow int `param:"slow"` // bars of the slow moving average
+}
+
+// Validate implements the Validator interface
+func (p *MACrossParams) Validate() error {
+	if p.Fast < 1 || p.Slow <= p.Fast {
+		return fmt.Errorf("fast %d must be positive and below slow %d", p.Fast, p.Slow)
+	}
+	return nil
+}
+
+// NewMACross creates a moving average crossover strategy trading all symbols
+func NewMACross(fast, slow int) *MACross {
+	s := &MACross{params: MACrossParams{Fast: fast, Slow: slow}}
+	// the conditions read the params on every bar, so changed params take effect
+	entry := func(list []DataEventHandler, ic *IndicatorCache) bool {
+		return CrossAbove(SMA(s.params.Fast), SMA(s.params.Slow))(list, ic)
+	}
+	exit := func(list []DataEventHandler, ic *IndicatorCache) bool {
+		return CrossBelow(SMA(s.params.Fast), SMA(s.params.Slow))(list, ic)
+	}
+	s.BaseStrategy = NewRuleStrategy("", entry, exit)
+	return s
+}
+
+// Params implements the Parameterizer interface
+func (s *MACross) Params() interface{} {
+	return &s.params
+}
+
+// builtinRegistry returns a registry with the components of this package
+func builtinRegistry() *Registry {
+	r := NewRegistry()
+
+	r.RegisterStrategy("ma_cross", ParamStrategy(func() Parameterizer { return NewMACross(10, 50) }))
+	r.RegisterStrategy("random", func(params map[string]interface{}) (StrategyHandler, error) {
+		var p struct {
+			Seed *int64 `json:"seed"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		s := &Strategy{}
+		if p.Seed != nil {
+			s.SetSeed(*p.Seed)
+		}
+		return s, nil
+	})
+	r.RegisterStrategy("dca", func(params map[string]interface{}) (StrategyHandler, error) {
+		var p struct {
+			Notional float64       `json:"notional"`
+			Interval paramDuration `json:"interval"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return &DCA{Notional: p.Notional, Interval: time.Duration(p.Interval)}, nil
+	})
+	r.RegisterStrategy("grid", func(params map[string]interface{}) (StrategyHandler, error) {
+		var p struct {
+			Lower  float64 `json:"lower"`
+			Upper  float64 `json:"upper"`
+			Levels int     `json:"levels"`
+			Size   float64 `json:"size"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return &Grid{Lower: p.Lower, Upper: p.Upper, Levels: p.Levels, Size: p.Size}, nil
+	})
+
+	r.RegisterSizer("fixed", func(params map[string]interface{}) (SizeHandler, error) {
+		p := struct {
+			Size float64 `json:"size"`
+		}{defaultSize}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return &Size{DefaultSize: p.Size}, nil
+	})
+
+	r.RegisterFilter("volatility", func(params map[string]interface{}) (SignalFilter, error) {
+		var p struct {
+			Lookback int     `json:"lookback"`
+			Min      float64 `json:"min"`
+			Max      float64 `json:"max"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Lookback < 2 {
+			return nil, fmt.Errorf("lookback %d must be at least 2", p.Lookback)
+		}
+		return VolatilityFilter{Lookback: p.Lookback, Min: p.Min, Max: p.Max}, nil
+	})
+	r.RegisterFilter("trend", func(params map[string]interface{}) (SignalFilter, error) {
+		var p struct {
+			Lookback int `json:"lookback"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Lookback < 1 {
+			return nil, fmt.Errorf("lookback %d must be positive", p.Lookback)
+		}
+		return TrendFilter{Lookback: p.Lookback}, nil
+	})
+	r.RegisterFilter("cooldown", func(params map[string]interface{}) (SignalFilter, error) {
+		var p struct {
+			Bars     int           `json:"bars"`
+			Duration paramDuration `json:"duration"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return &CooldownFilter{Bars: p.Bars, Duration: time.Duration(p.Duration)}, nil
+	})
+	r.RegisterFilter("time_of_day", func(params map[string]interface{}) (SignalFilter, error) {
+		var p struct {
+			Start    paramDuration `json:"start"`
+			End      paramDuration `json:"end"`
+			Location string        `json:"location"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		loc, err := time.LoadLocation(p.Location)
+		if err != nil {
+			return nil, err
+		}
+		return TimeOfDayFilter{Start: time.Duration(p.Start), End: time.Duration(p.End), Location: loc}, nil
+	})
+
+	r.RegisterData("csv", func(params map[string]interface{}) ([]DataEventHandler, error) {
+		var p struct {
+			Path   string `json:"path"`
+			Symbol string `json:"symbol"`
+		}
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Path == "" || p.Symbol == "" {
+			return nil, errors.New("csv data needs a path and a symbol")
+		}
+		f, err := OpenObject(context.Background(), p.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadBarsCSV(f, p.Symbol)
+	})
+
+	return r
+}
@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Replay paces a test to a speed, so its trading can be watched bar by bar on the
+// dashboard. The data events of a time count as one bar. The speed can be changed and the
+// replay paused, resumed and stepped while the test runs, also over HTTP from the dashboard.
+type Replay struct {
+	mu     sync.Mutex
+	speed  float64 // bars per second, 0 for full speed
+	paused bool
+	steps  int           // bars to replay while paused
+	wake   chan struct{} // closed when the settings change
+}
+
+// NewReplay creates a replay at the speed in bars per second
+func NewReplay(speed float64) *Replay {
+	return &Replay{speed: speed}
+}
+
+// SetSpeed sets the speed in bars per second, 0 runs at full speed
+func (r *Replay) SetSpeed(speed float64) {
+	r.change(func() { r.speed = speed })
+}
+
+// Speed returns the speed in bars per second
+func (r *Replay) Speed() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.speed
+}
+
+// Pause stops the test before the next bar
+func (r *Replay) Pause() {
+	r.change(func() { r.paused = true })
+}
+
+// Resume continues a paused test
+func (r *Replay) Resume() {
+	r.change(func() { r.paused, r.steps = false, 0 })
+}
+
+// Step replays a single bar of a paused test
+func (r *Replay) Step() {
+	r.change(func() { r.steps++ })
+}
+
+// Paused returns true if the replay is paused
+func (r *Replay) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// change applies a settings change and wakes the waiting test
+func (r *Replay) change(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn()
+	if r.wake != nil {
+		close(r.wake)
+		r.wake = nil
+	}
+}
+
+// wait blocks until the next bar is due, the replay is stepped or the context is done
+func (r *Replay) wait(ctx context.Context) error {
+	start := time.Now()
+	for {
+		r.mu.Lock()
+		if r.paused && r.steps > 0 {
+			r.steps--
+			r.mu.Unlock()
+			return nil
+		}
+		if r.wake == nil {
+			r.wake = make(chan struct{})
+		}
+		paused, speed, wake := r.paused, r.speed, r.wake
+		r.mu.Unlock()
+
+		if !paused && speed <= 0 {
+			return nil
+		}
+		var due <-chan time.Time
+		if !paused {
+			// the time already waited counts towards a changed speed
+			d := time.Duration(float64(time.Second)/speed) - time.Since(start)
+			if d <= 0 {
+				return nil
+			}
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-due:
+			return nil
+		case <-wake:
+		}
+	}
+}
+
+// ServeHTTP returns the replay state as JSON and changes it on POST requests with the
+// action pause, resume, step or speed with the speed as value
+func (r *Replay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		switch req.FormValue("action") {
+		case "pause":
+			r.Pause()
+		case "resume":
+			r.Resume()
+		case "step":
+			r.Step()
+		case "speed":
+			speed, err := strconv.ParseFloat(req.FormValue("value"), 64)
+			if err != nil || speed < 0 {
+				http.Error(w, "invalid speed", http.StatusBadRequest)
+				return
+			}
+			r.SetSpeed(speed)
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Speed  float64 `json:"speed"`
+		Paused bool    `json:"paused"`
+	}{r.Speed(), r.Paused()})
+}
+
+// SetReplay paces the test with the replay, e.g. to watch it on the dashboard
+func (t *Test) SetReplay(r *Replay) {
+	t.replay = r
+}
+
+// paceReplay waits for the replay before the next bar of the data stream
+func (t *Test) paceReplay(ctx context.Context, paced *time.Time) error {
+	stream := t.data.Stream()
+	if len(stream) == 0 || !t.includes(stream[0].GetSymbol()) || stream[0].GetTime().Equal(*paced) {
+		return nil
+	}
+	*paced = stream[0].GetTime()
+	return t.replay.wait(ctx)
+}
@@ -0,0 +1,240 @@
+package backtest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// reportTemplate is the layout of the self-contained HTML report
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) float64 { return v * 100 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: right; }
+th { background: #f4f4f4; }
+td.label { text-align: left; }
+.pos { color: #2ca02c; }
+.neg { color: #d62728; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table>
+{{range .Summary}}<tr><td class="label">{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+
+<h2>Equity</h2>
+{{.EquityChart}}
+
+<h2>Drawdown</h2>
+{{.DrawdownChart}}
+
+{{if .Drawdowns}}<h2>Drawdown episodes</h2>
+<table>
+<tr><th>Depth</th><th>Start</th><th>Trough</th><th>Recovery</th><th>Duration</th><th>Recovery duration</th></tr>
+{{range .Drawdowns}}<tr><td class="neg">{{printf "%.2f%%" (percent .Depth)}}</td><td>{{.Start.Format "2006-01-02 15:04"}}</td><td>{{.Trough.Format "2006-01-02 15:04"}}</td><td>{{if .Recovered}}{{.Recovery.Format "2006-01-02 15:04"}}{{else}}not recovered{{end}}</td><td>{{.Duration}}</td><td>{{if .Recovered}}{{.RecoveryDuration}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Monthly returns</h2>
+{{.ReturnsTable}}
+
+<h2>Symbols</h2>
+<table>
+<tr><th>Symbol</th><th>PnL</th><th>Trades</th><th>Wins</th><th>Win rate</th><th>Traded value</th><th>Cost</th></tr>
+{{range .Symbols}}<tr><td class="label">{{.Symbol}}</td><td class="{{if lt .PnL 0.0}}neg{{else}}pos{{end}}">{{printf "%.2f" .PnL}}</td><td>{{.Trades}}</td><td>{{.Wins}}</td><td>{{printf "%.2f%%" (percent .WinRate)}}</td><td>{{printf "%.2f" .TradedValue}}</td><td>{{printf "%.2f" .Cost}}</td></tr>
+{{end}}</table>
+
+{{if .Strategies}}<h2>Strategies</h2>
+<table>
+<tr><th>Strategy</th><th>PnL</th><th>Return</th><th>Sharpe ratio</th><th>Fills</th><th>Fees</th><th>Trades</th><th>Win rate</th></tr>
+{{range .Strategies}}<tr><td class="label">{{.Tag}}</td><td class="{{if lt .PnL 0.0}}neg{{else}}pos{{end}}">{{printf "%.2f" .PnL}}</td><td>{{printf "%.2f%%" (percent .Return)}}</td><td>{{printf "%.4f" .Sharpe}}</td><td>{{.Fills}}</td><td>{{printf "%.2f" .Fees}}</td><td>{{.Trades}}</td><td>{{printf "%.2f%%" (percent .WinRate)}}</td></tr>
+{{end}}</table>
+
+{{end}}{{if .Annotations}}<h2>Annotations</h2>
+<table>
+<tr><th>Time</th><th>Annotation</th></tr>
+{{range .Annotations}}<tr><td>{{.Time.Format "2006-01-02 15:04"}}</td><td class="label">{{.Label}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Transactions</h2>
+<table>
+<tr><th>#</th><th>Time</th><th>Symbol</th><th>Action</th><th>Qty</th><th>Price</th><th>Cost</th><th>Net value</th></tr>
+{{range $i, $t := .Transactions}}<tr><td>{{$i}}</td><td>{{$t.GetTime.Format "2006-01-02 15:04"}}</td><td class="label">{{$t.GetSymbol}}</td><td class="label">{{$t.GetDirection}}</td><td>{{$t.GetQty}}</td><td>{{$t.GetPrice}}</td><td>{{$t.GetCost}}</td><td>{{$t.NetValue}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// reportDrawdowns is the number of drawdown episodes listed in the report
+const reportDrawdowns = 10
+
+// reportMetric is a single line of the report summary
+type reportMetric struct {
+	Name  string
+	Value string
+}
+
+// WriteReport writes a self-contained HTML report with the summary metrics, the equity
+// and drawdown charts, the monthly returns table and the list of transactions
+func (s *Statistic) WriteReport(w io.Writer) error {
+	var equityChart, drawdownChart, returnsTable bytes.Buffer
+	opts := ChartOptions{Format: ChartSVG, Width: 1024, Height: 400}
+	if len(s.equity) > 1 {
+		if err := s.WriteEquityChart(&equityChart, opts); err != nil {
+			return err
+		}
+		if err := s.WriteDrawdownChart(&drawdownChart, opts); err != nil {
+			return err
+		}
+	}
+
+	// the deepest drawdown episodes
+	drawdowns := s.DrawdownEpisodes(0)
+	if len(drawdowns) > reportDrawdowns {
+		drawdowns = drawdowns[:reportDrawdowns]
+	}
+
+	// transactions are numbered from 1
+	transactions := make(map[int]FillEvent)
+	for i, t := range s.transactionHistory {
+		transactions[i+1] = t
+	}
+
+	if err := s.ReturnsTable().WriteHTML(&returnsTable); err != nil {
+		return err
+	}
+
+	return reportTemplate.Execute(w, map[string]interface{}{
+		"Title":         "Backtest report",
+		"Generated":     time.Now().Format("2006-01-02 15:04"),
+		"Summary":       s.reportSummary(),
+		"EquityChart":   template.HTML(equityChart.String()),
+		"DrawdownChart": template.HTML(drawdownChart.String()),
+		"ReturnsTable":  template.HTML(returnsTable.String()),
+		"Symbols":       s.SymbolStatistics(),
+		"Strategies":    s.StrategyStatistics(),
+		"Drawdowns":     drawdowns,
+		"Annotations":   s.annotations,
+		"Transactions":  transactions,
+	})
+}
+
+// reportSummary returns the summary metrics of the report
+func (s *Statistic) reportSummary() []reportMetric {
+	return s.summary(PrintOptions{Precision: 2})
+}
+
+// summary returns the summary metrics formatted with the print options
+func (s Statistic) summary(opts PrintOptions) []reportMetric {
+	metrics := []reportMetric{
+		{"Initial cash", opts.money(s.initialCash)},
+	}
+	if last, ok := s.lastEquityPoint(); ok {
+		metrics = append(metrics, reportMetric{"Final equity", opts.money(last.equity)})
+	}
+	if total, err := s.TotalEquityReturn(); err == nil {
+		metrics = append(metrics, reportMetric{"Total return", opts.percent(total)})
+	}
+	if cagr, err := s.CompoundAnnualGrowthRate(); err == nil {
+		metrics = append(metrics, reportMetric{"CAGR", opts.percent(cagr)})
+	}
+
+	metrics = append(metrics,
+		reportMetric{"Max drawdown", opts.percent(s.MaxDrawdown())},
+		reportMetric{"Max drawdown time", s.MaxDrawdownTime().Format("2006-01-02 15:04")},
+		reportMetric{"Max drawdown duration", s.MaxDrawdownDuration().String()},
+		reportMetric{"Time in market", opts.percent(s.TimeInMarket())},
+		reportMetric{"Average exposure", opts.percent(s.AverageExposure())},
+		reportMetric{"Ulcer index", opts.ratio(s.UlcerIndex())},
+		reportMetric{"Pain index", opts.ratio(s.PainIndex())},
+		reportMetric{"Sharpe ratio", opts.ratio(s.SharpRatio(0))},
+		reportMetric{"Sortino ratio", opts.ratio(s.SortinoRatio(0))},
+		reportMetric{"Transactions", fmt.Sprint(len(s.transactionHistory))},
+		reportMetric{"Events", fmt.Sprint(len(s.eventHistory))},
+	)
+
+	if recovery, err := s.RecoveryFactor(); err == nil {
+		metrics = append(metrics, reportMetric{"Recovery factor", opts.ratio(recovery)})
+	}
+	if vol, err := s.Volatility(); err == nil {
+		downside, _ := s.DownsideDeviation(0)
+		metrics = append(metrics,
+			reportMetric{"Volatility", opts.percent(vol)},
+			reportMetric{"Downside deviation", opts.percent(downside)},
+		)
+	}
+	if v, err := s.ValueAtRisk(0.95); err == nil {
+		cvar, _ := s.ConditionalValueAtRisk(0.95)
+		metrics = append(metrics,
+			reportMetric{"Value at risk (95%)", opts.percent(v)},
+			reportMetric{"Conditional value at risk (95%)", opts.percent(cvar)},
+		)
+	}
+	if skew, err := s.Skewness(); err == nil {
+		metrics = append(metrics, reportMetric{"Skewness", opts.ratio(skew)})
+	}
+	if kurtosis, err := s.Kurtosis(); err == nil {
+		metrics = append(metrics, reportMetric{"Excess kurtosis", opts.ratio(kurtosis)})
+	}
+	if best, err := s.BestReturn(); err == nil {
+		worst, _ := s.WorstReturn()
+		metrics = append(metrics,
+			reportMetric{"Best period return", opts.percent(best)},
+			reportMetric{"Worst period return", opts.percent(worst)},
+		)
+	}
+	if beta, err := s.Beta(); err == nil {
+		alpha, _ := s.Alpha(0)
+		trackingError, _ := s.TrackingError()
+		metrics = append(metrics,
+			reportMetric{"Beta", opts.ratio(beta)},
+			reportMetric{"Alpha", opts.ratio(alpha)},
+			reportMetric{"Tracking error", opts.ratio(trackingError)},
+		)
+		if ir, err := s.InformationRatio(); err == nil {
+			metrics = append(metrics, reportMetric{"Information ratio", opts.ratio(ir)})
+		}
+	}
+
+	costs := s.Costs()
+	metrics = append(metrics,
+		reportMetric{"Traded value", opts.money(costs.TradedValue)},
+		reportMetric{"Turnover", opts.number(costs.Turnover)},
+		reportMetric{"Commission", opts.money(costs.Commission)},
+		reportMetric{"Exchange fees", opts.money(costs.ExchangeFee)},
+		reportMetric{"Total cost", opts.money(costs.Cost)},
+		reportMetric{"Slippage", opts.money(costs.Slippage)},
+		reportMetric{"Cost share of gross profit", opts.percent(costs.CostShare)},
+	)
+
+	trades := s.TradeStatistics()
+	metrics = append(metrics,
+		reportMetric{"Round-trip trades", fmt.Sprint(trades.Trades)},
+		reportMetric{"Win rate", opts.percent(trades.WinRate)},
+		reportMetric{"Profit factor", opts.ratio(trades.ProfitFactor)},
+		reportMetric{"Average win", opts.money(trades.AverageWin)},
+		reportMetric{"Average loss", opts.money(trades.AverageLoss)},
+		reportMetric{"Largest win", opts.money(trades.LargestWin)},
+		reportMetric{"Largest loss", opts.money(trades.LargestLoss)},
+		reportMetric{"Expectancy", opts.money(trades.Expectancy)},
+		reportMetric{"Kelly fraction", opts.ratio(trades.Kelly)},
+		reportMetric{"Optimal f", opts.number(trades.OptimalF)},
+		reportMetric{"Average holding time", trades.AverageDuration.String()},
+		reportMetric{"Median holding time", trades.MedianDuration.String()},
+		reportMetric{"Max holding time", trades.MaxDuration.String()},
+		reportMetric{"Average time between trades", trades.AverageTimeBetween.String()},
+	)
+
+	return metrics
+}
@@ -0,0 +1,143 @@
+package backtest
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// PeriodReturn is the equity return of a calendar period
+type PeriodReturn struct {
+	Start  time.Time
+	Return float64
+}
+
+// ReturnsTableRow is a calendar year of the returns table
+type ReturnsTableRow struct {
+	Year   int          `json:"year"`
+	Months [12]*float64 `json:"months"` // nil for months without equity points
+	Total  float64      `json:"total"`
+}
+
+// ReturnsTable holds the monthly and yearly returns by calendar year
+type ReturnsTable []ReturnsTableRow
+
+// returnsTableTemplate renders the returns table as HTML fragment
+var returnsTableTemplate = template.Must(template.New("returns").Funcs(template.FuncMap{
+	"percent": formatReturn,
+	"class": func(v *float64) string {
+		switch {
+		case v == nil:
+			return ""
+		case *v < 0:
+			return "neg"
+		}
+		return "pos"
+	},
+	"ptr": func(v float64) *float64 { return &v },
+}).Parse(`<table class="returns">
+<tr><th>Year</th>{{range .Months}}<th>{{.}}</th>{{end}}<th>Year</th></tr>
+{{range .Rows}}<tr><td class="label">{{.Year}}</td>{{range .Months}}<td class="{{class .}}">{{percent .}}</td>{{end}}<td class="{{class (ptr .Total)}}">{{percent (ptr .Total)}}</td></tr>
+{{end}}</table>
+`))
+
+// MonthlyReturns returns the equity return of each calendar month
+func (s Statistic) MonthlyReturns() []PeriodReturn {
+	return s.periodReturns(func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	})
+}
+
+// YearlyReturns returns the equity return of each calendar year
+func (s Statistic) YearlyReturns() []PeriodReturn {
+	return s.periodReturns(func(t time.Time) time.Time {
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	})
+}
+
+// periodReturns returns the equity return of each period, relative to the last equity
+// of the previous period or the first equity point for the first period
+func (s Statistic) periodReturns(start func(time.Time) time.Time) []PeriodReturn {
+	if len(s.equity) == 0 {
+		return nil
+	}
+
+	var returns []PeriodReturn
+	base := s.equity[0].equity
+	for i, e := range s.equity {
+		// last equity point of the period
+		if i+1 < len(s.equity) && start(e.timestamp).Equal(start(s.equity[i+1].timestamp)) {
+			continue
+		}
+
+		r := PeriodReturn{Start: start(e.timestamp)}
+		if base != 0 {
+			r.Return = (e.equity - base) / base
+		}
+		returns = append(returns, r)
+		base = e.equity
+	}
+
+	return returns
+}
+
+// ReturnsTable returns the monthly returns with the total return of each calendar year
+func (s Statistic) ReturnsTable() ReturnsTable {
+	var table ReturnsTable
+	for _, r := range s.YearlyReturns() {
+		table = append(table, ReturnsTableRow{Year: r.Start.Year(), Total: r.Return})
+	}
+
+	for _, r := range s.MonthlyReturns() {
+		for i := range table {
+			if table[i].Year == r.Start.Year() {
+				v := r.Return
+				table[i].Months[r.Start.Month()-1] = &v
+			}
+		}
+	}
+
+	return table
+}
+
+// WriteText writes the returns table as aligned text
+func (t ReturnsTable) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "Year\t%s\tYear\t\n", strings.Join(monthNames(), "\t"))
+	for _, row := range t {
+		fmt.Fprintf(tw, "%d\t", row.Year)
+		for _, m := range row.Months {
+			fmt.Fprintf(tw, "%s\t", formatReturn(m))
+		}
+		fmt.Fprintf(tw, "%s\t\n", formatReturn(&row.Total))
+	}
+	return tw.Flush()
+}
+
+// WriteHTML writes the returns table as HTML table, cells are classed pos or neg
+func (t ReturnsTable) WriteHTML(w io.Writer) error {
+	return returnsTableTemplate.Execute(w, map[string]interface{}{
+		"Months": monthNames(),
+		"Rows":   t,
+	})
+}
+
+// monthNames returns the short names of the months
+func monthNames() []string {
+	var months []string
+	for m := time.January; m <= time.December; m++ {
+		months = append(months, m.String()[:3])
+	}
+	return months
+}
+
+// formatReturn formats a return in percent, nil as empty string
+func formatReturn(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", *v*100)
+}
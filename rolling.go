@@ -0,0 +1,58 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// SeriesPoint is a point of a time series calculated from the equity
+type SeriesPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// RollingVolatility returns the standard deviation of the equity returns over a rolling
+// window of periods, the first point is at the end of the first full window
+func (s Statistic) RollingVolatility(window int) []SeriesPoint {
+	return s.rolling(window, func(returns []float64) (float64, bool) {
+		return stat.StdDev(returns, nil), true
+	})
+}
+
+// RollingSharpe returns the annualized Sharp ratio of the equity returns compared to an annual
+// risk free rate over a rolling window of periods, windows without volatility are skipped
+func (s Statistic) RollingSharpe(window int, riskfree float64) []SeriesPoint {
+	periods := s.PeriodsPerYear()
+	return s.rolling(window, func(returns []float64) (float64, bool) {
+		mean, stddev := stat.MeanStdDev(returns, nil)
+		if stddev == 0 {
+			return 0, false
+		}
+		return (mean - riskfree/periods) / stddev * math.Sqrt(periods), true
+	})
+}
+
+// rolling applies fn to every window of equity returns after the first equity point
+func (s Statistic) rolling(window int, fn func([]float64) (float64, bool)) []SeriesPoint {
+	if window < 2 || len(s.equity) <= window {
+		return nil
+	}
+
+	returns := make([]float64, 0, len(s.equity)-1)
+	for _, e := range s.equity[1:] {
+		returns = append(returns, e.equityReturn)
+	}
+
+	var points []SeriesPoint
+	for end := window; end <= len(returns); end++ {
+		v, ok := fn(returns[end-window : end])
+		if !ok {
+			continue
+		}
+		// returns[i] belongs to the equity point i+1
+		points = append(points, SeriesPoint{Timestamp: s.equity[end].timestamp, Value: v})
+	}
+	return points
+}
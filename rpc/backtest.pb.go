@@ -0,0 +1,1050 @@
+// The Backtest service runs backtests for remote clients, e.g. a research UI. Regenerate
+// the Go code with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	       --go-grpc_out=. --go-grpc_opt=paths=source_relative rpc/backtest.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: rpc/backtest.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_QUEUED      Status = 1
+	Status_STATUS_RUNNING     Status = 2
+	Status_STATUS_DONE        Status = 3
+	Status_STATUS_FAILED      Status = 4
+	Status_STATUS_CANCELLED   Status = 5
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_QUEUED",
+		2: "STATUS_RUNNING",
+		3: "STATUS_DONE",
+		4: "STATUS_FAILED",
+		5: "STATUS_CANCELLED",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_QUEUED":      1,
+		"STATUS_RUNNING":     2,
+		"STATUS_DONE":        3,
+		"STATUS_FAILED":      4,
+		"STATUS_CANCELLED":   5,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_rpc_backtest_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_rpc_backtest_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{0}
+}
+
+type SubmitRunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// spec of the test, a JSON backtest.Spec or a YAML or TOML config
+	Spec []byte `protobuf:"bytes,1,opt,name=spec,proto3" json:"spec,omitempty"`
+	// format of the spec: json, yaml or toml, json if empty
+	Format        string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitRunRequest) Reset() {
+	*x = SubmitRunRequest{}
+	mi := &file_rpc_backtest_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRunRequest) ProtoMessage() {}
+
+func (x *SubmitRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRunRequest.ProtoReflect.Descriptor instead.
+func (*SubmitRunRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitRunRequest) GetSpec() []byte {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+func (x *SubmitRunRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type StreamProgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamProgressRequest) Reset() {
+	*x = StreamProgressRequest{}
+	mi := &file_rpc_backtest_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamProgressRequest) ProtoMessage() {}
+
+func (x *StreamProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamProgressRequest.ProtoReflect.Descriptor instead.
+func (*StreamProgressRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamProgressRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetResultsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// equity points returned at most, sampled evenly with the last point kept, all if 0
+	MaxPoints int32 `protobuf:"varint,2,opt,name=max_points,json=maxPoints,proto3" json:"max_points,omitempty"`
+	// return the complete results as JSON, which may exceed the message size limit
+	IncludeJson   bool `protobuf:"varint,3,opt,name=include_json,json=includeJson,proto3" json:"include_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResultsRequest) Reset() {
+	*x = GetResultsRequest{}
+	mi := &file_rpc_backtest_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResultsRequest) ProtoMessage() {}
+
+func (x *GetResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResultsRequest.ProtoReflect.Descriptor instead.
+func (*GetResultsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetResultsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetResultsRequest) GetMaxPoints() int32 {
+	if x != nil {
+		return x.MaxPoints
+	}
+	return 0
+}
+
+func (x *GetResultsRequest) GetIncludeJson() bool {
+	if x != nil {
+		return x.IncludeJson
+	}
+	return false
+}
+
+type CancelRunRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRunRequest) Reset() {
+	*x = CancelRunRequest{}
+	mi := &file_rpc_backtest_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRunRequest) ProtoMessage() {}
+
+func (x *CancelRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRunRequest.ProtoReflect.Descriptor instead.
+func (*CancelRunRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CancelRunRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Run struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status   Status                 `protobuf:"varint,2,opt,name=status,proto3,enum=backtest.v1.Status" json:"status,omitempty"`
+	Progress *Progress              `protobuf:"bytes,3,opt,name=progress,proto3" json:"progress,omitempty"`
+	Error    string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// reason of a stop rule ending the run early
+	StopReason string `protobuf:"bytes,5,opt,name=stop_reason,json=stopReason,proto3" json:"stop_reason,omitempty"`
+	// ID of the run in the result store of the server
+	StoreId       string                 `protobuf:"bytes,6,opt,name=store_id,json=storeId,proto3" json:"store_id,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created,proto3" json:"created,omitempty"`
+	Started       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=started,proto3" json:"started,omitempty"`
+	Finished      *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=finished,proto3" json:"finished,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Run) Reset() {
+	*x = Run{}
+	mi := &file_rpc_backtest_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Run) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Run) ProtoMessage() {}
+
+func (x *Run) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Run.ProtoReflect.Descriptor instead.
+func (*Run) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Run) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Run) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *Run) GetProgress() *Progress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *Run) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Run) GetStopReason() string {
+	if x != nil {
+		return x.StopReason
+	}
+	return ""
+}
+
+func (x *Run) GetStoreId() string {
+	if x != nil {
+		return x.StoreId
+	}
+	return ""
+}
+
+func (x *Run) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Run) GetStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *Run) GetFinished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Finished
+	}
+	return nil
+}
+
+type Progress struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Bars     int64                  `protobuf:"varint,1,opt,name=bars,proto3" json:"bars,omitempty"`
+	Total    int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Fraction float64                `protobuf:"fixed64,3,opt,name=fraction,proto3" json:"fraction,omitempty"`
+	// time of the last processed data event
+	Time          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	Equity        float64                `protobuf:"fixed64,5,opt,name=equity,proto3" json:"equity,omitempty"`
+	EtaSeconds    float64                `protobuf:"fixed64,6,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_rpc_backtest_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Progress) GetBars() int64 {
+	if x != nil {
+		return x.Bars
+	}
+	return 0
+}
+
+func (x *Progress) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Progress) GetFraction() float64 {
+	if x != nil {
+		return x.Fraction
+	}
+	return 0
+}
+
+func (x *Progress) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Progress) GetEquity() float64 {
+	if x != nil {
+		return x.Equity
+	}
+	return 0
+}
+
+func (x *Progress) GetEtaSeconds() float64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
+type Results struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Metrics      *Metrics               `protobuf:"bytes,2,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Equity       []*EquityPoint         `protobuf:"bytes,3,rep,name=equity,proto3" json:"equity,omitempty"`
+	Transactions []*Transaction         `protobuf:"bytes,4,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	// complete results in the JSON export schema of the package, if requested
+	ResultJson    []byte `protobuf:"bytes,5,opt,name=result_json,json=resultJson,proto3" json:"result_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Results) Reset() {
+	*x = Results{}
+	mi := &file_rpc_backtest_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Results) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Results) ProtoMessage() {}
+
+func (x *Results) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Results.ProtoReflect.Descriptor instead.
+func (*Results) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Results) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Results) GetMetrics() *Metrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *Results) GetEquity() []*EquityPoint {
+	if x != nil {
+		return x.Equity
+	}
+	return nil
+}
+
+func (x *Results) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Results) GetResultJson() []byte {
+	if x != nil {
+		return x.ResultJson
+	}
+	return nil
+}
+
+// Metrics are the main metrics of a run, metrics which can't be calculated are unset
+type Metrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InitialCash   float64                `protobuf:"fixed64,1,opt,name=initial_cash,json=initialCash,proto3" json:"initial_cash,omitempty"`
+	FinalEquity   *float64               `protobuf:"fixed64,2,opt,name=final_equity,json=finalEquity,proto3,oneof" json:"final_equity,omitempty"`
+	TotalReturn   *float64               `protobuf:"fixed64,3,opt,name=total_return,json=totalReturn,proto3,oneof" json:"total_return,omitempty"`
+	Cagr          *float64               `protobuf:"fixed64,4,opt,name=cagr,proto3,oneof" json:"cagr,omitempty"`
+	MaxDrawdown   *float64               `protobuf:"fixed64,5,opt,name=max_drawdown,json=maxDrawdown,proto3,oneof" json:"max_drawdown,omitempty"`
+	SharpeRatio   *float64               `protobuf:"fixed64,6,opt,name=sharpe_ratio,json=sharpeRatio,proto3,oneof" json:"sharpe_ratio,omitempty"`
+	SortinoRatio  *float64               `protobuf:"fixed64,7,opt,name=sortino_ratio,json=sortinoRatio,proto3,oneof" json:"sortino_ratio,omitempty"`
+	Volatility    *float64               `protobuf:"fixed64,8,opt,name=volatility,proto3,oneof" json:"volatility,omitempty"`
+	Transactions  int64                  `protobuf:"varint,9,opt,name=transactions,proto3" json:"transactions,omitempty"`
+	Trades        int64                  `protobuf:"varint,10,opt,name=trades,proto3" json:"trades,omitempty"`
+	WinRate       float64                `protobuf:"fixed64,11,opt,name=win_rate,json=winRate,proto3" json:"win_rate,omitempty"`
+	NetProfit     float64                `protobuf:"fixed64,12,opt,name=net_profit,json=netProfit,proto3" json:"net_profit,omitempty"`
+	Cost          float64                `protobuf:"fixed64,13,opt,name=cost,proto3" json:"cost,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metrics) Reset() {
+	*x = Metrics{}
+	mi := &file_rpc_backtest_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metrics) ProtoMessage() {}
+
+func (x *Metrics) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metrics.ProtoReflect.Descriptor instead.
+func (*Metrics) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Metrics) GetInitialCash() float64 {
+	if x != nil {
+		return x.InitialCash
+	}
+	return 0
+}
+
+func (x *Metrics) GetFinalEquity() float64 {
+	if x != nil && x.FinalEquity != nil {
+		return *x.FinalEquity
+	}
+	return 0
+}
+
+func (x *Metrics) GetTotalReturn() float64 {
+	if x != nil && x.TotalReturn != nil {
+		return *x.TotalReturn
+	}
+	return 0
+}
+
+func (x *Metrics) GetCagr() float64 {
+	if x != nil && x.Cagr != nil {
+		return *x.Cagr
+	}
+	return 0
+}
+
+func (x *Metrics) GetMaxDrawdown() float64 {
+	if x != nil && x.MaxDrawdown != nil {
+		return *x.MaxDrawdown
+	}
+	return 0
+}
+
+func (x *Metrics) GetSharpeRatio() float64 {
+	if x != nil && x.SharpeRatio != nil {
+		return *x.SharpeRatio
+	}
+	return 0
+}
+
+func (x *Metrics) GetSortinoRatio() float64 {
+	if x != nil && x.SortinoRatio != nil {
+		return *x.SortinoRatio
+	}
+	return 0
+}
+
+func (x *Metrics) GetVolatility() float64 {
+	if x != nil && x.Volatility != nil {
+		return *x.Volatility
+	}
+	return 0
+}
+
+func (x *Metrics) GetTransactions() int64 {
+	if x != nil {
+		return x.Transactions
+	}
+	return 0
+}
+
+func (x *Metrics) GetTrades() int64 {
+	if x != nil {
+		return x.Trades
+	}
+	return 0
+}
+
+func (x *Metrics) GetWinRate() float64 {
+	if x != nil {
+		return x.WinRate
+	}
+	return 0
+}
+
+func (x *Metrics) GetNetProfit() float64 {
+	if x != nil {
+		return x.NetProfit
+	}
+	return 0
+}
+
+func (x *Metrics) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+type EquityPoint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Equity        float64                `protobuf:"fixed64,2,opt,name=equity,proto3" json:"equity,omitempty"`
+	Drawdown      float64                `protobuf:"fixed64,3,opt,name=drawdown,proto3" json:"drawdown,omitempty"`
+	BuyAndHold    float64                `protobuf:"fixed64,4,opt,name=buy_and_hold,json=buyAndHold,proto3" json:"buy_and_hold,omitempty"`
+	Exposure      float64                `protobuf:"fixed64,5,opt,name=exposure,proto3" json:"exposure,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EquityPoint) Reset() {
+	*x = EquityPoint{}
+	mi := &file_rpc_backtest_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EquityPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EquityPoint) ProtoMessage() {}
+
+func (x *EquityPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EquityPoint.ProtoReflect.Descriptor instead.
+func (*EquityPoint) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EquityPoint) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *EquityPoint) GetEquity() float64 {
+	if x != nil {
+		return x.Equity
+	}
+	return 0
+}
+
+func (x *EquityPoint) GetDrawdown() float64 {
+	if x != nil {
+		return x.Drawdown
+	}
+	return 0
+}
+
+func (x *EquityPoint) GetBuyAndHold() float64 {
+	if x != nil {
+		return x.BuyAndHold
+	}
+	return 0
+}
+
+func (x *EquityPoint) GetExposure() float64 {
+	if x != nil {
+		return x.Exposure
+	}
+	return 0
+}
+
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Symbol        string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Direction     string                 `protobuf:"bytes,3,opt,name=direction,proto3" json:"direction,omitempty"`
+	Qty           float64                `protobuf:"fixed64,4,opt,name=qty,proto3" json:"qty,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	Cost          float64                `protobuf:"fixed64,6,opt,name=cost,proto3" json:"cost,omitempty"`
+	Tags          []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_rpc_backtest_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_backtest_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_rpc_backtest_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Transaction) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Transaction) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Transaction) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *Transaction) GetQty() float64 {
+	if x != nil {
+		return x.Qty
+	}
+	return 0
+}
+
+func (x *Transaction) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Transaction) GetCost() float64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *Transaction) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+var File_rpc_backtest_proto protoreflect.FileDescriptor
+
+const file_rpc_backtest_proto_rawDesc = "" +
+	"\n" +
+	"\x12rpc/backtest.proto\x12\vbacktest.v1\x1a\x1fgoogle/protobuf/timestamp.proto\">\n" +
+	"\x10SubmitRunRequest\x12\x12\n" +
+	"\x04spec\x18\x01 \x01(\fR\x04spec\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\"'\n" +
+	"\x15StreamProgressRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"e\n" +
+	"\x11GetResultsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"max_points\x18\x02 \x01(\x05R\tmaxPoints\x12!\n" +
+	"\finclude_json\x18\x03 \x01(\bR\vincludeJson\"\"\n" +
+	"\x10CancelRunRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xeb\x02\n" +
+	"\x03Run\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12+\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x13.backtest.v1.StatusR\x06status\x121\n" +
+	"\bprogress\x18\x03 \x01(\v2\x15.backtest.v1.ProgressR\bprogress\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1f\n" +
+	"\vstop_reason\x18\x05 \x01(\tR\n" +
+	"stopReason\x12\x19\n" +
+	"\bstore_id\x18\x06 \x01(\tR\astoreId\x124\n" +
+	"\acreated\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
+	"\astarted\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x126\n" +
+	"\bfinished\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\bfinished\"\xb9\x01\n" +
+	"\bProgress\x12\x12\n" +
+	"\x04bars\x18\x01 \x01(\x03R\x04bars\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x1a\n" +
+	"\bfraction\x18\x03 \x01(\x01R\bfraction\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x16\n" +
+	"\x06equity\x18\x05 \x01(\x01R\x06equity\x12\x1f\n" +
+	"\veta_seconds\x18\x06 \x01(\x01R\n" +
+	"etaSeconds\"\xda\x01\n" +
+	"\aResults\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\ametrics\x18\x02 \x01(\v2\x14.backtest.v1.MetricsR\ametrics\x120\n" +
+	"\x06equity\x18\x03 \x03(\v2\x18.backtest.v1.EquityPointR\x06equity\x12<\n" +
+	"\ftransactions\x18\x04 \x03(\v2\x18.backtest.v1.TransactionR\ftransactions\x12\x1f\n" +
+	"\vresult_json\x18\x05 \x01(\fR\n" +
+	"resultJson\"\xac\x04\n" +
+	"\aMetrics\x12!\n" +
+	"\finitial_cash\x18\x01 \x01(\x01R\vinitialCash\x12&\n" +
+	"\ffinal_equity\x18\x02 \x01(\x01H\x00R\vfinalEquity\x88\x01\x01\x12&\n" +
+	"\ftotal_return\x18\x03 \x01(\x01H\x01R\vtotalReturn\x88\x01\x01\x12\x17\n" +
+	"\x04cagr\x18\x04 \x01(\x01H\x02R\x04cagr\x88\x01\x01\x12&\n" +
+	"\fmax_drawdown\x18\x05 \x01(\x01H\x03R\vmaxDrawdown\x88\x01\x01\x12&\n" +
+	"\fsharpe_ratio\x18\x06 \x01(\x01H\x04R\vsharpeRatio\x88\x01\x01\x12(\n" +
+	"\rsortino_ratio\x18\a \x01(\x01H\x05R\fsortinoRatio\x88\x01\x01\x12#\n" +
+	"\n" +
+	"volatility\x18\b \x01(\x01H\x06R\n" +
+	"volatility\x88\x01\x01\x12\"\n" +
+	"\ftransactions\x18\t \x01(\x03R\ftransactions\x12\x16\n" +
+	"\x06trades\x18\n" +
+	" \x01(\x03R\x06trades\x12\x19\n" +
+	"\bwin_rate\x18\v \x01(\x01R\awinRate\x12\x1d\n" +
+	"\n" +
+	"net_profit\x18\f \x01(\x01R\tnetProfit\x12\x12\n" +
+	"\x04cost\x18\r \x01(\x01R\x04costB\x0f\n" +
+	"\r_final_equityB\x0f\n" +
+	"\r_total_returnB\a\n" +
+	"\x05_cagrB\x0f\n" +
+	"\r_max_drawdownB\x0f\n" +
+	"\r_sharpe_ratioB\x10\n" +
+	"\x0e_sortino_ratioB\r\n" +
+	"\v_volatility\"\xaf\x01\n" +
+	"\vEquityPoint\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x16\n" +
+	"\x06equity\x18\x02 \x01(\x01R\x06equity\x12\x1a\n" +
+	"\bdrawdown\x18\x03 \x01(\x01R\bdrawdown\x12 \n" +
+	"\fbuy_and_hold\x18\x04 \x01(\x01R\n" +
+	"buyAndHold\x12\x1a\n" +
+	"\bexposure\x18\x05 \x01(\x01R\bexposure\"\xc3\x01\n" +
+	"\vTransaction\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12\x1c\n" +
+	"\tdirection\x18\x03 \x01(\tR\tdirection\x12\x10\n" +
+	"\x03qty\x18\x04 \x01(\x01R\x03qty\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12\x12\n" +
+	"\x04cost\x18\x06 \x01(\x01R\x04cost\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags*\x81\x01\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATUS_QUEUED\x10\x01\x12\x12\n" +
+	"\x0eSTATUS_RUNNING\x10\x02\x12\x0f\n" +
+	"\vSTATUS_DONE\x10\x03\x12\x11\n" +
+	"\rSTATUS_FAILED\x10\x04\x12\x14\n" +
+	"\x10STATUS_CANCELLED\x10\x052\x94\x02\n" +
+	"\bBacktest\x12<\n" +
+	"\tSubmitRun\x12\x1d.backtest.v1.SubmitRunRequest\x1a\x10.backtest.v1.Run\x12H\n" +
+	"\x0eStreamProgress\x12\".backtest.v1.StreamProgressRequest\x1a\x10.backtest.v1.Run0\x01\x12B\n" +
+	"\n" +
+	"GetResults\x12\x1e.backtest.v1.GetResultsRequest\x1a\x14.backtest.v1.Results\x12<\n" +
+	"\tCancelRun\x12\x1d.backtest.v1.CancelRunRequest\x1a\x10.backtest.v1.RunB\"Z github.com/ivtpz/backtest-go/rpcb\x06proto3"
+
+var (
+	file_rpc_backtest_proto_rawDescOnce sync.Once
+	file_rpc_backtest_proto_rawDescData []byte
+)
+
+func file_rpc_backtest_proto_rawDescGZIP() []byte {
+	file_rpc_backtest_proto_rawDescOnce.Do(func() {
+		file_rpc_backtest_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rpc_backtest_proto_rawDesc), len(file_rpc_backtest_proto_rawDesc)))
+	})
+	return file_rpc_backtest_proto_rawDescData
+}
+
+var file_rpc_backtest_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_rpc_backtest_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_rpc_backtest_proto_goTypes = []any{
+	(Status)(0),                   // 0: backtest.v1.Status
+	(*SubmitRunRequest)(nil),      // 1: backtest.v1.SubmitRunRequest
+	(*StreamProgressRequest)(nil), // 2: backtest.v1.StreamProgressRequest
+	(*GetResultsRequest)(nil),     // 3: backtest.v1.GetResultsRequest
+	(*CancelRunRequest)(nil),      // 4: backtest.v1.CancelRunRequest
+	(*Run)(nil),                   // 5: backtest.v1.Run
+	(*Progress)(nil),              // 6: backtest.v1.Progress
+	(*Results)(nil),               // 7: backtest.v1.Results
+	(*Metrics)(nil),               // 8: backtest.v1.Metrics
+	(*EquityPoint)(nil),           // 9: backtest.v1.EquityPoint
+	(*Transaction)(nil),           // 10: backtest.v1.Transaction
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_rpc_backtest_proto_depIdxs = []int32{
+	0,  // 0: backtest.v1.Run.status:type_name -> backtest.v1.Status
+	6,  // 1: backtest.v1.Run.progress:type_name -> backtest.v1.Progress
+	11, // 2: backtest.v1.Run.created:type_name -> google.protobuf.Timestamp
+	11, // 3: backtest.v1.Run.started:type_name -> google.protobuf.Timestamp
+	11, // 4: backtest.v1.Run.finished:type_name -> google.protobuf.Timestamp
+	11, // 5: backtest.v1.Progress.time:type_name -> google.protobuf.Timestamp
+	8,  // 6: backtest.v1.Results.metrics:type_name -> backtest.v1.Metrics
+	9,  // 7: backtest.v1.Results.equity:type_name -> backtest.v1.EquityPoint
+	10, // 8: backtest.v1.Results.transactions:type_name -> backtest.v1.Transaction
+	11, // 9: backtest.v1.EquityPoint.time:type_name -> google.protobuf.Timestamp
+	11, // 10: backtest.v1.Transaction.time:type_name -> google.protobuf.Timestamp
+	1,  // 11: backtest.v1.Backtest.SubmitRun:input_type -> backtest.v1.SubmitRunRequest
+	2,  // 12: backtest.v1.Backtest.StreamProgress:input_type -> backtest.v1.StreamProgressRequest
+	3,  // 13: backtest.v1.Backtest.GetResults:input_type -> backtest.v1.GetResultsRequest
+	4,  // 14: backtest.v1.Backtest.CancelRun:input_type -> backtest.v1.CancelRunRequest
+	5,  // 15: backtest.v1.Backtest.SubmitRun:output_type -> backtest.v1.Run
+	5,  // 16: backtest.v1.Backtest.StreamProgress:output_type -> backtest.v1.Run
+	7,  // 17: backtest.v1.Backtest.GetResults:output_type -> backtest.v1.Results
+	5,  // 18: backtest.v1.Backtest.CancelRun:output_type -> backtest.v1.Run
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_rpc_backtest_proto_init() }
+func file_rpc_backtest_proto_init() {
+	if File_rpc_backtest_proto != nil {
+		return
+	}
+	file_rpc_backtest_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rpc_backtest_proto_rawDesc), len(file_rpc_backtest_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rpc_backtest_proto_goTypes,
+		DependencyIndexes: file_rpc_backtest_proto_depIdxs,
+		EnumInfos:         file_rpc_backtest_proto_enumTypes,
+		MessageInfos:      file_rpc_backtest_proto_msgTypes,
+	}.Build()
+	File_rpc_backtest_proto = out.File
+	file_rpc_backtest_proto_goTypes = nil
+	file_rpc_backtest_proto_depIdxs = nil
+}
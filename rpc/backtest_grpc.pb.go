@@ -0,0 +1,253 @@
+// The Backtest service runs backtests for remote clients, e.g. a research UI. Regenerate
+// the Go code with:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	       --go-grpc_out=. --go-grpc_opt=paths=source_relative rpc/backtest.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: rpc/backtest.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Backtest_SubmitRun_FullMethodName      = "/backtest.v1.Backtest/SubmitRun"
+	Backtest_StreamProgress_FullMethodName = "/backtest.v1.Backtest/StreamProgress"
+	Backtest_GetResults_FullMethodName     = "/backtest.v1.Backtest/GetResults"
+	Backtest_CancelRun_FullMethodName      = "/backtest.v1.Backtest/CancelRun"
+)
+
+// BacktestClient is the client API for Backtest service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BacktestClient interface {
+	// SubmitRun queues a test and returns the queued run
+	SubmitRun(ctx context.Context, in *SubmitRunRequest, opts ...grpc.CallOption) (*Run, error)
+	// StreamProgress streams the run on every change until it is finished
+	StreamProgress(ctx context.Context, in *StreamProgressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Run], error)
+	// GetResults returns the results of a finished run
+	GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (*Results, error)
+	// CancelRun stops a queued or running run
+	CancelRun(ctx context.Context, in *CancelRunRequest, opts ...grpc.CallOption) (*Run, error)
+}
+
+type backtestClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBacktestClient(cc grpc.ClientConnInterface) BacktestClient {
+	return &backtestClient{cc}
+}
+
+func (c *backtestClient) SubmitRun(ctx context.Context, in *SubmitRunRequest, opts ...grpc.CallOption) (*Run, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Run)
+	err := c.cc.Invoke(ctx, Backtest_SubmitRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backtestClient) StreamProgress(ctx context.Context, in *StreamProgressRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Run], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Backtest_ServiceDesc.Streams[0], Backtest_StreamProgress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamProgressRequest, Run]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Backtest_StreamProgressClient = grpc.ServerStreamingClient[Run]
+
+func (c *backtestClient) GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (*Results, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Results)
+	err := c.cc.Invoke(ctx, Backtest_GetResults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backtestClient) CancelRun(ctx context.Context, in *CancelRunRequest, opts ...grpc.CallOption) (*Run, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Run)
+	err := c.cc.Invoke(ctx, Backtest_CancelRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BacktestServer is the server API for Backtest service.
+// All implementations must embed UnimplementedBacktestServer
+// for forward compatibility.
+type BacktestServer interface {
+	// SubmitRun queues a test and returns the queued run
+	SubmitRun(context.Context, *SubmitRunRequest) (*Run, error)
+	// StreamProgress streams the run on every change until it is finished
+	StreamProgress(*StreamProgressRequest, grpc.ServerStreamingServer[Run]) error
+	// GetResults returns the results of a finished run
+	GetResults(context.Context, *GetResultsRequest) (*Results, error)
+	// CancelRun stops a queued or running run
+	CancelRun(context.Context, *CancelRunRequest) (*Run, error)
+	mustEmbedUnimplementedBacktestServer()
+}
+
+// UnimplementedBacktestServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBacktestServer struct{}
+
+func (UnimplementedBacktestServer) SubmitRun(context.Context, *SubmitRunRequest) (*Run, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitRun not implemented")
+}
+func (UnimplementedBacktestServer) StreamProgress(*StreamProgressRequest, grpc.ServerStreamingServer[Run]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamProgress not implemented")
+}
+func (UnimplementedBacktestServer) GetResults(context.Context, *GetResultsRequest) (*Results, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResults not implemented")
+}
+func (UnimplementedBacktestServer) CancelRun(context.Context, *CancelRunRequest) (*Run, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelRun not implemented")
+}
+func (UnimplementedBacktestServer) mustEmbedUnimplementedBacktestServer() {}
+func (UnimplementedBacktestServer) testEmbeddedByValue()                  {}
+
+// UnsafeBacktestServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BacktestServer will
+// result in compilation errors.
+type UnsafeBacktestServer interface {
+	mustEmbedUnimplementedBacktestServer()
+}
+
+func RegisterBacktestServer(s grpc.ServiceRegistrar, srv BacktestServer) {
+	// If the following call pancis, it indicates UnimplementedBacktestServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Backtest_ServiceDesc, srv)
+}
+
+func _Backtest_SubmitRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BacktestServer).SubmitRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backtest_SubmitRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BacktestServer).SubmitRun(ctx, req.(*SubmitRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backtest_StreamProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamProgressRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BacktestServer).StreamProgress(m, &grpc.GenericServerStream[StreamProgressRequest, Run]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Backtest_StreamProgressServer = grpc.ServerStreamingServer[Run]
+
+func _Backtest_GetResults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BacktestServer).GetResults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backtest_GetResults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BacktestServer).GetResults(ctx, req.(*GetResultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backtest_CancelRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BacktestServer).CancelRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Backtest_CancelRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BacktestServer).CancelRun(ctx, req.(*CancelRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Backtest_ServiceDesc is the grpc.ServiceDesc for Backtest service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Backtest_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "backtest.v1.Backtest",
+	HandlerType: (*BacktestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitRun",
+			Handler:    _Backtest_SubmitRun_Handler,
+		},
+		{
+			MethodName: "GetResults",
+			Handler:    _Backtest_GetResults_Handler,
+		},
+		{
+			MethodName: "CancelRun",
+			Handler:    _Backtest_CancelRun_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamProgress",
+			Handler:       _Backtest_StreamProgress_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc/backtest.proto",
+}
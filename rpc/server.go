@@ -0,0 +1,216 @@
+// Package rpc serves the backtests of a server.Manager over gRPC, e.g. for a research UI
+// in another language. The service is defined in backtest.proto: SubmitRun queues a test,
+// StreamProgress streams its status and progress until it is finished and GetResults
+// returns the metrics, equity curve and transactions of a finished run.
+//
+//	s := grpc.NewServer()
+//	rpc.RegisterBacktestServer(s, rpc.NewServer(server.NewManager(nil, nil, 2)))
+//	s.Serve(lis)
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative backtest.proto
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements the Backtest service with a manager
+type Server struct {
+	UnimplementedBacktestServer
+	manager *server.Manager
+}
+
+// NewServer returns the Backtest service of the manager
+func NewServer(m *server.Manager) *Server {
+	return &Server{manager: m}
+}
+
+// SubmitRun parses the spec of the request and queues its test
+func (s *Server) SubmitRun(ctx context.Context, req *SubmitRunRequest) (*Run, error) {
+	spec, err := s.manager.ParseSpec(req.Spec, req.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	job, err := s.manager.Submit(spec)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return runOf(job.Info()), nil
+}
+
+// StreamProgress sends the run on every change until it is finished or the client is gone
+func (s *Server) StreamProgress(req *StreamProgressRequest, stream Backtest_StreamProgressServer) error {
+	job, err := s.job(req.Id)
+	if err != nil {
+		return err
+	}
+	for {
+		// get the channel before the snapshot to not miss a change in between
+		changed := job.Changed()
+		info := job.Info()
+		if err := stream.Send(runOf(info)); err != nil {
+			return err
+		}
+		if info.Status.Finished() {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// GetResults returns the results of a done run
+func (s *Server) GetResults(ctx context.Context, req *GetResultsRequest) (*Results, error) {
+	job, err := s.job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := job.Statistic()
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "run %s is %s, results are not available", job.ID, job.Info().Status)
+	}
+	return resultsOf(job.ID, st.JSON(), int(req.MaxPoints), req.IncludeJson)
+}
+
+// CancelRun cancels a queued or running run
+func (s *Server) CancelRun(ctx context.Context, req *CancelRunRequest) (*Run, error) {
+	job, err := s.job(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	job.Cancel()
+	return runOf(job.Info()), nil
+}
+
+// job returns a job of the manager, a NotFound error if unknown
+func (s *Server) job(id string) (*server.Job, error) {
+	job, ok := s.manager.Job(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown run %q", id)
+	}
+	return job, nil
+}
+
+// statuses maps the job states to the states of the service
+var statuses = map[server.Status]Status{
+	server.StatusQueued:    Status_STATUS_QUEUED,
+	server.StatusRunning:   Status_STATUS_RUNNING,
+	server.StatusDone:      Status_STATUS_DONE,
+	server.StatusFailed:    Status_STATUS_FAILED,
+	server.StatusCancelled: Status_STATUS_CANCELLED,
+}
+
+// runOf converts a job snapshot to a run message
+func runOf(info server.Info) *Run {
+	run := &Run{
+		Id:         info.ID,
+		Status:     statuses[info.Status],
+		Error:      info.Error,
+		StopReason: info.StopReason,
+		StoreId:    info.RunID,
+		Created:    timestamppb.New(info.Created),
+		Progress: &Progress{
+			Bars:       int64(info.Progress.Bars),
+			Total:      int64(info.Progress.Total),
+			Fraction:   info.Progress.Fraction,
+			Time:       timestamp(info.Progress.Time),
+			Equity:     info.Progress.Equity,
+			EtaSeconds: info.Progress.ETA,
+		},
+	}
+	if info.Started != nil {
+		run.Started = timestamppb.New(*info.Started)
+	}
+	if info.Finished != nil {
+		run.Finished = timestamppb.New(*info.Finished)
+	}
+	return run
+}
+
+// resultsOf converts the results of a run to a results message with at most maxPoints
+// equity points, all if 0, and the JSON results if includeJSON
+func resultsOf(id string, r backtest.ResultJSON, maxPoints int, includeJSON bool) (*Results, error) {
+	var b []byte
+	if includeJSON {
+		var err error
+		if b, err = json.Marshal(r); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	m := r.Metrics
+	res := &Results{
+		Id: id,
+		Metrics: &Metrics{
+			InitialCash:  m.InitialCash,
+			FinalEquity:  m.FinalEquity,
+			TotalReturn:  m.TotalReturn,
+			Cagr:         m.CAGR,
+			MaxDrawdown:  m.MaxDrawdown,
+			SharpeRatio:  m.SharpeRatio,
+			SortinoRatio: m.SortinoRatio,
+			Volatility:   m.Volatility,
+			Transactions: int64(m.Transactions),
+			Trades:       int64(m.Trades.Count),
+			WinRate:      m.Trades.WinRate,
+			NetProfit:    m.Costs.NetProfit,
+			Cost:         m.Costs.Cost,
+		},
+		ResultJson: b,
+	}
+	for _, e := range sample(r.Equity, maxPoints) {
+		res.Equity = append(res.Equity, &EquityPoint{
+			Time:       timestamp(e.Time),
+			Equity:     e.Equity,
+			Drawdown:   e.Drawdown,
+			BuyAndHold: e.BuyAndHold,
+			Exposure:   e.Exposure,
+		})
+	}
+	for _, t := range r.Transactions {
+		res.Transactions = append(res.Transactions, &Transaction{
+			Time:      timestamp(t.Time),
+			Symbol:    t.Symbol,
+			Direction: t.Direction,
+			Qty:       t.Qty,
+			Price:     t.Price,
+			Cost:      t.Cost,
+			Tags:      t.Tags,
+		})
+	}
+	return res, nil
+}
+
+// sample returns at most n points evenly spread over the equity curve, keeping the first
+// and the last point, all if n is 0
+func sample(points []backtest.EquityJSON, n int) []backtest.EquityJSON {
+	if n <= 0 || len(points) <= n {
+		return points
+	}
+	if n == 1 {
+		return points[len(points)-1:]
+	}
+	sampled := make([]backtest.EquityJSON, n)
+	for i := range sampled {
+		sampled[i] = points[i*(len(points)-1)/(n-1)]
+	}
+	return sampled
+}
+
+// timestamp converts a time to a timestamp, nil for the zero time
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,288 @@
+package backtest
+
+import (
+	"fmt"
+)
+
+// Series returns the value of an indicator on the last data event of a list, computed
+// with the indicator cache of the test, which may be nil
+type Series func([]DataEventHandler, *IndicatorCache) (float64, bool)
+
+// Condition evaluates a rule on the data events of a symbol known so far
+type Condition func([]DataEventHandler, *IndicatorCache) bool
+
+// Price is the series of the latest prices
+func Price() Series {
+	return func(list []DataEventHandler, ic *IndicatorCache) (float64, bool) {
+		if len(list) == 0 {
+			return 0, false
+		}
+		return list[len(list)-1].LatestPrice(), true
+	}
+}
+
+// Const is a series with a constant value
+func Const(v float64) Series {
+	return func(list []DataEventHandler, ic *IndicatorCache) (float64, bool) {
+		return v, true
+	}
+}
+
+// SMA is the series of the simple moving average over n bars
+func SMA(n int) Series {
+	return cachedSeries("sma", n, func(list []DataEventHandler) (float64, bool) {
+		if n < 1 || len(list) < n {
+			return 0, false
+		}
+		var sum float64
+		for _, e := range list[len(list)-n:] {
+			sum += e.LatestPrice()
+		}
+		return sum / float64(n), true
+	})
+}
+
+// EMA is the series of the exponential moving average over n bars,
+// seeded with the simple moving average of the first n bars
+func EMA(n int) Series {
+	k := 2 / float64(n+1)
+	return recursive{
+		name:  "ema",
+		n:     n,
+		first: n - 1,
+		size:  1,
+		seed: func(list []DataEventHandler) []float64 {
+			var ema float64
+			for _, e := range list {
+				ema += e.LatestPrice()
+			}
+			return []float64{ema / float64(n)}
+		},
+		step: func(state []float64, prev, e DataEventHandler) []float64 {
+			return []float64{e.LatestPrice()*k + state[0]*(1-k)}
+		},
+		value: func(state []float64) float64 { return state[0] },
+	}.series()
+}
+
+// RSI is the series of the relative strength index over n bars with Wilder smoothing
+func RSI(n int) Series {
+	return recursive{
+		name:  "rsi",
+		n:     n,
+		first: n,
+		size:  2,
+		seed: func(list []DataEventHandler) []float64 {
+			var gain, loss float64
+			for i := 1; i < len(list); i++ {
+				g, l := gainLoss(list[i-1], list[i])
+				gain += g / float64(n)
+				loss += l / float64(n)
+			}
+			return []float64{gain, loss}
+		},
+		step: func(state []float64, prev, e DataEventHandler) []float64 {
+			g, l := gainLoss(prev, e)
+			return []float64{
+				(state[0]*float64(n-1) + g) / float64(n),
+				(state[1]*float64(n-1) + l) / float64(n),
+			}
+		},
+		value: func(state []float64) float64 {
+			if state[1] == 0 {
+				return 100
+			}
+			return 100 - 100/(1+state[0]/state[1])
+		},
+	}.series()
+}
+
+// gainLoss returns the gain and the loss of the price change between two data events
+func gainLoss(prev, e DataEventHandler) (gain, loss float64) {
+	change := e.LatestPrice() - prev.LatestPrice()
+	if change > 0 {
+		return change, 0
+	}
+	return 0, -change
+}
+
+// cachedSeries shares the values of a series through the indicator cache
+func cachedSeries(name string, n int, fn func([]DataEventHandler) (float64, bool)) Series {
+	return func(list []DataEventHandler, ic *IndicatorCache) (float64, bool) {
+		if len(list) == 0 {
+			return 0, false
+		}
+		last := list[len(list)-1]
+		key := IndicatorKey{Symbol: last.GetSymbol(), Indicator: name, Params: fmt.Sprint(n), Time: last.GetTime()}
+		return ic.Get(key, func() (float64, bool) {
+			return fn(list)
+		})
+	}
+}
+
+// recursive is a series depending on the whole history, e.g. an EMA, whose state on a bar
+// follows from its state on the previous bar. The state of the last bar is cached, so a
+// test evaluating the series bar by bar does a single step per bar.
+type recursive struct {
+	name  string
+	n     int
+	first int                                                       // index of the first bar with a value
+	size  int                                                       // number of values of the state
+	seed  func(list []DataEventHandler) []float64                   // state on the first bar from the bars up to it
+	step  func(state []float64, prev, e DataEventHandler) []float64 // state on a bar from the state on the previous bar
+	value func(state []float64) float64
+}
+
+// series returns the series, the states are keyed by the first data event of the history
+// as well
+func (r recursive) series() Series {
+	return func(list []DataEventHandler, ic *IndicatorCache) (float64, bool) {
+		if r.n < 1 || len(list) <= r.first {
+			return 0, false
+		}
+
+		// continue from the last bar with a cached state, or from the seed
+		i := len(list) - 1
+		state := r.cached(list, i, ic)
+		for state == nil && i > r.first {
+			i--
+			state = r.cached(list, i, ic)
+		}
+		if state == nil {
+			state = r.seed(list[:r.first+1])
+		}
+		for i++; i < len(list); i++ {
+			state = r.step(state, list[i-1], list[i])
+		}
+
+		r.store(list, state, ic)
+		return r.value(state), true
+	}
+}
+
+// key returns the cache key of a state value on the bar at index i
+func (r recursive) key(list []DataEventHandler, i, j int) IndicatorKey {
+	return IndicatorKey{
+		Symbol:    list[i].GetSymbol(),
+		Indicator: r.name,
+		Params:    fmt.Sprintf("%d/%d", r.n, j),
+		Time:      list[i].GetTime(),
+		Start:     list[0].GetTime(),
+	}
+}
+
+// cached returns the cached state on the bar at index i, nil if not cached
+func (r recursive) cached(list []DataEventHandler, i int, ic *IndicatorCache) []float64 {
+	if ic == nil {
+		return nil
+	}
+	state := make([]float64, r.size)
+	for j := range state {
+		v, ok := ic.lookup(r.key(list, i, j))
+		if !ok {
+			return nil
+		}
+		state[j] = v
+	}
+	return state
+}
+
+// store caches the state on the last bar
+func (r recursive) store(list []DataEventHandler, state []float64, ic *IndicatorCache) {
+	for j, v := range state {
+		ic.put(r.key(list, len(list)-1, j), v)
+	}
+}
+
+// Above is true when the series is above the value
+func (s Series) Above(v float64) Condition {
+	return s.AboveSeries(Const(v))
+}
+
+// Below is true when the series is below the value
+func (s Series) Below(v float64) Condition {
+	return s.BelowSeries(Const(v))
+}
+
+// AboveSeries is true when the series is above the other series
+func (s Series) AboveSeries(o Series) Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		a, okA := s(list, ic)
+		b, okB := o(list, ic)
+		return okA && okB && a > b
+	}
+}
+
+// BelowSeries is true when the series is below the other series
+func (s Series) BelowSeries(o Series) Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		a, okA := s(list, ic)
+		b, okB := o(list, ic)
+		return okA && okB && a < b
+	}
+}
+
+// CrossAbove is true on the bar the first series crosses above the second
+func CrossAbove(a, b Series) Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		if len(list) < 2 {
+			return false
+		}
+		// without a cache the previous values are kept for this bar, so series depending on
+		// the whole history continue from them
+		if ic == nil {
+			ic = NewIndicatorCache()
+		}
+		prevA, okA := a(list[:len(list)-1], ic)
+		prevB, okB := b(list[:len(list)-1], ic)
+		return okA && okB && prevA <= prevB && a.AboveSeries(b)(list, ic)
+	}
+}
+
+// CrossBelow is true on the bar the first series crosses below the second
+func CrossBelow(a, b Series) Condition {
+	return CrossAbove(b, a)
+}
+
+// And is true when both conditions are true
+func (c Condition) And(o Condition) Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		return c(list, ic) && o(list, ic)
+	}
+}
+
+// Or is true when one of the conditions is true
+func (c Condition) Or(o Condition) Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		return c(list, ic) || o(list, ic)
+	}
+}
+
+// Not negates the condition
+func (c Condition) Not() Condition {
+	return func(list []DataEventHandler, ic *IndicatorCache) bool {
+		return !c(list, ic)
+	}
+}
+
+// NewRuleStrategy creates a strategy buying when the entry condition is met without an
+// open position and closing the position when the exit condition is met.
+// An empty symbol applies the rules to all symbols.
+func NewRuleStrategy(symbol string, entry, exit Condition) *BaseStrategy {
+	return NewBaseStrategy(symbol, DeciderFunc(func(b *BaseStrategy) (SignalEvent, error) {
+		list := b.Data().List(b.Event().GetSymbol())
+		indicators := IndicatorsOf(b.Data())
+
+		if b.IsInvested() {
+			if exit != nil && exit(list, indicators) {
+				return b.ClosePosition(), nil
+			}
+			return b.Hold(), nil
+		}
+
+		if entry != nil && entry(list, indicators) {
+			return b.Buy(0), nil
+		}
+		return b.Hold(), nil
+	}))
+}
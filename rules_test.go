@@ -0,0 +1,104 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+)
+
+var ruleCloses = []float64{10, 11, 10.5, 12, 13, 12.5, 12, 14, 15, 14.5, 16, 15, 17, 18, 17.5}
+
+// emaOf computes the EMA over n bars of the closes in one pass
+func emaOf(closes []float64, n int) float64 {
+	k := 2 / float64(n+1)
+	var ema float64
+	for _, c := range closes[:n] {
+		ema += c / float64(n)
+	}
+	for _, c := range closes[n:] {
+		ema = c*k + ema*(1-k)
+	}
+	return ema
+}
+
+// rsiOf computes the RSI over n bars of the closes in one pass
+func rsiOf(closes []float64, n int) float64 {
+	var gain, loss float64
+	for i := 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		g, l := math.Max(change, 0), math.Max(-change, 0)
+		if i <= n {
+			gain += g / float64(n)
+			loss += l / float64(n)
+			continue
+		}
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+	}
+	if loss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+func TestRecursiveSeries(t *testing.T) {
+	d, reads := countedData("A", ruleCloses...)
+	list := d.List("A")
+	ic := NewIndicatorCache()
+	ema, rsi := EMA(3), RSI(4)
+
+	for i := 1; i <= len(list); i++ {
+		*reads = 0
+		got, ok := ema(list[:i], ic)
+		if want := i >= 3; ok != want {
+			t.Fatalf("EMA on %d bars ok is %v, want %v", i, ok, want)
+		}
+		if ok && math.Abs(got-emaOf(ruleCloses[:i], 3)) > 1e-9 {
+			t.Errorf("EMA on %d bars is %v, want %v", i, got, emaOf(ruleCloses[:i], 3))
+		}
+		// a bar after the seed steps from the cached value of the previous bar
+		if i > 3 && *reads != 1 {
+			t.Errorf("EMA on %d bars read %d prices, want 1", i, *reads)
+		}
+
+		*reads = 0
+		got, ok = rsi(list[:i], ic)
+		if want := i > 4; ok != want {
+			t.Fatalf("RSI on %d bars ok is %v, want %v", i, ok, want)
+		}
+		if ok && math.Abs(got-rsiOf(ruleCloses[:i], 4)) > 1e-9 {
+			t.Errorf("RSI on %d bars is %v, want %v", i, got, rsiOf(ruleCloses[:i], 4))
+		}
+		if i > 5 && *reads != 2 {
+			t.Errorf("RSI on %d bars read %d prices, want 2", i, *reads)
+		}
+	}
+}
+
+func TestRecursiveSeriesWithoutCache(t *testing.T) {
+	d, _ := countedData("A", ruleCloses...)
+	list := d.List("A")
+
+	got, ok := EMA(3)(list, nil)
+	if want := emaOf(ruleCloses, 3); !ok || math.Abs(got-want) > 1e-9 {
+		t.Errorf("EMA without cache is %v, %v, want %v", got, ok, want)
+	}
+	// a cache filled on a shorter history continues where it stopped
+	ic := NewIndicatorCache()
+	RSI(4)(list[:8], ic)
+	got, ok = RSI(4)(list, ic)
+	if want := rsiOf(ruleCloses, 4); !ok || math.Abs(got-want) > 1e-9 {
+		t.Errorf("RSI continued from the cache is %v, %v, want %v", got, ok, want)
+	}
+}
+
+func TestCrossAboveWithoutCache(t *testing.T) {
+	d, reads := countedData("A", ruleCloses...)
+	list := d.List("A")
+
+	cross := CrossAbove(Price(), EMA(3))
+	cross(list, nil)
+	// the previous bar is computed in one pass, the last bar steps from it
+	if max := len(list) + 3; *reads > max {
+		t.Errorf("cross without cache read %d prices, want at most %d", *reads, max)
+	}
+}
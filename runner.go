@@ -0,0 +1,124 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is a single test of a parallel run. Setup has to create an isolated test with its
+// own data, strategy, portfolio, exchange and statistic, as the jobs run concurrently.
+// Random number generators must not be shared either, strategies without one create a
+// randomly seeded generator of their own.
+type Job struct {
+	Name   string
+	Params map[string]interface{} // params of the job, e.g. the strategy params or symbols
+	Setup  func() (*Test, error)
+}
+
+// JobResult holds the outcome of a job
+type JobResult struct {
+	Name     string
+	Params   map[string]interface{}
+	Results  Results
+	Metrics  MetricsJSON // metrics of the statistic, if it is a *Statistic
+	Err      error
+	Duration time.Duration
+	Cached   bool // the metrics were taken from the result store, the results are empty
+}
+
+// Runner runs independent tests on a pool of goroutines
+type Runner struct {
+	Workers int          // number of concurrent tests, the number of CPUs if 0
+	Store   *ResultStore // caches the runs by their configuration, if set
+}
+
+// Run runs all jobs until done or the context is cancelled and returns the results
+// in the order of the jobs. Jobs not started before the cancellation return the error
+// of the context.
+func (r Runner) Run(ctx context.Context, jobs []Job) []JobResult {
+	workers := r.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]JobResult, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = r.runJob(ctx, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		if ctx.Err() != nil {
+			results[i] = JobResult{Name: jobs[i].Name, Params: jobs[i].Params, Err: ctx.Err()}
+			continue
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			results[i] = JobResult{Name: jobs[i].Name, Params: jobs[i].Params, Err: ctx.Err()}
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
+
+// runJob sets up and runs a single job and recovers from its panics
+func (r Runner) runJob(ctx context.Context, job Job) (result JobResult) {
+	result = JobResult{Name: job.Name, Params: job.Params}
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("job %s panicked: %v", job.Name, r)
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	if job.Setup == nil {
+		result.Err = errors.New("job has no setup")
+		return result
+	}
+	test, err := job.Setup()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if r.Store != nil {
+		run, cached, err := test.runCached(ctx, r.Store, job.Params)
+		result.Metrics, result.Cached, result.Err = run.Metrics, cached, err
+		if !cached && err == nil {
+			result.Results = test.results()
+		}
+		return result
+	}
+
+	result.Results, result.Err = test.RunContext(ctx)
+	if s, ok := result.Results.Statistic.(*Statistic); ok {
+		result.Metrics = s.JSON().Metrics
+	}
+	return result
+}
+
+// SortJobResults sorts the results by a metric in descending order, failed jobs last
+func SortJobResults(results []JobResult, metric func(MetricsJSON) float64) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Err == nil) != (results[j].Err == nil) {
+			return results[i].Err == nil
+		}
+		return metric(results[i].Metrics) > metric(results[j].Metrics)
+	})
+}
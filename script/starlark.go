@@ -0,0 +1,287 @@
+// Package script runs strategies written in Starlark, so strategies can be
+// changed without recompiling the backtest binary.
+//
+// A script defines a calculate_signal function, which is called for every data event:
+//
+//	def calculate_signal(bar):
+//	    if bar.close > sma(bar.symbol, 20):
+//	        return "buy"
+//	    return {"direction": "sell", "strength": 0.5}
+//
+// The function returns None for no signal, a direction string or a dict with the
+// keys direction, strength, notional, limit, ttl, stop_loss and take_profit.
+// The builtins history, sma, cash, value and position give access to the data and
+// the portfolio, the global params holds the parameters set on the strategy.
+package script
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// Strategy is a strategy handler running a Starlark script
+type Strategy struct {
+	Params  map[string]interface{} // parameters exposed to the script as params
+	thread  *starlark.Thread
+	globals starlark.StringDict
+	fn      starlark.Callable
+
+	// set for the duration of a CalculateSignal call
+	data      backtest.DataHandler
+	portfolio backtest.PortfolioHandler
+}
+
+// Load reads and compiles a Starlark script file
+func Load(path string, params map[string]interface{}) (*Strategy, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(path, src, params)
+}
+
+// New compiles a Starlark script, the filename is only used in error messages
+func New(filename string, src []byte, params map[string]interface{}) (*Strategy, error) {
+	s := &Strategy{Params: params, thread: &starlark.Thread{Name: filename}}
+
+	p := starlark.NewDict(len(params))
+	for k, v := range params {
+		sv, err := toStarlark(v)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %v", k, err)
+		}
+		p.SetKey(starlark.String(k), sv)
+	}
+	p.Freeze()
+
+	predeclared := starlark.StringDict{
+		"params":   p,
+		"history":  starlark.NewBuiltin("history", s.history),
+		"sma":      starlark.NewBuiltin("sma", s.sma),
+		"cash":     starlark.NewBuiltin("cash", s.cash),
+		"value":    starlark.NewBuiltin("value", s.value),
+		"position": starlark.NewBuiltin("position", s.position),
+	}
+
+	globals, err := starlark.ExecFile(s.thread, filename, src, predeclared)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := globals["calculate_signal"].(starlark.Callable)
+	if !ok {
+		return nil, errors.New("script does not define calculate_signal")
+	}
+	s.globals = globals
+	s.fn = fn
+
+	return s, nil
+}
+
+// CalculateSignal calls the calculate_signal function of the script
+func (s *Strategy) CalculateSignal(de backtest.DataEventHandler, d backtest.DataHandler, p backtest.PortfolioHandler) (backtest.SignalEvent, error) {
+	s.data = d
+	s.portfolio = p
+	defer func() {
+		s.data = nil
+		s.portfolio = nil
+	}()
+
+	signal := &backtest.Signal{Event: backtest.Event{Time: de.GetTime(), Symbol: de.GetSymbol()}}
+
+	result, err := starlark.Call(s.thread, s.fn, starlark.Tuple{barValue(de)}, nil)
+	if err != nil {
+		return signal, err
+	}
+
+	switch r := result.(type) {
+	case starlark.NoneType:
+	case starlark.String:
+		signal.SetDirection(string(r))
+	case *starlark.Dict:
+		if err := setSignal(signal, r); err != nil {
+			return signal, err
+		}
+	default:
+		return signal, fmt.Errorf("calculate_signal returned unsupported %s", result.Type())
+	}
+
+	return signal, nil
+}
+
+// barValue converts a data event into a Starlark struct
+func barValue(de backtest.DataEventHandler) starlark.Value {
+	fields := starlark.StringDict{
+		"symbol": starlark.String(de.GetSymbol()),
+		"time":   starlark.MakeInt64(de.GetTime().Unix()),
+		"price":  starlark.Float(de.LatestPrice()),
+		"close":  starlark.Float(de.LatestPrice()),
+	}
+	if bar, ok := de.(backtest.Bar); ok {
+		fields["open"] = starlark.Float(bar.Open)
+		fields["high"] = starlark.Float(bar.High)
+		fields["low"] = starlark.Float(bar.Low)
+		fields["volume"] = starlark.Float(bar.Volume)
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, fields)
+}
+
+// setSignal sets the fields of a signal from a dict returned by the script
+func setSignal(signal *backtest.Signal, d *starlark.Dict) error {
+	for _, item := range d.Items() {
+		key, ok := item[0].(starlark.String)
+		if !ok {
+			return fmt.Errorf("signal key %s is not a string", item[0])
+		}
+
+		if key == "direction" {
+			direction, ok := item[1].(starlark.String)
+			if !ok {
+				return errors.New("signal direction is not a string")
+			}
+			signal.SetDirection(string(direction))
+			continue
+		}
+
+		if key == "ttl" {
+			var ttl int
+			if err := starlark.AsInt(item[1], &ttl); err != nil {
+				return fmt.Errorf("signal ttl: %v", err)
+			}
+			signal.TTL = ttl
+			continue
+		}
+
+		f, ok := starlark.AsFloat(item[1])
+		if !ok {
+			return fmt.Errorf("signal %s is not a number", key)
+		}
+		switch key {
+		case "strength":
+			signal.SetStrength(f)
+		case "notional":
+			signal.Notional = f
+		case "limit":
+			signal.Limit = f
+		case "stop_loss":
+			signal.StopLoss = f
+		case "take_profit":
+			signal.TakeProfit = f
+		default:
+			return fmt.Errorf("unknown signal key %s", key)
+		}
+	}
+	return nil
+}
+
+// history(symbol, n) returns the last n prices of a symbol, oldest first
+func (s *Strategy) history(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var symbol string
+	var n int
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "symbol", &symbol, "n", &n); err != nil {
+		return nil, err
+	}
+
+	prices := s.prices(symbol, n)
+	list := make([]starlark.Value, len(prices))
+	for i, p := range prices {
+		list[i] = starlark.Float(p)
+	}
+	return starlark.NewList(list), nil
+}
+
+// sma(symbol, n) returns the simple moving average over the last n prices, None if not enough data
+func (s *Strategy) sma(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var symbol string
+	var n int
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "symbol", &symbol, "n", &n); err != nil {
+		return nil, err
+	}
+
+	if s.data == nil {
+		return nil, errors.New("sma called outside of calculate_signal")
+	}
+	sma, ok := backtest.IndicatorsOf(s.data).SMA(s.data, symbol, n)
+	if !ok {
+		return starlark.None, nil
+	}
+	return starlark.Float(sma), nil
+}
+
+// cash() returns the current cash of the portfolio
+func (s *Strategy) cash(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	if s.portfolio == nil {
+		return nil, errors.New("cash called outside of calculate_signal")
+	}
+	return starlark.Float(s.portfolio.Cash()), nil
+}
+
+// value() returns the current total value of the portfolio
+func (s *Strategy) value(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	if s.portfolio == nil {
+		return nil, errors.New("value called outside of calculate_signal")
+	}
+	return starlark.Float(s.portfolio.Value()), nil
+}
+
+// position(symbol) returns the qty held of a symbol
+func (s *Strategy) position(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var symbol string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "symbol", &symbol); err != nil {
+		return nil, err
+	}
+	if s.portfolio == nil {
+		return nil, errors.New("position called outside of calculate_signal")
+	}
+	pos, _ := s.portfolio.Position(symbol)
+	return starlark.Float(pos.Qty), nil
+}
+
+// prices returns the last n prices of a symbol
+func (s *Strategy) prices(symbol string, n int) []float64 {
+	if s.data == nil || n <= 0 {
+		return nil
+	}
+
+	list := s.data.List(symbol)
+	if len(list) > n {
+		list = list[len(list)-n:]
+	}
+
+	prices := make([]float64, len(list))
+	for i, e := range list {
+		prices[i] = e.LatestPrice()
+	}
+	return prices
+}
+
+// toStarlark converts a Go parameter value into a Starlark value
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch x := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(x), nil
+	case int:
+		return starlark.MakeInt(x), nil
+	case int64:
+		return starlark.MakeInt64(x), nil
+	case float64:
+		return starlark.Float(x), nil
+	case string:
+		return starlark.String(x), nil
+	}
+	return nil, fmt.Errorf("unsupported param type %T", v)
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	backtest "github.com/ivtpz/backtest-go"
+)
+
+// maxSpecSize limits the size of submitted specs
+const maxSpecSize = 1 << 20
+
+// handler serves the REST API of a manager
+type handler struct {
+	manager *Manager
+}
+
+// NewHandler returns the HTTP REST API of the manager
+func NewHandler(m *Manager) http.Handler {
+	return &handler{manager: m}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "components":
+		h.components(w, r)
+	case len(parts) == 1 && parts[0] == "runs":
+		switch r.Method {
+		case http.MethodGet:
+			h.list(w, r)
+		case http.MethodPost:
+			h.submit(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case len(parts) >= 2 && parts[0] == "runs":
+		job, ok := h.manager.Job(parts[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown run %q", parts[1])
+			return
+		}
+		h.job(w, r, job, parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+}
+
+// components lists the names of the registered components by kind
+func (h *handler) components(w http.ResponseWriter, r *http.Request) {
+	reg := h.manager.Registry()
+	names := make(map[string][]string)
+	for _, kind := range []string{"strategy", "sizer", "filter", "data"} {
+		names[kind] = reg.Names(kind)
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// list returns the infos of all jobs
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	infos := []Info{}
+	for _, job := range h.manager.Jobs() {
+		infos = append(infos, job.Info())
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// submit queues the spec of the request body, a JSON spec or a config by content type
+func (h *handler) submit(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "%v", err)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format := "json"
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		format = "yaml"
+	case "application/toml":
+		format = "toml"
+	}
+	spec, err := h.manager.ParseSpec(body, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	job, err := h.manager.Submit(spec)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	w.Header().Set("Location", "/runs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job.Info())
+}
+
+// job serves the paths below a job
+func (h *handler) job(w http.ResponseWriter, r *http.Request, job *Job, path []string) {
+	if len(path) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, job.Info())
+		case http.MethodDelete:
+			job.Cancel()
+			writeJSON(w, http.StatusAccepted, job.Info())
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	s, ok := job.Statistic()
+	if !ok {
+		writeError(w, http.StatusConflict, "run %s is %s, results are not available", job.ID, job.Info().Status)
+		return
+	}
+
+	switch {
+	case len(path) == 1 && path[0] == "results":
+		writeJSON(w, http.StatusOK, s.JSON())
+	case len(path) == 1 && path[0] == "report":
+		if r.URL.Query().Get("format") == "pdf" {
+			render(w, "application/pdf", s.WritePDFReport)
+			return
+		}
+		render(w, "text/html; charset=utf-8", s.WriteReport)
+	case len(path) == 2 && path[0] == "charts":
+		chart(w, r, s, path[1])
+	default:
+		writeError(w, http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+}
+
+// chart writes a chart of the results with the options of the request
+func chart(w http.ResponseWriter, r *http.Request, s *backtest.Statistic, name string) {
+	opts := backtest.ChartOptionsFromRequest(r)
+	bins, err := strconv.Atoi(r.URL.Query().Get("bins"))
+	if err != nil {
+		bins = 20
+	}
+	var write func(io.Writer) error
+	switch name {
+	case "equity":
+		write = func(w io.Writer) error { return s.WriteEquityChart(w, opts) }
+	case "drawdown":
+		write = func(w io.Writer) error { return s.WriteDrawdownChart(w, opts) }
+	case "returns":
+		write = func(w io.Writer) error { return s.WriteReturnsHistogram(w, bins, opts) }
+	default:
+		writeError(w, http.StatusNotFound, "unknown chart %q, known are equity, drawdown and returns", name)
+		return
+	}
+	render(w, opts.ContentType(), write)
+}
+
+// render writes the output of a writer function as response, or an error if it fails
+func render(w http.ResponseWriter, contentType string, write func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	buf.WriteTo(w)
+}
+
+// writeJSON writes the value as JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response as JSON object with the message
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// methodNotAllowed writes an error response with the allowed methods
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
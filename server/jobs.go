@@ -0,0 +1,450 @@
+// Package server runs backtests as a service. A Manager queues the submitted tests and
+// runs a limited number of them at once, NewHandler exposes it as HTTP REST API:
+//
+//	POST   /runs                    submit a JSON spec or a YAML/TOML config, returns the job
+//	GET    /runs                    list the jobs
+//	GET    /runs/{id}               status and progress of a job, the metrics when done
+//	DELETE /runs/{id}               cancel a job
+//	GET    /runs/{id}/results       results as JSON
+//	GET    /runs/{id}/report        HTML report, ?format=pdf for the PDF report
+//	GET    /runs/{id}/charts/{name} equity, drawdown or returns chart, with chart options and bins as query
+//	GET    /components              names of the registered components
+//
+// The data paths of submitted specs are relative to the data directory of the manager,
+// and finished jobs are removed after their retention.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	backtest "github.com/ivtpz/backtest-go"
+	"github.com/ivtpz/backtest-go/config"
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	// StatusQueued jobs wait for a free worker
+	StatusQueued Status = "queued"
+	// StatusRunning jobs are running
+	StatusRunning Status = "running"
+	// StatusDone jobs finished, also if a stop rule ended them early
+	StatusDone Status = "done"
+	// StatusFailed jobs ended with an error
+	StatusFailed Status = "failed"
+	// StatusCancelled jobs were cancelled before they finished
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished returns true if the job will not change anymore
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCancelled
+}
+
+// progressInterval is the interval the progress of running jobs is updated
+const progressInterval = 200 * time.Millisecond
+
+// Job is a submitted test
+type Job struct {
+	ID      string
+	Created time.Time
+
+	mu         sync.Mutex
+	test       *backtest.Test
+	status     Status
+	progress   backtest.Progress
+	err        error
+	stopReason string
+	runID      string // ID of the run in the result store
+	statistic  *backtest.Statistic
+	metrics    *backtest.MetricsJSON // metrics of the statistic
+	started    time.Time
+	finished   time.Time
+	cancel     context.CancelFunc
+	changed    chan struct{} // closed on every change
+}
+
+// Info is a snapshot of a job
+type Info struct {
+	ID         string                `json:"id"`
+	Status     Status                `json:"status"`
+	Progress   ProgressInfo          `json:"progress"`
+	Error      string                `json:"error,omitempty"`
+	StopReason string                `json:"stop_reason,omitempty"`
+	RunID      string                `json:"run_id,omitempty"`
+	Created    time.Time             `json:"created"`
+	Started    *time.Time            `json:"started,omitempty"`
+	Finished   *time.Time            `json:"finished,omitempty"`
+	Metrics    *backtest.MetricsJSON `json:"metrics,omitempty"`
+}
+
+// ProgressInfo is the progress of a job
+type ProgressInfo struct {
+	Bars     int       `json:"bars"`
+	Total    int       `json:"total"`
+	Fraction float64   `json:"fraction"`
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`
+	ETA      float64   `json:"eta_seconds"`
+}
+
+// Info returns a snapshot of the job
+func (j *Job) Info() Info {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := Info{
+		ID:         j.ID,
+		Status:     j.status,
+		StopReason: j.stopReason,
+		RunID:      j.runID,
+		Created:    j.Created,
+		Progress: ProgressInfo{
+			Bars:     j.progress.Bars,
+			Total:    j.progress.Total,
+			Fraction: j.progress.Fraction(),
+			Time:     j.progress.Time,
+			Equity:   j.progress.Equity,
+			ETA:      j.progress.ETA.Seconds(),
+		},
+	}
+	if j.err != nil {
+		info.Error = j.err.Error()
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		info.Started = &started
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		info.Finished = &finished
+	}
+	info.Metrics = j.metrics
+	return info
+}
+
+// Statistic returns the statistic of a finished job, false if not done yet or failed
+func (j *Job) Statistic() (*backtest.Statistic, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.statistic, j.statistic != nil
+}
+
+// Changed returns a channel closed on the next change of the job, e.g. to stream its
+// progress
+func (j *Job) Changed() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.changed
+}
+
+// Cancel stops the job, queued jobs do not start
+func (j *Job) Cancel() {
+	j.mu.Lock()
+	cancel := j.cancel
+	j.mu.Unlock()
+	cancel()
+}
+
+// update changes the job under its lock and wakes the watchers
+func (j *Job) update(fn func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn()
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+const (
+	// defaultRetention is the time finished jobs are kept by default
+	defaultRetention = 24 * time.Hour
+	// defaultMaxFinished is the number of finished jobs kept by default
+	defaultMaxFinished = 1000
+)
+
+// Manager queues the submitted tests and runs them on a limited number of workers
+type Manager struct {
+	// DataDir is the directory or bucket URL the data paths of the specs are relative to,
+	// specs with data paths are rejected if empty
+	DataDir string
+	// Retention is the time finished jobs are kept after they finished, 0 to keep them
+	Retention time.Duration
+	// MaxFinished is the number of finished jobs kept, the oldest are removed first, 0 for
+	// no limit
+	MaxFinished int
+
+	registry *backtest.Registry
+	store    *backtest.ResultStore
+	slots    chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewManager creates a manager building the tests with the registry, the default
+// registry if nil. The results are saved to the store, if not nil, and runs already
+// stored are not run again. At most workers tests run at once, 1 if 0. Finished jobs are
+// kept for a day, at most the last 1000.
+func NewManager(registry *backtest.Registry, store *backtest.ResultStore, workers int) *Manager {
+	if registry == nil {
+		registry = backtest.DefaultRegistry
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return &Manager{
+		Retention:   defaultRetention,
+		MaxFinished: defaultMaxFinished,
+		registry:    registry,
+		store:       store,
+		slots:       make(chan struct{}, workers),
+		jobs:        make(map[string]*Job),
+	}
+}
+
+// Registry returns the registry of the manager
+func (m *Manager) Registry() *backtest.Registry {
+	return m.registry
+}
+
+// Submit builds the test of the spec and queues it. Specs which can't be built are
+// returned as error and not queued.
+func (m *Manager) Submit(spec backtest.Spec) (*Job, error) {
+	spec, err := m.resolveData(spec)
+	if err != nil {
+		return nil, err
+	}
+	t, err := m.registry.Build(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:      id,
+		Created: time.Now(),
+		test:    t,
+		status:  StatusQueued,
+		cancel:  cancel,
+		changed: make(chan struct{}),
+	}
+	m.mu.Lock()
+	m.prune(job.Created)
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	go m.run(ctx, job)
+	return job, nil
+}
+
+// ParseSpec parses a JSON spec, or a config in the format yaml or toml which is
+// validated against the registry of the manager
+func (m *Manager) ParseSpec(b []byte, format string) (backtest.Spec, error) {
+	if format == "" || format == "json" {
+		var spec backtest.Spec
+		if err := json.Unmarshal(b, &spec); err != nil {
+			return spec, fmt.Errorf("invalid spec: %v", err)
+		}
+		return spec, nil
+	}
+	c, err := config.Parse(b, format)
+	if err != nil {
+		return backtest.Spec{}, err
+	}
+	if err := c.Validate(m.registry); err != nil {
+		return backtest.Spec{}, err
+	}
+	return c.Spec(), nil
+}
+
+// Job returns a job by ID
+func (m *Manager) Job(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// Jobs returns all jobs, the oldest first
+func (m *Manager) Jobs() []*Job {
+	m.mu.Lock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mu.Unlock()
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Created.Before(jobs[k].Created) })
+	return jobs
+}
+
+// prune removes the finished jobs past the retention and the oldest above the limit,
+// the manager must be locked
+func (m *Manager) prune(now time.Time) {
+	type finishedJob struct {
+		id       string
+		finished time.Time
+	}
+	var finished []finishedJob
+	for id, job := range m.jobs {
+		job.mu.Lock()
+		done, at := job.status.Finished(), job.finished
+		job.mu.Unlock()
+		if !done {
+			continue
+		}
+		if m.Retention > 0 && now.Sub(at) > m.Retention {
+			delete(m.jobs, id)
+			continue
+		}
+		finished = append(finished, finishedJob{id, at})
+	}
+	if m.MaxFinished <= 0 || len(finished) <= m.MaxFinished {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i].finished.Before(finished[k].finished) })
+	for _, f := range finished[:len(finished)-m.MaxFinished] {
+		delete(m.jobs, f.id)
+	}
+}
+
+// resolveData returns the spec with the data paths resolved in the data directory, paths
+// outside of it are rejected
+func (m *Manager) resolveData(spec backtest.Spec) (backtest.Spec, error) {
+	data := make([]backtest.ComponentSpec, len(spec.Data))
+	for i, c := range spec.Data {
+		data[i] = c
+		p, ok := c.Params["path"]
+		if !ok {
+			continue
+		}
+		name, ok := p.(string)
+		if !ok {
+			return spec, fmt.Errorf("data path of %s is not a string", c.Name)
+		}
+		resolved, err := m.dataPath(name)
+		if err != nil {
+			return spec, err
+		}
+		data[i].Params = make(map[string]interface{}, len(c.Params))
+		for k, v := range c.Params {
+			data[i].Params[k] = v
+		}
+		data[i].Params["path"] = resolved
+	}
+	spec.Data = data
+	return spec, nil
+}
+
+// dataPath resolves a data path relative to the data directory
+func (m *Manager) dataPath(name string) (string, error) {
+	if m.DataDir == "" {
+		return "", fmt.Errorf("data path %q not allowed, the server has no data directory", name)
+	}
+	if strings.Contains(name, "://") || path.IsAbs(filepath.ToSlash(name)) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("data path %q must be relative to the data directory", name)
+	}
+	clean := path.Clean(filepath.ToSlash(name))
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("data path %q is outside of the data directory", name)
+	}
+	if strings.Contains(m.DataDir, "://") {
+		return strings.TrimSuffix(m.DataDir, "/") + "/" + clean, nil
+	}
+	return filepath.Join(m.DataDir, filepath.FromSlash(clean)), nil
+}
+
+// run waits for a free worker and runs the job
+func (m *Manager) run(ctx context.Context, job *Job) {
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		job.update(func() {
+			job.status, job.err, job.finished = StatusCancelled, ctx.Err(), time.Now()
+		})
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.update(func() {
+				job.status, job.err, job.finished, job.test = StatusFailed, fmt.Errorf("test panicked: %v", r), time.Now(), nil
+			})
+		}
+	}()
+
+	t := job.test
+	t.SetProgress(progressInterval, func(p backtest.Progress) {
+		job.update(func() { job.progress = p })
+	})
+	job.update(func() { job.status, job.started = StatusRunning, time.Now() })
+
+	var s *backtest.Statistic
+	var runID string
+	var err error
+	if m.store != nil {
+		var run backtest.StoredRun
+		run, _, err = t.RunCachedContext(ctx, m.store)
+		if err == nil {
+			s, runID = backtest.StatisticFromJSON(run.Result), run.ID
+		}
+	} else {
+		var results backtest.Results
+		results, err = t.RunContext(ctx)
+		s, _ = results.Statistic.(*backtest.Statistic)
+	}
+
+	// tests ended by a stop rule are done with the results up to the stop
+	var stop *backtest.EarlyStopError
+	if errors.As(err, &stop) && s == nil {
+		s, _ = t.Stats().(*backtest.Statistic)
+	}
+	var metrics *backtest.MetricsJSON
+	if s != nil {
+		m := s.JSON().Metrics
+		metrics = &m
+	}
+	job.update(func() {
+		job.finished, job.runID, job.test = time.Now(), runID, nil
+		switch {
+		case stop != nil:
+			job.status, job.stopReason, job.statistic, job.metrics = StatusDone, stop.Reason, s, metrics
+		case ctx.Err() != nil:
+			job.status, job.err = StatusCancelled, ctx.Err()
+		case err != nil:
+			job.status, job.err = StatusFailed, err
+		case s == nil:
+			job.status, job.err = StatusFailed, errors.New("results have no *Statistic")
+		default:
+			job.status, job.statistic, job.metrics = StatusDone, s, metrics
+		}
+	})
+}
+
+// newJobID returns a random job ID
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,84 @@
+package backtest
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+// SizeHandler is the basic interface for setting the size of an order
+type SizeHandler interface {
+	SizeOrder(SignalEvent, OrderEvent, DataEventHandler, PortfolioHandler) (*Order, error)
+}
+
+// Size is a basic size handler implementation
+type Size struct {
+	DefaultSize float64 // default qty of an order at full signal strength
+}
+
+// SizeOrder sets the qty of an order, scaled by the strength of the signal.
+// Signals with a fixed qty keep it, signals with a fraction close that part of
+// the open position and signals with a notional value are sized to that value
+// at the latest price.
+func (s *Size) SizeOrder(signal SignalEvent, order OrderEvent, data DataEventHandler, p PortfolioHandler) (*Order, error) {
+	o, ok := order.(*Order)
+	if !ok {
+		return &Order{}, errors.New("could not size order, unknown order type")
+	}
+
+	if signal.GetStrength() <= 0 {
+		return o, errors.New("could not size order, signal strength not positive")
+	}
+
+	if q, ok := signal.(Quantifier); ok && q.GetQty() > 0 {
+		o.Qty = q.GetQty()
+		return o, nil
+	}
+
+	if f, ok := signal.(Fractioner); ok && f.GetFraction() > 0 {
+		return s.sizeFraction(o, f.GetFraction(), p)
+	}
+
+	if n, ok := signal.(Notionaler); ok && n.GetNotional() > 0 {
+		if data == nil || data.LatestPrice() <= 0 {
+			return o, errors.New("could not size order, no price for notional")
+		}
+		notional := decimal.NewFromFloat(n.GetNotional())
+		strength := decimal.NewFromFloat(signal.GetStrength())
+		price := decimal.NewFromFloat(data.LatestPrice())
+		o.Qty, _ = notional.Mul(strength).Div(price).Round(DP).Float64()
+		return o, nil
+	}
+
+	if s.DefaultSize <= 0 {
+		return o, errors.New("could not size order, no default size set")
+	}
+
+	size := decimal.NewFromFloat(s.DefaultSize)
+	strength := decimal.NewFromFloat(signal.GetStrength())
+	o.Qty, _ = size.Mul(strength).Round(DP).Float64()
+
+	return o, nil
+}
+
+// sizeFraction sets the qty of an order to a fraction of the open position
+func (s *Size) sizeFraction(o *Order, fraction float64, p PortfolioHandler) (*Order, error) {
+	if fraction > 1 {
+		return o, errors.New("could not size order, fraction above 1")
+	}
+
+	pos, ok := p.Position(o.GetSymbol())
+	if !ok {
+		return o, errors.New("could not size order, no open position")
+	}
+
+	// the order has to reduce the position
+	if (pos.Qty > 0 && o.GetDirection() != "sell") || (pos.Qty < 0 && o.GetDirection() != "buy") {
+		return o, errors.New("could not size order, direction does not reduce the position")
+	}
+
+	qty := decimal.NewFromFloat(pos.Qty).Abs()
+	o.Qty, _ = qty.Mul(decimal.NewFromFloat(fraction)).Round(DP).Float64()
+
+	return o, nil
+}
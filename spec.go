@@ -0,0 +1,159 @@
+package backtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ComponentSpec names a registered component and its params. In JSON the params can be
+// given inline, {"name": "ma_cross", "fast": 10, "slow": 50}, below a params key or the
+// spec can be just the name.
+type ComponentSpec struct {
+	Name   string
+	Params map[string]interface{}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (c *ComponentSpec) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*c = ComponentSpec{Name: name}
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	c.Name, _ = fields["name"].(string)
+	if c.Name == "" {
+		return errors.New("component spec without name")
+	}
+	delete(fields, "name")
+	if params, ok := fields["params"].(map[string]interface{}); ok && len(fields) == 1 {
+		fields = params
+	}
+	c.Params = fields
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, the params are written inline
+func (c ComponentSpec) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(c.Params)+1)
+	for k, v := range c.Params {
+		fields[k] = v
+	}
+	fields["name"] = c.Name
+	return json.Marshal(fields)
+}
+
+// Spec describes a test assembled from registered components, e.g.
+//
+//	{
+//		"data": [{"name": "csv", "path": "btc.csv", "symbol": "BTC"}],
+//		"strategy": {"name": "ma_cross", "fast": 10, "slow": 50},
+//		"sizer": {"name": "fixed", "size": 0.5},
+//		"filters": [{"name": "trend", "lookback": 200}],
+//		"initial_cash": 10000,
+//		"commission": 0.001
+//	}
+type Spec struct {
+	Symbols      []string        `json:"symbols,omitempty"` // symbols of the data if empty
+	Start        time.Time       `json:"start"`             // first time of the data, all data if zero
+	End          time.Time       `json:"end"`               // end of the data, exclusive, all data if zero
+	Data         []ComponentSpec `json:"data"`
+	Strategy     ComponentSpec   `json:"strategy"`
+	Sizer        *ComponentSpec  `json:"sizer,omitempty"`
+	Filters      []ComponentSpec `json:"filters,omitempty"`
+	InitialCash  float64         `json:"initial_cash"`
+	Commission   float64         `json:"commission,omitempty"`
+	ExchangeFee  float64         `json:"exchange_fee,omitempty"`
+	ShortSelling bool            `json:"short_selling,omitempty"`
+	Warmup       int             `json:"warmup,omitempty"`
+	Seed         *int64          `json:"seed,omitempty"` // runs the test deterministic if set
+}
+
+// Build assembles a test from the spec with the components of the registry
+func (r *Registry) Build(spec Spec) (*Test, error) {
+	if len(spec.Data) == 0 {
+		return nil, errors.New("spec without data")
+	}
+	data := &Data{}
+	var stream []DataEventHandler
+	for _, c := range spec.Data {
+		events, err := r.Data(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if inRange(e.GetTime(), spec.Start, spec.End) {
+				stream = append(stream, e)
+			}
+		}
+	}
+	if len(stream) == 0 {
+		return nil, errors.New("no data in the time range of the spec")
+	}
+	data.SetStream(stream)
+	data.SortStream()
+
+	strategy, err := r.Strategy(spec.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &Portfolio{}
+	portfolio.SetInitialCash(spec.InitialCash)
+	portfolio.SetShortSelling(spec.ShortSelling)
+	if spec.Sizer != nil {
+		sizer, err := r.Sizer(*spec.Sizer)
+		if err != nil {
+			return nil, err
+		}
+		portfolio.SetSizeManager(sizer)
+	}
+
+	var filters []SignalFilter
+	for _, c := range spec.Filters {
+		f, err := r.Filter(c)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	symbols := spec.Symbols
+	if len(symbols) == 0 {
+		symbols = data.Symbols()
+	}
+	opts := []Option{
+		WithSymbols(symbols...),
+		WithData(data),
+		WithStrategy(strategy),
+		WithPortfolio(portfolio),
+		WithExchange(&Exchange{CommissionRate: spec.Commission, ExchangeFee: spec.ExchangeFee}),
+		WithStatistics(&Statistic{}),
+		WithFilters(filters...),
+		WithWarmup(spec.Warmup),
+	}
+	if spec.Seed != nil {
+		opts = append(opts, WithDeterministic(*spec.Seed))
+	}
+	return New(opts...), nil
+}
+
+// BuildJSON assembles a test from a JSON spec with the components of the registry
+func (r *Registry) BuildJSON(b []byte) (*Test, error) {
+	var spec Spec
+	if err := json.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	return r.Build(spec)
+}
+
+// inRange checks if the time is within start and the exclusive end, zero times are open
+func inRange(t, start, end time.Time) bool {
+	return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
+}
@@ -0,0 +1,95 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// StateSaver is the optional interface for strategies persisting their internal state,
+// e.g. indicator buffers or regime flags, so a run or paper trading session can be resumed.
+type StateSaver interface {
+	SaveState(io.Writer) error
+	LoadState(io.Reader) error
+}
+
+// SaveStrategyState writes the internal state of the strategy
+func (t *Test) SaveStrategyState(w io.Writer) error {
+	s, ok := t.strategy.(StateSaver)
+	if !ok {
+		return errors.New("strategy does not support saving its state")
+	}
+	return s.SaveState(w)
+}
+
+// LoadStrategyState restores the internal state of the strategy
+func (t *Test) LoadStrategyState(r io.Reader) error {
+	s, ok := t.strategy.(StateSaver)
+	if !ok {
+		return errors.New("strategy does not support loading its state")
+	}
+	return s.LoadState(r)
+}
+
+// SaveStrategyStateFile writes the internal state of the strategy to a file
+func (t *Test) SaveStrategyStateFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return t.SaveStrategyState(f)
+}
+
+// LoadStrategyStateFile restores the internal state of the strategy from a file
+func (t *Test) LoadStrategyStateFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return t.LoadStrategyState(f)
+}
+
+// SaveState saves the states of all attached strategies supporting it
+func (c *CompositeStrategy) SaveState(w io.Writer) error {
+	states := make([][]byte, len(c.strategies))
+	for i, strategy := range c.strategies {
+		s, ok := strategy.(StateSaver)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := s.SaveState(&buf); err != nil {
+			return err
+		}
+		states[i] = buf.Bytes()
+	}
+	return json.NewEncoder(w).Encode(states)
+}
+
+// LoadState loads the states of all attached strategies supporting it
+func (c *CompositeStrategy) LoadState(r io.Reader) error {
+	var states [][]byte
+	if err := json.NewDecoder(r).Decode(&states); err != nil {
+		return err
+	}
+	if len(states) != len(c.strategies) {
+		return errors.New("saved state does not match the attached strategies")
+	}
+
+	for i, strategy := range c.strategies {
+		s, ok := strategy.(StateSaver)
+		if !ok || states[i] == nil {
+			continue
+		}
+		if err := s.LoadState(bytes.NewReader(states[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,11 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"net/http"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
-	"github.com/wcharczuk/go-chart"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/stat"
 )
 
@@ -47,11 +47,35 @@ type StatisticUpdater interface {
 // Resulter bundles all methods which return the results of the backtest
 type Resulter interface {
 	TotalEquityReturn() (float64, error)
+	CompoundAnnualGrowthRate() (float64, error)
 	MaxDrawdown() float64
 	MaxDrawdownTime() time.Time
 	MaxDrawdownDuration() time.Duration
+	UlcerIndex() float64
+	PainIndex() float64
+	RecoveryFactor() (float64, error)
+	TimeInMarket() float64
+	AverageExposure() float64
 	SharpRatio(float64) float64
 	SortinoRatio(float64) float64
+	SortinoRatioMAR(float64, float64) (float64, error)
+	Volatility() (float64, error)
+	DownsideDeviation(float64) (float64, error)
+	ValueAtRisk(float64) (float64, error)
+	ConditionalValueAtRisk(float64) (float64, error)
+	Skewness() (float64, error)
+	Kurtosis() (float64, error)
+	BestReturn() (float64, error)
+	WorstReturn() (float64, error)
+	BenchmarkResulter
+}
+
+// BenchmarkResulter bundles the results relative to a benchmark
+type BenchmarkResulter interface {
+	Beta() (float64, error)
+	Alpha(float64) (float64, error)
+	TrackingError() (float64, error)
+	InformationRatio() (float64, error)
 }
 
 // Statistic is a basic test statistic, which holds simple lists of historic events
@@ -61,7 +85,19 @@ type Statistic struct {
 	equity             []equityPoint
 	high               equityPoint
 	low                equityPoint
-	initialBuy         float64 // TODO: this only handles one currency, needs to support multiple
+	prevHigh           equityPoint // high before the last equity point
+	prevLow            equityPoint // low before the last equity point
+	initialBuy         float64     // TODO: this only handles one currency, needs to support multiple
+	initialSymbol      string      // symbol of the buy and hold value
+	initialCash        float64
+	benchmarkName      string
+	benchmark          []DataEventHandler // benchmark prices sorted by time
+	benchmarkIndex     int                // index of the last benchmark price used
+	benchmarkBase      float64            // benchmark price at the first equity point
+	periodsPerYear     float64            // annualization factor, 0 to detect from the equity points
+	prices             map[string]float64 // latest price per symbol
+	attribution        map[string]*attributionBook
+	annotations        []Annotation // sorted by time
 }
 
 type equityPoint struct {
@@ -70,12 +106,42 @@ type equityPoint struct {
 	equityReturn    float64
 	drawdown        float64
 	buyAndHoldValue float64
+	benchmarkValue  float64 // benchmark normalized to the first equity, 0 without benchmark
+	exposure        float64 // gross market value of the positions relative to the equity
+}
+
+// EquityPoint is a point of the equity series of a backtest
+type EquityPoint struct {
+	Timestamp  time.Time
+	Equity     float64
+	Return     float64 // return relative to the previous point
+	Drawdown   float64 // drawdown relative to the previous high
+	BuyAndHold float64 // value of buying and holding with the initial cash
+	Benchmark  float64 // benchmark normalized to the first equity, 0 without benchmark
+	Exposure   float64 // gross market value of the positions relative to the equity
 }
 
 // Update the complete statistics to a given data event.
 func (s *Statistic) Update(d DataEventHandler, p PortfolioHandler) {
+	if s.prices == nil {
+		s.prices = make(map[string]float64)
+	}
+	s.prices[d.GetSymbol()] = d.LatestPrice()
+
 	if s.initialBuy == 0 {
 		s.initialBuy = p.InitialCash() / d.LatestPrice()
+		s.initialSymbol = d.GetSymbol()
+		s.initialCash = p.InitialCash()
+	}
+
+	// the data events of several symbols at the same time make a single equity point of
+	// the combined portfolio, each event replaces the point of the previous one
+	replace := len(s.equity) > 0 && s.equity[len(s.equity)-1].timestamp.Equal(d.GetTime())
+	if replace {
+		s.equity = s.equity[:len(s.equity)-1]
+		s.high, s.low = s.prevHigh, s.prevLow
+	} else {
+		s.prevHigh, s.prevLow = s.high, s.low
 	}
 
 	// create new equity point based on current data timestamp and portfolio value
@@ -83,8 +149,14 @@ func (s *Statistic) Update(d DataEventHandler, p PortfolioHandler) {
 	e.timestamp = d.GetTime()
 	e.equity = p.Value()
 
-	// Record buy and hold value
-	e.buyAndHoldValue = s.initialBuy * d.LatestPrice()
+	// Record buy and hold value of the first symbol
+	e.buyAndHoldValue = s.initialBuy * s.prices[s.initialSymbol]
+
+	// Record normalized benchmark value
+	e.benchmarkValue = s.calcBenchmarkValue(e)
+
+	// Record gross exposure of all positions
+	e.exposure = calcExposure(p, e.equity)
 
 	// calc equity return for current equity point
 	if len(s.equity) > 0 {
@@ -106,6 +178,9 @@ func (s *Statistic) Update(d DataEventHandler, p PortfolioHandler) {
 
 	// append new quity point
 	s.equity = append(s.equity, e)
+
+	// mark the holdings attributed to the strategies to market
+	s.updateAttribution(replace)
 }
 
 // TrackEvent tracks an event
@@ -121,6 +196,7 @@ func (s Statistic) Events() []EventHandler {
 // TrackTransaction tracks a transaction aka a fill event
 func (s *Statistic) TrackTransaction(f FillEvent) {
 	s.transactionHistory = append(s.transactionHistory, f)
+	s.trackAttribution(f)
 }
 
 // Transactions returns the complete events history
@@ -128,6 +204,23 @@ func (s Statistic) Transactions() []FillEvent {
 	return s.transactionHistory
 }
 
+// Equity returns the equity series
+func (s Statistic) Equity() []EquityPoint {
+	points := make([]EquityPoint, 0, len(s.equity))
+	for _, e := range s.equity {
+		points = append(points, EquityPoint{
+			Timestamp:  e.timestamp,
+			Equity:     e.equity,
+			Return:     e.equityReturn,
+			Drawdown:   e.drawdown,
+			BuyAndHold: e.buyAndHoldValue,
+			Benchmark:  e.benchmarkValue,
+			Exposure:   e.exposure,
+		})
+	}
+	return points
+}
+
 // Reset the statistic to a clean state
 func (s *Statistic) Reset() {
 	s.eventHistory = nil
@@ -135,17 +228,26 @@ func (s *Statistic) Reset() {
 	s.equity = nil
 	s.high = equityPoint{}
 	s.low = equityPoint{}
+	s.prevHigh = equityPoint{}
+	s.prevLow = equityPoint{}
+	s.initialBuy = 0
+	s.initialSymbol = ""
+	s.benchmarkIndex = 0
+	s.benchmarkBase = 0
+	s.prices = nil
+	s.attribution = nil
 }
 
-// PrintResult prints the backtest statistics to the screen
-func (s Statistic) PrintResult() {
-	fmt.Println("Printing backtest results:")
-	fmt.Printf("Counted %d total events.\n", len(s.Events()))
-
-	fmt.Printf("Counted %d total transactions:\n", len(s.Transactions()))
-	for k, v := range s.Transactions() {
-		fmt.Printf("%d. Transaction: %v Action: %s Price: %f Qty: %f\n", k+1, v.GetTime().Format("2006-01-02 03:04 PM"), v.GetDirection(), v.GetPrice(), v.GetQty())
-	}
+// SetBenchmark sets a benchmark price series, e.g. the stream of a separately loaded
+// Data, which is normalized to the equity of the first equity point
+func (s *Statistic) SetBenchmark(name string, prices []DataEventHandler) {
+	s.benchmarkName = name
+	s.benchmark = append([]DataEventHandler(nil), prices...)
+	sort.SliceStable(s.benchmark, func(i, j int) bool {
+		return s.benchmark[i].GetTime().Before(s.benchmark[j].GetTime())
+	})
+	s.benchmarkIndex = 0
+	s.benchmarkBase = 0
 }
 
 // TotalEquityReturn calculates the the total return on the first and last equity point
@@ -167,6 +269,27 @@ func (s Statistic) TotalEquityReturn() (r float64, err error) {
 	return total, nil
 }
 
+// CompoundAnnualGrowthRate calculates the annualized return between the first and last equity point
+func (s Statistic) CompoundAnnualGrowthRate() (r float64, err error) {
+	first, ok := s.firstEquityPoint()
+	if !ok {
+		return r, errors.New("could not calculate CAGR, no equity points found")
+	}
+	last, _ := s.lastEquityPoint()
+
+	years := float64(last.timestamp.Sub(first.timestamp)) / float64(calendarYear)
+	if years <= 0 {
+		return r, errors.New("could not calculate CAGR, no time elapsed")
+	}
+	if first.equity <= 0 || last.equity < 0 {
+		return r, errors.New("could not calculate CAGR, equity is not positive")
+	}
+
+	cagr := decimal.NewFromFloat(math.Pow(last.equity/first.equity, 1/years) - 1)
+	r, _ = cagr.Round(DP).Float64()
+	return r, nil
+}
+
 // MaxDrawdown returns the maximum draw down value in percent.
 func (s Statistic) MaxDrawdown() float64 {
 	_, ep := s.maxDrawdownPoint()
@@ -199,103 +322,336 @@ func (s Statistic) MaxDrawdownDuration() (d time.Duration) {
 	return d
 }
 
-func (s *Statistic) GraphResult(res http.ResponseWriter, req *http.Request) {
-	var xv []time.Time
+// UlcerIndex returns the root mean square of the drawdowns of all equity points
+func (s Statistic) UlcerIndex() float64 {
+	if len(s.equity) == 0 {
+		return 0
+	}
 
-	var yv1 []float64
-	var yv2 []float64
+	var sum float64
+	for _, e := range s.equity {
+		sum += e.drawdown * e.drawdown
+	}
+	return math.Sqrt(sum / float64(len(s.equity)))
+}
 
-	var maxY float64
-	var minY float64
+// PainIndex returns the mean depth of the drawdowns of all equity points
+func (s Statistic) PainIndex() float64 {
+	if len(s.equity) == 0 {
+		return 0
+	}
 
+	var sum float64
 	for _, e := range s.equity {
-		xv = append(xv, e.timestamp)
-		yv1 = append(yv1, e.equity)
-		yv2 = append(yv2, e.buyAndHoldValue)
-		maxY = math.Max(math.Max(maxY, e.equity), e.buyAndHoldValue)
-		if minY == 0 {
-			minY = maxY
+		sum += math.Abs(e.drawdown)
+	}
+	return sum / float64(len(s.equity))
+}
+
+// RecoveryFactor returns the net profit divided by the largest drop of the equity from a previous high
+func (s Statistic) RecoveryFactor() (float64, error) {
+	first, ok := s.firstEquityPoint()
+	if !ok {
+		return 0, errors.New("could not calculate recovery factor, no equity points found")
+	}
+	last, _ := s.lastEquityPoint()
+
+	var high, maxDrop float64
+	for _, e := range s.equity {
+		high = math.Max(high, e.equity)
+		maxDrop = math.Max(maxDrop, high-e.equity)
+	}
+	if maxDrop == 0 {
+		return 0, errors.New("could not calculate recovery factor, no drawdown")
+	}
+
+	return (last.equity - first.equity) / maxDrop, nil
+}
+
+// TimeInMarket returns the fraction of equity points with an open position
+func (s Statistic) TimeInMarket() float64 {
+	if len(s.equity) == 0 {
+		return 0
+	}
+
+	var invested int
+	for _, e := range s.equity {
+		if e.exposure > 0 {
+			invested++
 		}
-		minY = math.Min(math.Min(minY, e.equity), e.buyAndHoldValue)
-	}
-
-	fmt.Println(maxY, minY)
-
-	priceSeries := chart.TimeSeries{
-		Name: "SPY",
-		Style: chart.Style{
-			Show:        true,
-			StrokeColor: chart.GetDefaultColor(0),
-		},
-		XValues: xv,
-		YValues: yv1,
-	}
-
-	comparisonSeries := chart.TimeSeries{
-		Name: "SPC",
-		Style: chart.Style{
-			Show:        true,
-			StrokeColor: chart.GetDefaultColor(1),
-		},
-		XValues: xv,
-		YValues: yv2,
-	}
-
-	graph := chart.Chart{
-		XAxis: chart.XAxis{
-			Style:        chart.Style{Show: true},
-			TickPosition: chart.TickPositionBetweenTicks,
-		},
-		YAxis: chart.YAxis{
-			Style: chart.Style{Show: true},
-			Range: &chart.ContinuousRange{
-				Max: maxY + (maxY-minY)/10,
-				Min: minY - (maxY-minY)/10,
-			},
-		},
-		Series: []chart.Series{
-			priceSeries,
-			comparisonSeries,
-		},
-	}
-
-	res.Header().Set("Content-Type", "image/png")
-	graph.Render(chart.PNG, res)
-}
-
-// SharpRatio returns the Sharp ratio compared to a risk free benchmark return.
-func (s *Statistic) SharpRatio(riskfree float64) float64 {
-	var equityReturns = make([]float64, len(s.equity))
+	}
+	return float64(invested) / float64(len(s.equity))
+}
+
+// AverageExposure returns the mean gross market value of the positions relative to the equity
+func (s Statistic) AverageExposure() float64 {
+	if len(s.equity) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, e := range s.equity {
+		sum += e.exposure
+	}
+	return sum / float64(len(s.equity))
+}
 
-	for i, v := range s.equity {
-		equityReturns[i] = v.equityReturn
+// SharpRatio returns the annualized Sharp ratio compared to an annual risk free rate,
+// see PeriodsPerYear for the annualization of the per period returns. It is NaN with
+// less than two returns.
+func (s *Statistic) SharpRatio(riskfree float64) float64 {
+	equityReturns, err := s.equityReturns(2)
+	if err != nil {
+		return math.NaN()
 	}
 	mean, stddev := stat.MeanStdDev(equityReturns, nil)
 
-	sharp := (mean - riskfree) / stddev
+	periods := s.PeriodsPerYear()
+	sharp := (mean - riskfree/periods) / stddev * math.Sqrt(periods)
 	return sharp
 }
 
-// SortinoRatio returns the Sortino ratio compared to a risk free benchmark return.
+// SortinoRatio returns the annualized Sortino ratio compared to an annual risk free rate,
+// which is also used as minimum acceptable return. It returns 0 if there are no returns
+// below the risk free rate, see SortinoRatioMAR.
 func (s *Statistic) SortinoRatio(riskfree float64) float64 {
-	var equityReturns = make([]float64, len(s.equity))
+	sortino, err := s.SortinoRatioMAR(riskfree, riskfree)
+	if err != nil {
+		return 0
+	}
+	return sortino
+}
 
-	for i, v := range s.equity {
-		equityReturns[i] = v.equityReturn
+// SortinoRatioMAR returns the annualized Sortino ratio of the returns above an annual risk
+// free rate relative to the downside deviation below an annual minimum acceptable return.
+// The downside deviation is the root mean square of the shortfalls of all returns below
+// the minimum acceptable return, without shortfalls an error is returned.
+func (s *Statistic) SortinoRatioMAR(riskfree, mar float64) (float64, error) {
+	equityReturns, err := s.equityReturns(1)
+	if err != nil {
+		return 0, errors.New("could not calculate sortino ratio, not enough equity points")
 	}
 	mean := stat.Mean(equityReturns, nil)
 
-	// sortino uses the stddev of only the negativ returns
-	var negReturns = []float64{}
-	for _, v := range equityReturns {
-		if v < 0 {
-			negReturns = append(negReturns, v)
+	periods := s.PeriodsPerYear()
+	downside := downsideDeviation(equityReturns, mar/periods)
+	if downside == 0 {
+		return 0, errors.New("could not calculate sortino ratio, no returns below the minimum acceptable return")
+	}
+
+	sortino := (mean - riskfree/periods) / downside * math.Sqrt(periods)
+	return sortino, nil
+}
+
+// Volatility returns the annualized realized volatility, the standard deviation of the equity
+// returns, at least two of them
+func (s Statistic) Volatility() (float64, error) {
+	equityReturns, err := s.equityReturns(2)
+	if err != nil {
+		return 0, errors.New("could not calculate volatility, not enough equity points")
+	}
+	return stat.StdDev(equityReturns, nil) * math.Sqrt(s.PeriodsPerYear()), nil
+}
+
+// DownsideDeviation returns the annualized downside deviation of the equity returns below
+// an annual minimum acceptable return, as used by the Sortino ratio
+func (s Statistic) DownsideDeviation(mar float64) (float64, error) {
+	equityReturns, err := s.equityReturns(1)
+	if err != nil {
+		return 0, errors.New("could not calculate downside deviation, not enough equity points")
+	}
+	periods := s.PeriodsPerYear()
+	return downsideDeviation(equityReturns, mar/periods) * math.Sqrt(periods), nil
+}
+
+// downsideDeviation returns the root mean square of the shortfalls of all returns below the target
+func downsideDeviation(returns []float64, target float64) float64 {
+	var shortfall float64
+	for _, v := range returns {
+		if v < target {
+			shortfall += (v - target) * (v - target)
+		}
+	}
+	return math.Sqrt(shortfall / float64(len(returns)))
+}
+
+// ValueAtRisk returns the historical value at risk of the equity returns at a confidence
+// level between 0 and 1, the loss per period which is not exceeded with that confidence
+func (s Statistic) ValueAtRisk(confidence float64) (float64, error) {
+	returns, err := s.sortedReturns(confidence)
+	if err != nil {
+		return 0, err
+	}
+	return -stat.Quantile(1-confidence, stat.Empirical, returns, nil), nil
+}
+
+// ConditionalValueAtRisk returns the expected shortfall of the equity returns at a confidence
+// level between 0 and 1, the average loss per period of the returns beyond the value at risk
+func (s Statistic) ConditionalValueAtRisk(confidence float64) (float64, error) {
+	returns, err := s.sortedReturns(confidence)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := stat.Quantile(1-confidence, stat.Empirical, returns, nil)
+	var tail []float64
+	for _, r := range returns {
+		if r > cutoff {
+			break
 		}
+		tail = append(tail, r)
 	}
-	stdDev := stat.StdDev(negReturns, nil)
+	return -stat.Mean(tail, nil), nil
+}
 
-	sortino := (mean - riskfree) / stdDev
-	return sortino
+// Skewness returns the sample skewness of the equity returns, negative values indicate
+// a longer tail of losses than of gains
+func (s Statistic) Skewness() (float64, error) {
+	returns, err := s.equityReturns(3)
+	if err != nil {
+		return 0, err
+	}
+	return stat.Skew(returns, nil), nil
+}
+
+// Kurtosis returns the excess kurtosis of the equity returns, positive values indicate
+// fatter tails than the normal distribution
+func (s Statistic) Kurtosis() (float64, error) {
+	returns, err := s.equityReturns(4)
+	if err != nil {
+		return 0, err
+	}
+	return stat.ExKurtosis(returns, nil), nil
+}
+
+// BestReturn returns the highest equity return of a single period
+func (s Statistic) BestReturn() (float64, error) {
+	returns, err := s.equityReturns(1)
+	if err != nil {
+		return 0, err
+	}
+	return floats.Max(returns), nil
+}
+
+// WorstReturn returns the lowest equity return of a single period
+func (s Statistic) WorstReturn() (float64, error) {
+	returns, err := s.equityReturns(1)
+	if err != nil {
+		return 0, err
+	}
+	return floats.Min(returns), nil
+}
+
+// equityReturns returns the equity returns after the first equity point, at least n of them
+func (s Statistic) equityReturns(n int) ([]float64, error) {
+	if len(s.equity) < n+1 {
+		return nil, errors.New("not enough equity points to calculate the returns")
+	}
+
+	returns := make([]float64, 0, len(s.equity)-1)
+	for _, e := range s.equity[1:] {
+		returns = append(returns, e.equityReturn)
+	}
+	return returns, nil
+}
+
+// sortedReturns returns the equity returns after the first equity point in ascending order
+func (s Statistic) sortedReturns(confidence float64) ([]float64, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return nil, errors.New("confidence level must be between 0 and 1")
+	}
+	returns, err := s.equityReturns(1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Float64s(returns)
+	return returns, nil
+}
+
+// Beta returns the covariance of the equity returns with the benchmark returns
+// relative to the variance of the benchmark returns
+func (s Statistic) Beta() (float64, error) {
+	returns, benchmark, err := s.benchmarkReturns()
+	if err != nil {
+		return 0, err
+	}
+
+	variance := stat.Variance(benchmark, nil)
+	if variance == 0 {
+		return 0, errors.New("could not calculate beta, benchmark has no variance")
+	}
+	return stat.Covariance(returns, benchmark, nil) / variance, nil
+}
+
+// Alpha returns the annualized Jensen's alpha, the mean equity return above the return
+// expected by the beta to the benchmark and an annual risk free rate, like the Sharp ratio
+// annualized with PeriodsPerYear
+func (s Statistic) Alpha(riskfree float64) (float64, error) {
+	beta, err := s.Beta()
+	if err != nil {
+		return 0, err
+	}
+	returns, benchmark, _ := s.benchmarkReturns()
+
+	periods := s.PeriodsPerYear()
+	rf := riskfree / periods
+	alpha := stat.Mean(returns, nil) - (rf + beta*(stat.Mean(benchmark, nil)-rf))
+	return alpha * periods, nil
+}
+
+// TrackingError returns the standard deviation of the equity returns above the benchmark returns
+func (s Statistic) TrackingError() (float64, error) {
+	active, err := s.activeReturns()
+	if err != nil {
+		return 0, err
+	}
+	return stat.StdDev(active, nil), nil
+}
+
+// InformationRatio returns the mean equity return above the benchmark return relative to the tracking error
+func (s Statistic) InformationRatio() (float64, error) {
+	active, err := s.activeReturns()
+	if err != nil {
+		return 0, err
+	}
+
+	mean, stddev := stat.MeanStdDev(active, nil)
+	if stddev == 0 {
+		return 0, errors.New("could not calculate information ratio, no tracking error")
+	}
+	return mean / stddev, nil
+}
+
+// benchmarkReturns returns the equity and benchmark returns of all equity points
+// which have a benchmark value for themselves and their predecessor
+func (s Statistic) benchmarkReturns() (returns, benchmark []float64, err error) {
+	for i := 1; i < len(s.equity); i++ {
+		prev, cur := s.equity[i-1].benchmarkValue, s.equity[i].benchmarkValue
+		if prev == 0 || cur == 0 {
+			continue
+		}
+		returns = append(returns, s.equity[i].equityReturn)
+		benchmark = append(benchmark, (cur-prev)/prev)
+	}
+
+	if len(returns) < 2 {
+		return nil, nil, errors.New("not enough benchmark returns, set a benchmark covering the test")
+	}
+	return returns, benchmark, nil
+}
+
+// activeReturns returns the equity returns above the benchmark returns
+func (s Statistic) activeReturns() ([]float64, error) {
+	returns, benchmark, err := s.benchmarkReturns()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]float64, len(returns))
+	for i := range returns {
+		active[i] = returns[i] - benchmark[i]
+	}
+	return active, nil
 }
 
 func (s Statistic) ViewEquityHistory() {
@@ -346,6 +702,51 @@ func (s Statistic) calcEquityReturn(e equityPoint) equityPoint {
 	return e
 }
 
+// calculates the benchmark value of an equity point from the last known benchmark price,
+// normalized to the equity of the first equity point
+func (s *Statistic) calcBenchmarkValue(e equityPoint) float64 {
+	if len(s.benchmark) == 0 {
+		return 0
+	}
+
+	// advance to the last benchmark price at or before the equity point
+	for s.benchmarkIndex+1 < len(s.benchmark) && !s.benchmark[s.benchmarkIndex+1].GetTime().After(e.timestamp) {
+		s.benchmarkIndex++
+	}
+	latest := s.benchmark[s.benchmarkIndex]
+	if latest.GetTime().After(e.timestamp) {
+		return 0
+	}
+
+	// benchmark price per unit of the first equity
+	if s.benchmarkBase == 0 {
+		first := e.equity
+		if len(s.equity) > 0 {
+			first = s.equity[0].equity
+		}
+		if first == 0 || latest.LatestPrice() == 0 {
+			return 0
+		}
+		s.benchmarkBase = latest.LatestPrice() / first
+	}
+
+	value, _ := decimal.NewFromFloat(latest.LatestPrice()).Div(decimal.NewFromFloat(s.benchmarkBase)).Round(DP).Float64()
+	return value
+}
+
+// calculates the gross market value of all positions relative to the equity
+func calcExposure(p PortfolioHandler, equity float64) float64 {
+	if equity == 0 {
+		return 0
+	}
+
+	var gross float64
+	for _, pos := range p.Positions() {
+		gross += math.Abs(pos.Qty * pos.MarketPrice)
+	}
+	return gross / equity
+}
+
 // calculates the drawdown of an equity point relativ to the latest high of the statistic handler
 func (s Statistic) calcDrawdown(e equityPoint) equityPoint {
 	if s.high.equity == 0 {
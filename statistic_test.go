@@ -0,0 +1,92 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// statisticOf returns a statistic of daily equity points with the returns, the first
+// point has the synthetic return 0
+func statisticOf(returns ...float64) *Statistic {
+	s := &Statistic{}
+	equity := 1000.0
+	s.equity = append(s.equity, equityPoint{timestamp: queueStart, equity: equity})
+	for i, r := range returns {
+		equity *= 1 + r
+		s.equity = append(s.equity, equityPoint{
+			timestamp:    queueStart.Add(time.Duration(i+1) * 24 * time.Hour),
+			equity:       equity,
+			equityReturn: r,
+		})
+	}
+	return s
+}
+
+func TestReturnMetricsSample(t *testing.T) {
+	returns := []float64{0.01, -0.02, 0.015, 0.005, -0.01}
+	s := statisticOf(returns...)
+	periods := s.PeriodsPerYear()
+
+	mean, stddev := stat.MeanStdDev(returns, nil)
+	if got, want := s.SharpRatio(0), mean/stddev*math.Sqrt(periods); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Sharpe ratio is %v, want %v over the returns after the first point", got, want)
+	}
+	volatility, err := s.Volatility()
+	if want := stddev * math.Sqrt(periods); err != nil || math.Abs(volatility-want) > 1e-9 {
+		t.Errorf("volatility is %v, %v, want %v", volatility, err, want)
+	}
+
+	var shortfall float64
+	for _, r := range returns {
+		if r < 0 {
+			shortfall += r * r
+		}
+	}
+	downside := math.Sqrt(shortfall / float64(len(returns)))
+	got, err := s.DownsideDeviation(0)
+	if want := downside * math.Sqrt(periods); err != nil || math.Abs(got-want) > 1e-9 {
+		t.Errorf("downside deviation is %v, %v, want %v", got, err, want)
+	}
+	got, err = s.SortinoRatioMAR(0, 0)
+	if want := mean / downside * math.Sqrt(periods); err != nil || math.Abs(got-want) > 1e-9 {
+		t.Errorf("Sortino ratio is %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestReturnMetricsTooFewReturns(t *testing.T) {
+	s := statisticOf(0.01)
+	if sharpe := s.SharpRatio(0); !math.IsNaN(sharpe) {
+		t.Errorf("Sharpe ratio of a single return is %v, want NaN", sharpe)
+	}
+	if _, err := s.Volatility(); err == nil {
+		t.Error("volatility of a single return calculated")
+	}
+}
+
+func TestAlphaAnnualized(t *testing.T) {
+	returns := []float64{0.01, -0.02, 0.015, 0.005, -0.01}
+	benchmark := []float64{0.005, -0.01, 0.01, 0.0, -0.005}
+	s := statisticOf(returns...)
+	value := 1000.0
+	s.equity[0].benchmarkValue = value
+	for i, r := range benchmark {
+		value *= 1 + r
+		s.equity[i+1].benchmarkValue = value
+	}
+
+	beta, err := s.Beta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	periods := s.PeriodsPerYear()
+	rf := 0.02 / periods
+	want := (stat.Mean(returns, nil) - (rf + beta*(stat.Mean(benchmark, nil)-rf))) * periods
+
+	alpha, err := s.Alpha(0.02)
+	if err != nil || math.Abs(alpha-want) > 1e-9 {
+		t.Errorf("alpha at an annual risk free rate of 2%% is %v, %v, want %v", alpha, err, want)
+	}
+}
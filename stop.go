@@ -0,0 +1,135 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunState is the state of a running test checked by the stop rules
+type RunState struct {
+	Time              time.Time     // time of the last event
+	Equity            float64       // current value of the portfolio
+	HighEquity        float64       // highest value of the portfolio
+	Drawdown          float64       // drop from the highest value as fraction, e.g. 0.2
+	ConsecutiveLosses int           // number of losing trades in a row
+	Elapsed           time.Duration // wall time of the run
+}
+
+// StopRule ends a test early, e.g. when its parameters obviously ruined the portfolio
+type StopRule interface {
+	// Check returns the reason to stop the test, or an empty string to continue
+	Check(RunState) string
+}
+
+// StopFunc is a function implementing the StopRule interface
+type StopFunc func(RunState) string
+
+// Check implements the StopRule interface
+func (f StopFunc) Check(s RunState) string {
+	return f(s)
+}
+
+// EquityFloor stops the test when the equity falls below the floor
+func EquityFloor(floor float64) StopRule {
+	return StopFunc(func(s RunState) string {
+		if s.Equity < floor {
+			return fmt.Sprintf("equity %.2f below floor %.2f", s.Equity, floor)
+		}
+		return ""
+	})
+}
+
+// DrawdownLimit stops the test when the drawdown exceeds the limit, e.g. 0.3 for 30%
+func DrawdownLimit(limit float64) StopRule {
+	return StopFunc(func(s RunState) string {
+		if s.Drawdown > limit {
+			return fmt.Sprintf("drawdown %.4g%% beyond limit %.4g%%", s.Drawdown*100, limit*100)
+		}
+		return ""
+	})
+}
+
+// ConsecutiveLosses stops the test after n losing trades in a row
+func ConsecutiveLosses(n int) StopRule {
+	return StopFunc(func(s RunState) string {
+		if s.ConsecutiveLosses >= n {
+			return fmt.Sprintf("%d consecutive losing trades", s.ConsecutiveLosses)
+		}
+		return ""
+	})
+}
+
+// MaxWallTime stops the test when it runs longer than the duration
+func MaxWallTime(d time.Duration) StopRule {
+	return StopFunc(func(s RunState) string {
+		if s.Elapsed > d {
+			return fmt.Sprintf("wall time %v exceeded", d)
+		}
+		return ""
+	})
+}
+
+// EarlyStopError is returned by Run if a stop rule ended the test, the statistic holds
+// the results up to the stop
+type EarlyStopError struct {
+	Time   time.Time
+	Reason string
+}
+
+// Error implements the error interface
+func (e *EarlyStopError) Error() string {
+	return fmt.Sprintf("test stopped early at %s: %s", e.Time.Format(time.RFC3339), e.Reason)
+}
+
+// AddStopRule appends rules checked after every event, the first rule returning a reason
+// ends the test with an EarlyStopError
+func (t *Test) AddStopRule(rules ...StopRule) {
+	t.stopRules = append(t.stopRules, rules...)
+}
+
+// stopTracker keeps the run state of the stop rules
+type stopTracker struct {
+	state RunState
+	start time.Time
+	open  Position // position of the fill symbol before the fill
+}
+
+// beforeFill remembers the position of the fill symbol
+func (s *stopTracker) beforeFill(f FillEvent, p PortfolioHandler) {
+	s.open, _ = p.Position(f.GetSymbol())
+}
+
+// afterFill counts the losing trades closed by the fill
+func (s *stopTracker) afterFill(f FillEvent, p PortfolioHandler) {
+	pos, _ := p.Position(f.GetSymbol())
+	closed := s.open.Qty != 0 && (pos.Qty == 0 || (pos.Qty > 0) != (s.open.Qty > 0))
+	if !closed {
+		return
+	}
+	if pos.RealProfitLoss-s.open.RealProfitLoss < 0 {
+		s.state.ConsecutiveLosses++
+		return
+	}
+	s.state.ConsecutiveLosses = 0
+}
+
+// check updates the state after an event and returns the reason of the first rule to stop
+func (s *stopTracker) check(rules []StopRule, e EventHandler, p PortfolioHandler) string {
+	s.state.Time = e.GetTime()
+	s.state.Equity = p.Value()
+	if s.state.Equity > s.state.HighEquity {
+		s.state.HighEquity = s.state.Equity
+	}
+	s.state.Drawdown = 0
+	if s.state.HighEquity > 0 {
+		s.state.Drawdown = (s.state.HighEquity - s.state.Equity) / s.state.HighEquity
+	}
+	s.state.Elapsed = time.Since(s.start)
+
+	for _, rule := range rules {
+		if reason := rule.Check(s.state); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package backtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunRecord is the summary of a saved run
+type RunRecord struct {
+	ID          string                 `json:"id"`
+	Time        time.Time              `json:"time"`
+	Config      map[string]interface{} `json:"config"`
+	DatasetHash string                 `json:"dataset_hash"`
+	ConfigHash  string                 `json:"config_hash,omitempty"` // hash of the config and dataset
+	Metrics     MetricsJSON            `json:"metrics"`
+}
+
+// StoredRun is a saved run with its full results
+type StoredRun struct {
+	RunRecord
+	Result ResultJSON `json:"result"`
+}
+
+// ResultStore saves the results of runs as JSON objects in an object store, one object
+// per run
+type ResultStore struct {
+	objects ObjectStore
+	prefix  string // key prefix of the runs
+	mu      sync.Mutex
+	index   map[string]string // run IDs by config hash, loaded by Find
+}
+
+// NewResultStore creates a result store in the directory, which is created if missing, or
+// at the URL of a registered object store, e.g. s3://bucket/runs
+func NewResultStore(location string) (*ResultStore, error) {
+	objects, prefix, err := OpenObjectStore(location)
+	if err != nil {
+		return nil, err
+	}
+	if dir, ok := objects.(DirStore); ok {
+		if err := os.MkdirAll(string(dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return NewObjectResultStore(objects, prefix), nil
+}
+
+// NewObjectResultStore creates a result store saving the runs in the object store with
+// the key prefix, e.g. runs/
+func NewObjectResultStore(objects ObjectStore, prefix string) *ResultStore {
+	return &ResultStore{objects: objects, prefix: prefix}
+}
+
+// Save saves the results of the statistic with a config summary, e.g. the strategy
+// params, and the hash of the dataset, which may be nil. It returns the saved record
+// with the new run ID.
+func (r *ResultStore) Save(s *Statistic, config map[string]interface{}, data DataStreamer) (RunRecord, error) {
+	var datasetHash string
+	if data != nil {
+		datasetHash = DatasetHash(data)
+	}
+	return r.save(s, config, datasetHash)
+}
+
+// save saves the results with the hash of the dataset
+func (r *ResultStore) save(s *Statistic, config map[string]interface{}, datasetHash string) (RunRecord, error) {
+	id, err := newRunID()
+	if err != nil {
+		return RunRecord{}, err
+	}
+
+	result := s.JSON()
+	run := StoredRun{
+		RunRecord: RunRecord{
+			ID:          id,
+			Time:        time.Now(),
+			Config:      config,
+			DatasetHash: datasetHash,
+			Metrics:     result.Metrics,
+		},
+		Result: result,
+	}
+	if run.ConfigHash, err = ConfigHash(config, run.DatasetHash); err != nil {
+		return RunRecord{}, err
+	}
+
+	b, err := json.MarshalIndent(run, "", " ")
+	if err != nil {
+		return RunRecord{}, err
+	}
+	if err := r.objects.Put(context.Background(), r.key(id), append(b, '\n')); err != nil {
+		return RunRecord{}, err
+	}
+	if err := r.objects.Put(context.Background(), r.hashKey(run.ConfigHash), []byte(id)); err != nil {
+		return RunRecord{}, err
+	}
+
+	r.mu.Lock()
+	if r.index != nil {
+		r.index[run.ConfigHash] = id
+	}
+	r.mu.Unlock()
+	return run.RunRecord, nil
+}
+
+// Load loads a saved run by its ID
+func (r *ResultStore) Load(id string) (StoredRun, error) {
+	var run StoredRun
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return run, errors.New("invalid run id")
+	}
+
+	b, err := r.objects.Get(context.Background(), r.key(id))
+	if err != nil {
+		return run, err
+	}
+	err = json.Unmarshal(b, &run)
+	return run, err
+}
+
+// List returns the records of all saved runs, the oldest first
+func (r *ResultStore) List() ([]RunRecord, error) {
+	ctx := context.Background()
+	keys, err := r.objects.List(ctx, r.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]RunRecord, 0, len(keys))
+	for _, key := range keys {
+		// runs are directly below the prefix
+		name := strings.TrimPrefix(key, r.prefix)
+		if strings.Contains(name, "/") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		b, err := r.objects.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		var record RunRecord
+		if err := json.Unmarshal(b, &record); err != nil {
+			return nil, fmt.Errorf("could not read run %s: %v", name, err)
+		}
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// Delete removes a saved run
+func (r *ResultStore) Delete(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return errors.New("invalid run id")
+	}
+	r.mu.Lock()
+	r.index = nil // rebuilt by the next Find
+	r.mu.Unlock()
+
+	ctx := context.Background()
+	run, err := r.Load(id)
+	if err != nil {
+		return err
+	}
+	// the hash may point to a newer run with the same configuration
+	if run.ConfigHash != "" {
+		b, err := r.objects.Get(ctx, r.hashKey(run.ConfigHash))
+		if err == nil && strings.TrimSpace(string(b)) == id {
+			if err := r.objects.Delete(ctx, r.hashKey(run.ConfigHash)); err != nil {
+				return err
+			}
+		}
+	}
+	return r.objects.Delete(ctx, r.key(id))
+}
+
+// key returns the object key of a run
+func (r *ResultStore) key(id string) string {
+	return r.prefix + id + ".json"
+}
+
+// DatasetHasher is the optional interface of data handlers streaming other events than the
+// events of their stream, e.g. stressed data, it returns the hash of the streamed events
+type DatasetHasher interface {
+	DatasetHash() string
+}
+
+// DatasetHash returns the SHA-256 hash of the data events of the stream, the already
+// streamed and the remaining, to identify runs on the same dataset
+func DatasetHash(data DataStreamer) string {
+	if d, ok := data.(DatasetHasher); ok {
+		return d.DatasetHash()
+	}
+	h := sha256.New()
+	for _, events := range [][]DataEventHandler{data.History(), data.Stream()} {
+		for _, e := range events {
+			fmt.Fprintf(h, "%d %s %v", e.GetTime().UnixNano(), e.GetSymbol(), e.LatestPrice())
+			if bar, ok := e.(Bar); ok {
+				fmt.Fprintf(h, " %v %v %v %v %v", bar.Open, bar.High, bar.Low, bar.Close, bar.Volume)
+			}
+			fmt.Fprintln(h)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newRunID returns a new run ID, sortable by creation time
+func newRunID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b), nil
+}
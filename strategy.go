@@ -2,6 +2,7 @@ package backtest
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -10,11 +11,57 @@ type StrategyHandler interface {
 	CalculateSignal(DataEventHandler, DataHandler, PortfolioHandler) (SignalEvent, error)
 }
 
-type Strategy struct{}
+// FillListener is the optional interface for strategies which need to be notified about fills
+type FillListener interface {
+	OnFill(FillEvent)
+}
+
+// DefaultRand is the package-level random number generator. Strategies, Monte Carlo
+// simulations and bootstraps without a generator of their own get one seeded from it, so
+// seeding it with SetSeed makes them reproducible. It is not safe for concurrent use, use
+// it directly only while no tests run.
+var DefaultRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// defaultRandMu guards DefaultRand against the components of concurrent tests
+var defaultRandMu sync.Mutex
+
+// SetSeed reseeds the package-level random number generator, the components created
+// without a generator afterwards draw the same numbers on every run. Tests in determinism
+// mode seed their components instead, see SetDeterministic.
+func SetSeed(seed int64) {
+	defaultRandMu.Lock()
+	DefaultRand = rand.New(rand.NewSource(seed))
+	defaultRandMu.Unlock()
+}
+
+// Strategy is a basic strategy creating random signals
+type Strategy struct {
+	rand *rand.Rand
+}
 
-func randInt() int {
-	rand.Seed(time.Now().UnixNano())
-	num := rand.Float32()
+// SetSeed seeds the random number generator of the strategy, so runs can be replayed exactly
+func (s *Strategy) SetSeed(seed int64) {
+	s.rand = rand.New(rand.NewSource(seed))
+}
+
+// SetRand sets the random number generator of the strategy
+func (s *Strategy) SetRand(r *rand.Rand) {
+	s.rand = r
+}
+
+// newRand returns a random number generator seeded from DefaultRand for components
+// without their own, so concurrent tests don't share one
+func newRand() *rand.Rand {
+	defaultRandMu.Lock()
+	defer defaultRandMu.Unlock()
+	return rand.New(rand.NewSource(DefaultRand.Int63()))
+}
+
+func (s *Strategy) randInt() int {
+	if s.rand == nil {
+		s.rand = newRand()
+	}
+	num := s.rand.Float32()
 	if num < 0.2 {
 		return 1
 	} else if num < 0.4 {
@@ -23,10 +70,11 @@ func randInt() int {
 	return 0
 }
 
+// CalculateSignal creates a random buy or sell signal
 func (s *Strategy) CalculateSignal(de DataEventHandler, d DataHandler, p PortfolioHandler) (SignalEvent, error) {
 	event := Event{Time: de.GetTime(), Symbol: de.GetSymbol()}
 	signal := Signal{Event: event}
-	switch randInt() {
+	switch s.randInt() {
 	case 1:
 		signal.SetDirection("buy")
 		break
@@ -0,0 +1,34 @@
+package backtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetSeedReproducible(t *testing.T) {
+	defer SetSeed(time.Now().UnixNano())
+
+	draw := func() ([]int, MonteCarloResult) {
+		SetSeed(42)
+		var s Strategy
+		var ints []int
+		for i := 0; i < 20; i++ {
+			ints = append(ints, s.randInt())
+		}
+		result, err := MonteCarlo{Runs: 10, Replace: true}.Run(1000, []float64{10, -5, 20, -15, 5})
+		if err != nil {
+			t.Fatalf("monte carlo failed: %v", err)
+		}
+		return ints, result
+	}
+
+	ints1, result1 := draw()
+	ints2, result2 := draw()
+	if !reflect.DeepEqual(ints1, ints2) {
+		t.Errorf("unseeded strategies drew %v and %v after the same seed", ints1, ints2)
+	}
+	if !reflect.DeepEqual(result1, result2) {
+		t.Error("unseeded monte carlo simulations differ after the same seed")
+	}
+}
@@ -0,0 +1,251 @@
+package backtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scenario is a synthetic shock overlaid on the historical data of a stress test
+type Scenario interface {
+	// Apply returns the data event as seen under the scenario, the events are
+	// passed in the order of the data stream
+	Apply(DataEventHandler) DataEventHandler
+}
+
+// OutageChecker is implemented by data handlers with exchange outages, during which
+// the exchange neither fills pending orders nor accepts new ones
+type OutageChecker interface {
+	Outage(time.Time) bool
+}
+
+// errOutage is the warning of an order rejected during an outage
+var errOutage = errors.New("exchange outage, order rejected")
+
+// Gap moves the prices of a symbol by a relative change from a point in time on, e.g. a
+// -30% gap. An empty symbol moves all symbols.
+type Gap struct {
+	Symbol string
+	Time   time.Time
+	Change float64 // relative change of the prices, e.g. -0.3
+}
+
+// Apply implements the Scenario interface
+func (g Gap) Apply(e DataEventHandler) DataEventHandler {
+	if (g.Symbol != "" && e.GetSymbol() != g.Symbol) || e.GetTime().Before(g.Time) {
+		return e
+	}
+	return scaleEvent(e, 1+g.Change)
+}
+
+// VolatilityShock multiplies the returns of a symbol between Start and End by a factor,
+// e.g. 2 doubles the volatility. The price level reached at the end of the shock is kept
+// for the rest of the data. An empty symbol shocks all symbols.
+type VolatilityShock struct {
+	Symbol string
+	Start  time.Time
+	End    time.Time
+	Factor float64
+
+	last  map[string]float64 // last original price per symbol
+	scale map[string]float64 // current price scale per symbol
+}
+
+// Apply implements the Scenario interface
+func (v *VolatilityShock) Apply(e DataEventHandler) DataEventHandler {
+	if v.Symbol != "" && e.GetSymbol() != v.Symbol {
+		return e
+	}
+	if v.last == nil {
+		v.last = make(map[string]float64)
+		v.scale = make(map[string]float64)
+	}
+
+	symbol, price := e.GetSymbol(), e.LatestPrice()
+	scale, ok := v.scale[symbol]
+	if !ok {
+		scale = 1
+	}
+	last := v.last[symbol]
+	v.last[symbol] = price
+
+	t := e.GetTime()
+	if last != 0 && price != 0 && !t.Before(v.Start) && !t.After(v.End) {
+		// the stressed price moves by the factor times the original return
+		stressed := last * scale * (1 + v.Factor*(price/last-1))
+		scale = stressed / price
+	}
+	v.scale[symbol] = scale
+
+	if scale == 1 {
+		return e
+	}
+	return scaleEvent(e, scale)
+}
+
+// Reset implements the Reseter interface
+func (v *VolatilityShock) Reset() {
+	v.last = nil
+	v.scale = nil
+}
+
+// Outage is an exchange outage between Start and End, orders are neither filled
+// nor accepted
+type Outage struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Apply implements the Scenario interface and passes the data unchanged
+func (o Outage) Apply(e DataEventHandler) DataEventHandler {
+	return e
+}
+
+// Outage implements the OutageChecker interface
+func (o Outage) Outage(t time.Time) bool {
+	return !t.Before(o.Start) && !t.After(o.End)
+}
+
+// StressData overlays scenarios on the events of a data handler, so a test runs on the
+// stressed data. The scenarios are applied in order.
+type StressData struct {
+	DataHandler
+	scenarios []Scenario
+	latest    map[string]DataEventHandler
+	list      map[string][]DataEventHandler
+	history   []DataEventHandler
+
+	indicators *IndicatorCache
+}
+
+// NewStressData creates stress data of a data handler and scenarios
+func NewStressData(data DataHandler, scenarios ...Scenario) *StressData {
+	return &StressData{DataHandler: data, scenarios: scenarios}
+}
+
+// Next returns the next data event with the scenarios applied
+func (s *StressData) Next() (DataEventHandler, bool) {
+	e, ok := s.DataHandler.Next()
+	if !ok {
+		return e, false
+	}
+	for _, scenario := range s.scenarios {
+		e = scenario.Apply(e)
+	}
+
+	if s.latest == nil {
+		s.latest = make(map[string]DataEventHandler)
+		s.list = make(map[string][]DataEventHandler)
+	}
+	s.latest[e.GetSymbol()] = e
+	s.list[e.GetSymbol()] = append(s.list[e.GetSymbol()], e)
+	s.history = append(s.history, e)
+	return e, true
+}
+
+// History returns the streamed data events with the scenarios applied
+func (s *StressData) History() []DataEventHandler {
+	return s.history
+}
+
+// Latest returns the last stressed data event of a symbol
+func (s *StressData) Latest(symbol string) DataEventHandler {
+	return s.latest[symbol]
+}
+
+// List returns the stressed data events of a symbol
+func (s *StressData) List(symbol string) []DataEventHandler {
+	return s.list[symbol]
+}
+
+// SetIndicators implements the IndicatorSetter interface, the indicators are computed on
+// the stressed data events
+func (s *StressData) SetIndicators(c *IndicatorCache) {
+	s.indicators = c
+}
+
+// Indicators returns the indicator cache of the test
+func (s *StressData) Indicators() *IndicatorCache {
+	return s.indicators
+}
+
+// DatasetHash implements the DatasetHasher interface, the stressed events are identified by
+// the hash of the data and the type and exported fields of the scenarios in order
+func (s *StressData) DatasetHash() string {
+	h := sha256.New()
+	fmt.Fprintln(h, DatasetHash(s.DataHandler))
+	for _, scenario := range s.scenarios {
+		b, err := json.Marshal(scenario)
+		if err != nil {
+			// scenarios which can't be identified never match another dataset
+			id, _ := newRunID()
+			b = []byte(id)
+		}
+		fmt.Fprintf(h, "%T %s\n", scenario, b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Outage implements the OutageChecker interface and returns true if any scenario has an
+// exchange outage at the time
+func (s *StressData) Outage(t time.Time) bool {
+	for _, scenario := range s.scenarios {
+		if o, ok := scenario.(OutageChecker); ok && o.Outage(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset implements the Reseter interface and resets the data and the scenarios
+func (s *StressData) Reset() {
+	s.DataHandler.Reset()
+	s.latest = nil
+	s.list = nil
+	s.history = nil
+	for _, scenario := range s.scenarios {
+		if r, ok := scenario.(Reseter); ok {
+			r.Reset()
+		}
+	}
+}
+
+// scaleEvent returns a copy of a bar or tick with the prices scaled, other events are
+// returned unchanged
+func scaleEvent(e DataEventHandler, f float64) DataEventHandler {
+	switch ev := e.(type) {
+	case Bar:
+		return scaleBar(ev, f)
+	case *Bar:
+		bar := scaleBar(*ev, f)
+		return &bar
+	case Tick:
+		ev.Bid *= f
+		ev.Ask *= f
+		return ev
+	case *Tick:
+		tick := *ev
+		tick.Bid *= f
+		tick.Ask *= f
+		return &tick
+	}
+	return e
+}
+
+// scaleBar returns the bar with its prices scaled
+func scaleBar(b Bar, f float64) Bar {
+	b.Open *= f
+	b.High *= f
+	b.Low *= f
+	b.Close *= f
+	return b
+}
+
+// outage returns true if the exchange is down at the time of the event
+func (t *Test) outage(e EventHandler) bool {
+	o, ok := t.data.(OutageChecker)
+	return ok && o.Outage(e.GetTime())
+}
@@ -0,0 +1,158 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// TCARecord holds the transaction costs of a single fill. Slippage is the difference of
+// the fill price to the arrival price in the direction of the fill, positive values are
+// costs, negative values price improvements.
+type TCARecord struct {
+	Time         time.Time
+	Symbol       string
+	Direction    string // BOT or SLD
+	OrderType    string // MKT, LMT or STP
+	Qty          float64
+	ArrivalPrice float64 // market price when the order was created
+	FillPrice    float64
+	Slippage     float64 // slippage in currency
+	Fees         float64 // commission and exchange fees
+	Value        float64 // value of the fill at the arrival price
+}
+
+// SlippageBps returns the slippage in basis points of the value at arrival
+func (r TCARecord) SlippageBps() float64 {
+	return bps(r.Slippage, r.Value)
+}
+
+// FeesBps returns the fees in basis points of the value at arrival
+func (r TCARecord) FeesBps() float64 {
+	return bps(r.Fees, r.Value)
+}
+
+// TCAGroup sums up the transaction costs of a group of fills
+type TCAGroup struct {
+	Key         string
+	Fills       int
+	Value       float64 // value of the fills at the arrival price
+	Slippage    float64
+	Fees        float64
+	SlippageBps float64 // slippage in basis points of the value
+	FeesBps     float64 // fees in basis points of the value
+	TotalBps    float64 // slippage and fees in basis points of the value
+}
+
+// TCAReport summarizes the transaction costs in total and grouped by symbol,
+// direction and order type
+type TCAReport struct {
+	Total       TCAGroup
+	BySymbol    []TCAGroup
+	ByDirection []TCAGroup
+	ByOrderType []TCAGroup
+}
+
+// TCA returns the transaction costs of all fills. Fills without an arrival price
+// are measured against their own price.
+func (s Statistic) TCA() []TCARecord {
+	records := make([]TCARecord, 0, len(s.transactionHistory))
+	for _, f := range s.transactionHistory {
+		r := TCARecord{
+			Time:         f.GetTime(),
+			Symbol:       f.GetSymbol(),
+			Direction:    f.GetDirection(),
+			Qty:          f.GetQty(),
+			ArrivalPrice: f.GetPrice(),
+			FillPrice:    f.GetPrice(),
+			Fees:         f.GetCost(),
+		}
+		if a, ok := f.(ArrivalPricer); ok {
+			r.OrderType = a.GetOrderType()
+			if a.GetArrivalPrice() > 0 {
+				r.ArrivalPrice = a.GetArrivalPrice()
+			}
+		}
+
+		r.Value = r.ArrivalPrice * r.Qty
+		r.Slippage = (r.FillPrice - r.ArrivalPrice) * r.Qty
+		if r.Direction == "SLD" {
+			r.Slippage = -r.Slippage
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// TCAReport summarizes the transaction costs of all fills
+func (s Statistic) TCAReport() TCAReport {
+	records := s.TCA()
+	report := TCAReport{
+		Total:       TCAGroup{Key: "total"},
+		BySymbol:    tcaGroups(records, func(r TCARecord) string { return r.Symbol }),
+		ByDirection: tcaGroups(records, func(r TCARecord) string { return r.Direction }),
+		ByOrderType: tcaGroups(records, func(r TCARecord) string { return r.OrderType }),
+	}
+	if total := tcaGroups(records, func(TCARecord) string { return "total" }); len(total) > 0 {
+		report.Total = total[0]
+	}
+	return report
+}
+
+// WriteText writes the TCA report as aligned text tables
+func (t TCAReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	sections := []struct {
+		name   string
+		groups []TCAGroup
+	}{
+		{"Total", []TCAGroup{t.Total}},
+		{"Symbol", t.BySymbol},
+		{"Direction", t.ByDirection},
+		{"Order type", t.ByOrderType},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "%s\tFills\tValue\tSlippage bps\tFees bps\tTotal bps\t\n", section.name)
+		for _, g := range section.groups {
+			fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t\n", g.Key, g.Fills, g.Value, g.SlippageBps, g.FeesBps, g.TotalBps)
+		}
+		fmt.Fprintln(tw, "\t\t\t\t\t\t")
+	}
+	return tw.Flush()
+}
+
+// tcaGroups sums up the records by key, sorted by key
+func tcaGroups(records []TCARecord, key func(TCARecord) string) []TCAGroup {
+	byKey := make(map[string]*TCAGroup)
+	for _, r := range records {
+		k := key(r)
+		if _, ok := byKey[k]; !ok {
+			byKey[k] = &TCAGroup{Key: k}
+		}
+		g := byKey[k]
+		g.Fills++
+		g.Value += r.Value
+		g.Slippage += r.Slippage
+		g.Fees += r.Fees
+	}
+
+	groups := make([]TCAGroup, 0, len(byKey))
+	for _, g := range byKey {
+		g.SlippageBps = bps(g.Slippage, g.Value)
+		g.FeesBps = bps(g.Fees, g.Value)
+		g.TotalBps = bps(g.Slippage+g.Fees, g.Value)
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+// bps returns the amount in basis points of the value
+func bps(amount, value float64) float64 {
+	if value == 0 {
+		return 0
+	}
+	return amount / value * 10000
+}
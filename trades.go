@@ -0,0 +1,275 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Trade is a round-trip trade, a position opened by one fill and closed by another.
+// Fills are paired first in first out per symbol, a fill closing several lots
+// results in several trades.
+type Trade struct {
+	Symbol     string
+	Direction  string // long or short
+	EntryTime  time.Time
+	ExitTime   time.Time
+	Qty        float64
+	EntryPrice float64
+	ExitPrice  float64
+	Cost       float64 // the entry and exit cost of the traded qty
+	PnL        float64 // profit or loss net of cost
+	Return     float64 // PnL relative to the entry value
+}
+
+// TradeStats holds the performance statistics of the round-trip trades
+type TradeStats struct {
+	Trades       int
+	Wins         int
+	Losses       int
+	WinRate      float64
+	ProfitFactor float64 // gross profit divided by gross loss, +Inf without losses
+	AverageWin   float64
+	AverageLoss  float64
+	LargestWin   float64
+	LargestLoss  float64
+	Expectancy   float64 // average PnL per trade
+	Kelly        float64 // Kelly fraction of the win rate and payoff ratio
+	OptimalF     float64 // fraction of the largest loss maximizing the terminal wealth
+
+	AverageDuration    time.Duration // holding time from entry to exit
+	MedianDuration     time.Duration
+	MaxDuration        time.Duration
+	AverageTimeBetween time.Duration // time between the entries of consecutive trades
+}
+
+// tradeLot is an open part of a position waiting to be closed
+type tradeLot struct {
+	time      time.Time
+	direction string
+	qty       float64
+	price     float64
+	unitCost  float64
+}
+
+// Trades pairs the transactions into round-trip trades, trades still open are not included
+func (s Statistic) Trades() []Trade {
+	return pairTrades(s.transactionHistory)
+}
+
+// pairTrades pairs fills into round-trip trades first in first out per symbol
+func pairTrades(fills []FillEvent) []Trade {
+	var trades []Trade
+	open := make(map[string][]tradeLot)
+
+	for _, f := range fills {
+		if f.GetQty() <= 0 {
+			continue
+		}
+		symbol := f.GetSymbol()
+		qty := f.GetQty()
+		unitCost := f.GetCost() / f.GetQty()
+
+		lots := open[symbol]
+		for qty > 0 && len(lots) > 0 && lots[0].direction != f.GetDirection() {
+			lot := &lots[0]
+			closed := math.Min(qty, lot.qty)
+			trades = append(trades, newTrade(symbol, *lot, f, closed, unitCost))
+
+			lot.qty -= closed
+			qty -= closed
+			if lot.qty <= 0 {
+				lots = lots[1:]
+			}
+		}
+		if qty > 0 {
+			lots = append(lots, tradeLot{
+				time:      f.GetTime(),
+				direction: f.GetDirection(),
+				qty:       qty,
+				price:     f.GetPrice(),
+				unitCost:  unitCost,
+			})
+		}
+		open[symbol] = lots
+	}
+
+	return trades
+}
+
+// newTrade creates a trade closing qty of a lot with a fill
+func newTrade(symbol string, lot tradeLot, f FillEvent, qty, unitCost float64) Trade {
+	t := Trade{
+		Symbol:     symbol,
+		Direction:  "long",
+		EntryTime:  lot.time,
+		ExitTime:   f.GetTime(),
+		Qty:        qty,
+		EntryPrice: lot.price,
+		ExitPrice:  f.GetPrice(),
+		Cost:       (lot.unitCost + unitCost) * qty,
+	}
+
+	gross := (t.ExitPrice - t.EntryPrice) * qty
+	if lot.direction == "SLD" {
+		t.Direction = "short"
+		gross = -gross
+	}
+	t.PnL = gross - t.Cost
+	if entry := t.EntryPrice * qty; entry != 0 {
+		t.Return = t.PnL / entry
+	}
+
+	return t
+}
+
+// TradeStatistics calculates the performance statistics of the round-trip trades
+func (s Statistic) TradeStatistics() TradeStats {
+	var stats TradeStats
+	var grossProfit, grossLoss, total float64
+	var durations []time.Duration
+	var entries []time.Time
+
+	for _, t := range s.Trades() {
+		durations = append(durations, t.ExitTime.Sub(t.EntryTime))
+		entries = append(entries, t.EntryTime)
+
+		stats.Trades++
+		total += t.PnL
+		switch {
+		case t.PnL > 0:
+			stats.Wins++
+			grossProfit += t.PnL
+			stats.LargestWin = math.Max(stats.LargestWin, t.PnL)
+		case t.PnL < 0:
+			stats.Losses++
+			grossLoss -= t.PnL
+			stats.LargestLoss = math.Min(stats.LargestLoss, t.PnL)
+		}
+	}
+
+	if stats.Trades == 0 {
+		return stats
+	}
+
+	stats.WinRate = float64(stats.Wins) / float64(stats.Trades)
+	stats.Expectancy = total / float64(stats.Trades)
+	if stats.Wins > 0 {
+		stats.AverageWin = grossProfit / float64(stats.Wins)
+	}
+	if stats.Losses > 0 {
+		stats.AverageLoss = -grossLoss / float64(stats.Losses)
+	}
+
+	switch {
+	case grossLoss > 0:
+		stats.ProfitFactor = grossProfit / grossLoss
+	case grossProfit > 0:
+		stats.ProfitFactor = math.Inf(1)
+	}
+
+	switch {
+	case stats.Wins == 0:
+		stats.Kelly = 0
+	case stats.Losses == 0:
+		stats.Kelly = 1
+	default:
+		payoff := stats.AverageWin / -stats.AverageLoss
+		stats.Kelly = stats.WinRate - (1-stats.WinRate)/payoff
+	}
+	stats.OptimalF = optimalF(s.Trades(), stats.LargestLoss)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	stats.AverageDuration = sum / time.Duration(len(durations))
+	stats.MaxDuration = durations[len(durations)-1]
+	stats.MedianDuration = durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		stats.MedianDuration = (durations[len(durations)/2-1] + durations[len(durations)/2]) / 2
+	}
+
+	if len(entries) > 1 {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Before(entries[j]) })
+		stats.AverageTimeBetween = entries[len(entries)-1].Sub(entries[0]) / time.Duration(len(entries)-1)
+	}
+
+	return stats
+}
+
+// optimalF returns Ralph Vince's optimal f, the fraction f between 0 and 1 maximizing the
+// terminal wealth relative of the trades, each risking f of the equity per largest loss.
+// It returns 0 without losses or if no fraction grows the wealth.
+func optimalF(trades []Trade, largestLoss float64) float64 {
+	if largestLoss >= 0 {
+		return 0
+	}
+
+	best, bestTWR := 0.0, 0.0
+	for step := 1; step <= 100; step++ {
+		f := float64(step) / 100
+		// log of the terminal wealth relative, the largest loss with f = 1 is a total loss
+		var twr float64
+		for _, t := range trades {
+			hpr := 1 + f*t.PnL/-largestLoss
+			if hpr <= 0 {
+				twr = math.Inf(-1)
+				break
+			}
+			twr += math.Log(hpr)
+		}
+		if twr > bestTWR {
+			best, bestTWR = f, twr
+		}
+	}
+	return best
+}
+
+// SymbolStats holds the performance of a single symbol
+type SymbolStats struct {
+	Symbol      string
+	PnL         float64 // realized profit or loss of the round-trip trades net of cost
+	Trades      int
+	Wins        int
+	WinRate     float64
+	TradedValue float64 // value of all fills without cost
+	Cost        float64 // commission and fees of all fills
+}
+
+// SymbolStatistics breaks down the performance by symbol, sorted by symbol
+func (s Statistic) SymbolStatistics() []SymbolStats {
+	bySymbol := make(map[string]*SymbolStats)
+	get := func(symbol string) *SymbolStats {
+		if _, ok := bySymbol[symbol]; !ok {
+			bySymbol[symbol] = &SymbolStats{Symbol: symbol}
+		}
+		return bySymbol[symbol]
+	}
+
+	for _, f := range s.transactionHistory {
+		stats := get(f.GetSymbol())
+		stats.TradedValue += f.Value()
+		stats.Cost += f.GetCost()
+	}
+	for _, t := range s.Trades() {
+		stats := get(t.Symbol)
+		stats.Trades++
+		stats.PnL += t.PnL
+		if t.PnL > 0 {
+			stats.Wins++
+		}
+	}
+
+	list := make([]SymbolStats, 0, len(bySymbol))
+	for _, stats := range bySymbol {
+		if stats.Trades > 0 {
+			stats.WinRate = float64(stats.Wins) / float64(stats.Trades)
+		}
+		list = append(list, *stats)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+
+	return list
+}
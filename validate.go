@@ -0,0 +1,116 @@
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValidationError lists the problems of a test configuration
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid test: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the configuration of the test before it runs: all components are set,
+// the data has events for the symbols of the test and covers the warmup, the initial cash
+// is positive and the components implementing the Validator interface, like the exchange
+// and the strategy params, are valid. It is called by Run.
+func (t *Test) Validate() error {
+	var problems []string
+	for _, c := range []struct {
+		name  string
+		isSet bool
+	}{
+		{"data", t.data != nil},
+		{"strategy", t.strategy != nil},
+		{"portfolio", t.portfolio != nil},
+		{"exchange", t.exchange != nil},
+		{"statistic", t.statistic != nil},
+	} {
+		if !c.isSet {
+			problems = append(problems, fmt.Sprintf("no %s set", c.name))
+		}
+	}
+
+	// live data has its events only once it streams
+	if _, live := t.data.(Subscriber); t.data != nil && !live {
+		events := len(t.data.History()) + len(t.data.Stream())
+		if events == 0 {
+			problems = append(problems, "data has no events")
+		}
+
+		available := make(map[string]bool)
+		for _, symbol := range t.data.Symbols() {
+			available[symbol] = true
+		}
+		for _, symbol := range t.symbols {
+			if events > 0 && !available[symbol] {
+				problems = append(problems, fmt.Sprintf("data has no events for symbol %s", symbol))
+			}
+		}
+
+		if !t.resumed && t.warmupBars > 0 && t.warmupBars >= len(t.data.Stream()) {
+			problems = append(problems, fmt.Sprintf("warmup of %d bars covers all %d data events", t.warmupBars, len(t.data.Stream())))
+		}
+	}
+
+	if t.portfolio != nil {
+		if cash := t.portfolio.InitialCash(); !(cash > 0) {
+			problems = append(problems, fmt.Sprintf("initial cash must be positive, is %v", cash))
+		}
+	}
+
+	for _, c := range []struct {
+		name      string
+		component interface{}
+	}{
+		{"data", t.data},
+		{"strategy", t.strategy},
+		{"portfolio", t.portfolio},
+		{"exchange", t.exchange},
+	} {
+		if v, ok := c.component.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", c.name, err))
+			}
+		}
+	}
+	if p, ok := t.strategy.(Parameterizer); ok {
+		if err := ValidateParams(p); err != nil {
+			problems = append(problems, fmt.Sprintf("strategy params: %v", err))
+		}
+	}
+	for i, tm := range t.timers {
+		if tm.schedule == nil || tm.fn == nil {
+			problems = append(problems, fmt.Sprintf("timer %d: no schedule or function", i+1))
+			continue
+		}
+		if v, ok := tm.schedule.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("timer %d: %v", i+1, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate implements the Validator interface and checks the commission and fee settings
+func (e *Exchange) Validate() error {
+	if math.IsNaN(e.CommissionRate) || e.CommissionRate < 0 || e.CommissionRate >= 1 {
+		return fmt.Errorf("commission rate must be between 0 and 1, is %v", e.CommissionRate)
+	}
+	if math.IsNaN(e.ExchangeFee) || e.ExchangeFee < 0 {
+		return errors.New("exchange fee must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebsocketFeed is a feed streaming the data events of a websocket, e.g. the bar stream of
+// an exchange. A dropped connection is reconnected with a growing backoff.
+type WebsocketFeed struct {
+	URL    string
+	Header http.Header // header of the handshake, e.g. for authentication
+	// Subscribe returns the messages sent as JSON after connecting, none if nil
+	Subscribe func(symbols []string) []interface{}
+	// Parse converts a message to data events, ParseBarJSON if nil. Messages which can't
+	// be parsed are skipped.
+	Parse func([]byte) ([]DataEventHandler, error)
+	// Retries is the number of failed connects in a row before the feed fails, 5 if 0
+	Retries int
+
+	logger Logger
+}
+
+// SetLogger implements the LoggerSetter interface
+func (f *WebsocketFeed) SetLogger(l Logger) {
+	f.logger = l
+}
+
+// Stream implements the Feed interface
+func (f *WebsocketFeed) Stream(ctx context.Context, symbols []string, events chan<- DataEventHandler) error {
+	retries := f.Retries
+	if retries == 0 {
+		retries = 5
+	}
+	backoff := time.Second
+	var failures int
+	for {
+		received, err := f.stream(ctx, symbols, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if received {
+			failures, backoff = 0, time.Second
+		}
+		failures++
+		if failures > retries {
+			return fmt.Errorf("websocket %s: %v", f.URL, err)
+		}
+		logger(f.logger).Warn("websocket reconnecting", "url", f.URL, "error", err, "backoff", backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// stream connects to the websocket and sends its events until the connection drops, it
+// returns true if events were received
+func (f *WebsocketFeed) stream(ctx context.Context, symbols []string, events chan<- DataEventHandler) (received bool, err error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, f.URL, f.Header)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// close the connection when the context is done to end the blocking read
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if f.Subscribe != nil {
+		for _, msg := range f.Subscribe(symbols) {
+			if err := conn.WriteJSON(msg); err != nil {
+				return false, err
+			}
+		}
+	}
+	logger(f.logger).Info("websocket connected", "url", f.URL, "symbols", symbols)
+
+	parse := f.Parse
+	if parse == nil {
+		parse = ParseBarJSON
+	}
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return received, err
+		}
+		parsed, err := parse(msg)
+		if err != nil {
+			logger(f.logger).Warn("websocket message skipped", "error", err)
+			continue
+		}
+		for _, e := range parsed {
+			received = true
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+		}
+	}
+}
+
+// barJSON is a bar message, the time is a unix timestamp in seconds or a time string
+type barJSON struct {
+	Symbol string          `json:"symbol"`
+	Time   json.RawMessage `json:"time"`
+	Open   float64         `json:"open"`
+	High   float64         `json:"high"`
+	Low    float64         `json:"low"`
+	Close  float64         `json:"close"`
+	Volume float64         `json:"volume"`
+}
+
+// ParseBarJSON parses a bar or a list of bars as JSON objects with the fields symbol, time,
+// open, high, low, close and volume. Missing open, high and low prices take the close.
+func ParseBarJSON(b []byte) ([]DataEventHandler, error) {
+	var bars []barJSON
+	if err := json.Unmarshal(b, &bars); err != nil {
+		var bar barJSON
+		if err := json.Unmarshal(b, &bar); err != nil {
+			return nil, fmt.Errorf("invalid bar message: %v", err)
+		}
+		bars = []barJSON{bar}
+	}
+
+	events := make([]DataEventHandler, 0, len(bars))
+	for _, bar := range bars {
+		if bar.Symbol == "" {
+			return nil, errors.New("bar message without symbol")
+		}
+		t, err := parseCSVTime(unquote(bar.Time))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range []*float64{&bar.Open, &bar.High, &bar.Low} {
+			if *p == 0 {
+				*p = bar.Close
+			}
+		}
+		events = append(events, Bar{
+			Event:   Event{Time: t, Symbol: bar.Symbol},
+			BarData: BarData{Time: int(t.Unix()), Open: bar.Open, High: bar.High, Low: bar.Low, Close: bar.Close, Volume: bar.Volume},
+		})
+	}
+	return events, nil
+}
+
+// unquote returns a raw JSON string or number as string
+func unquote(raw json.RawMessage) string {
+	if s, err := strconv.Unquote(string(raw)); err == nil {
+		return s
+	}
+	return string(raw)
+}